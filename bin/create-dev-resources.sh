@@ -11,3 +11,11 @@ kafka-topics --bootstrap-server broker:9092 \
 kafka-topics --bootstrap-server broker:9092 \
              --create \
              --topic users
+
+kafka-topics --bootstrap-server broker:9092 \
+             --create \
+             --topic user-events
+
+kafka-topics --bootstrap-server broker:9092 \
+             --create \
+             --topic groups
@@ -0,0 +1,16 @@
+#!/bin/sh
+
+set -e
+
+# refresh the bundled list of disposable email domains from the community
+# maintained blocklist
+SOURCE=https://raw.githubusercontent.com/disposable-email-domains/disposable-email-domains/main/disposable_email_blocklist.conf
+TARGET=internal/emaildomain/disposable_domains.txt
+
+{
+  echo "# Disposable email domains, one per line. Regenerate with"
+  echo "# bin/update-disposable-domains.sh, or point DISPOSABLE_EMAIL_DOMAINS_FILE at"
+  echo "# a newer copy."
+  curl -sSf "$SOURCE" | tr 'A-Z' 'a-z' | grep -v '^#' | grep -v '^$' | sort -u
+} > "$TARGET.tmp"
+mv "$TARGET.tmp" "$TARGET"
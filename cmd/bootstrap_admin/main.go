@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/joho/godotenv"
+	_ "github.com/joho/godotenv/autoload"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"log"
+	"os"
+)
+
+// Promotes an existing user to a super-admin. Super-admins are the only users
+// allowed to create other admins through the API, so the first one has to be
+// created out of band.
+func main() {
+	_ = godotenv.Load()
+	if len(os.Args) < 2 {
+		log.Fatal("usage: bootstrap_admin <username>")
+	}
+	username := os.Args[1]
+	conn := db.CreateDefaultConnection()
+	userRepository := repository.CreateUserRepository(conn)
+	roleRepository := repository.CreateRoleRepository(conn)
+	user, err := userRepository.GetUserFromUsername(username)
+	if err != nil {
+		log.Fatal("no user found")
+	}
+	role, err := roleRepository.FindOneByName(string(model.ADMIN))
+	if err != nil {
+		log.Fatal(err)
+	}
+	user.IsSuperAdmin = true
+	user.RevokeSessions()
+	err = roleRepository.ReplaceUserRoles(user, []*entity.Role{role})
+	if err != nil {
+		log.Fatal(err)
+	}
+	println("done")
+}
@@ -5,10 +5,12 @@ import (
 	"github.com/rs/cors"
 	"github.com/third-place/user-service/internal"
 	"github.com/third-place/user-service/internal/middleware"
+	"github.com/third-place/user-service/internal/service"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 )
 
 func getServicePort() int {
@@ -23,17 +25,43 @@ func getServicePort() int {
 	return servicePort
 }
 
+// getHandler allows requests from any origin, unless CORS_ALLOWED_ORIGINS
+// lists the origins that may send session cookies.
+func getHandler(router http.Handler) http.Handler {
+	origins := strings.FieldsFunc(os.Getenv("CORS_ALLOWED_ORIGINS"), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(origins) == 0 {
+		return middleware.CorsMiddleware(
+			middleware.ContentTypeMiddleware(cors.AllowAll().Handler(router)),
+		)
+	}
+	return middleware.ContentTypeMiddleware(cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders:   []string{"*"},
+		AllowCredentials: true,
+	}).Handler(router))
+}
+
 func main() {
+	if _, err := service.LoadMailTemplates(); err != nil {
+		log.Fatal("error loading email templates :: ", err)
+	}
+	go service.CreateOutboxService().RunWorker()
 	router := internal.NewRouter()
-	handler := cors.AllowAll().Handler(router)
 	port := getServicePort()
 	log.Printf("Listening on %d", port)
 	log.Fatal(
 		http.ListenAndServe(
 			fmt.Sprintf(":%d", port),
-			middleware.CorsMiddleware(
-				middleware.ContentTypeMiddleware(handler),
-			),
+			getHandler(router),
 		),
 	)
 }
@@ -0,0 +1,10 @@
+// Package config bundles the files the service reads at runtime, so the
+// binary works without them on disk.
+package config
+
+import "embed"
+
+// Templates holds the email templates, one directory per locale.
+//
+//go:embed templates
+var Templates embed.FS
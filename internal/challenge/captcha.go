@@ -0,0 +1,65 @@
+package challenge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CaptchaVerifier checks captcha responses with a siteverify endpoint like
+// the ones hCaptcha and Cloudflare Turnstile provide.
+type CaptchaVerifier struct {
+	name      string
+	siteKey   string
+	secret    string
+	verifyURL string
+	client    *http.Client
+}
+
+func NewCaptchaVerifier(name string, siteKey string, secret string, verifyURL string) *CaptchaVerifier {
+	return &CaptchaVerifier{name, siteKey, secret, verifyURL, http.DefaultClient}
+}
+
+func (v *CaptchaVerifier) Type() string {
+	return v.name
+}
+
+func (v *CaptchaVerifier) Challenge(endpoint string) (*Challenge, error) {
+	return &Challenge{Type: v.name, SiteKey: v.siteKey}, nil
+}
+
+func (v *CaptchaVerifier) Verify(ctx context.Context, endpoint string, response string, remoteIp string) error {
+	if response == "" {
+		return ErrChallengeFailed
+	}
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {response},
+		"sitekey":  {v.siteKey},
+	}
+	if remoteIp != "" {
+		form.Set("remoteip", remoteIp)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	result := struct {
+		Success bool `json:"success"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return ErrChallengeFailed
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package challenge
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var captchaVerifyURLs = map[string]string{
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+const (
+	defaultPowDifficulty = 20
+	powLifetime          = 5 * time.Minute
+)
+
+// CreateRegistryFromEnv picks the verifier for each endpoint from
+// CHALLENGE_<ENDPOINT>, which is one of none, pow, hcaptcha or turnstile.
+// Captchas are configured with CHALLENGE_<TYPE>_SITE_KEY and
+// CHALLENGE_<TYPE>_SECRET, and the proof of work with
+// CHALLENGE_POW_DIFFICULTY. Endpoints default to none. An endpoint naming a
+// verifier that can't be built stops the service rather than leaving the
+// endpoint unprotected. Spent proofs of work are kept in the spent store.
+func CreateRegistryFromEnv(key []byte, spent SpentStore) *Registry {
+	registry := CreateRegistry(NewNoopVerifier())
+	verifiers := map[string]Verifier{}
+	for _, endpoint := range []string{EndpointSignUp, EndpointForgotPassword, EndpointWaitlist} {
+		name := strings.ToLower(strings.TrimSpace(os.Getenv("CHALLENGE_" + strings.ToUpper(endpoint))))
+		if name == "" || name == TypeNone {
+			continue
+		}
+		verifier, ok := verifiers[name]
+		if !ok {
+			verifier = createVerifier(name, key, spent)
+			if verifier == nil {
+				log.Fatal("challenge verifier is unknown or not configured :: ", endpoint, " :: ", name)
+			}
+			verifiers[name] = verifier
+		}
+		registry.Register(endpoint, verifier)
+	}
+	return registry
+}
+
+func createVerifier(name string, key []byte, spent SpentStore) Verifier {
+	if name == TypeProofOfWork {
+		difficulty, err := strconv.Atoi(getEnv(name, "DIFFICULTY", ""))
+		if err != nil {
+			difficulty = defaultPowDifficulty
+		}
+		return NewProofOfWorkVerifier(key, difficulty, powLifetime, spent)
+	}
+	verifyURL := getEnv(name, "VERIFY_URL", captchaVerifyURLs[name])
+	secret := getEnv(name, "SECRET", "")
+	if verifyURL == "" || secret == "" {
+		return nil
+	}
+	return NewCaptchaVerifier(name, getEnv(name, "SITE_KEY", ""), secret, verifyURL)
+}
+
+func getEnv(name string, key string, fallback string) string {
+	value, ok := os.LookupEnv("CHALLENGE_" + strings.ToUpper(name) + "_" + key)
+	if !ok {
+		return fallback
+	}
+	return value
+}
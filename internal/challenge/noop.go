@@ -0,0 +1,25 @@
+package challenge
+
+import "context"
+
+const TypeNone = "none"
+
+// NoopVerifier accepts every request. It's used when an endpoint isn't
+// protected, and in tests.
+type NoopVerifier struct{}
+
+func NewNoopVerifier() *NoopVerifier {
+	return &NoopVerifier{}
+}
+
+func (v *NoopVerifier) Type() string {
+	return TypeNone
+}
+
+func (v *NoopVerifier) Challenge(endpoint string) (*Challenge, error) {
+	return &Challenge{Type: TypeNone}, nil
+}
+
+func (v *NoopVerifier) Verify(ctx context.Context, endpoint string, response string, remoteIp string) error {
+	return nil
+}
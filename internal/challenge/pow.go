@@ -0,0 +1,102 @@
+package challenge
+
+import (
+	"context"
+	"crypto/sha256"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+const TypeProofOfWork = "pow"
+
+type powClaims struct {
+	Difficulty int `json:"difficulty"`
+	jwt.RegisteredClaims
+}
+
+// ProofOfWorkVerifier asks clients to spend some CPU time before calling an
+// endpoint, without needing an external service. The client is given a
+// signed token and has to find a nonce so that the SHA-256 hash of
+// "<token>:<nonce>" starts with Difficulty zero bits, then sends
+// "<token>:<nonce>" as its response.
+type ProofOfWorkVerifier struct {
+	key        []byte
+	difficulty int
+	lifetime   time.Duration
+	spent      SpentStore
+}
+
+func NewProofOfWorkVerifier(key []byte, difficulty int, lifetime time.Duration, spent SpentStore) *ProofOfWorkVerifier {
+	return &ProofOfWorkVerifier{
+		key:        key,
+		difficulty: difficulty,
+		lifetime:   lifetime,
+		spent:      spent,
+	}
+}
+
+func (v *ProofOfWorkVerifier) Type() string {
+	return TypeProofOfWork
+}
+
+func (v *ProofOfWorkVerifier) Challenge(endpoint string) (*Challenge, error) {
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &powClaims{
+		Difficulty: v.difficulty,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Audience:  jwt.ClaimStrings{endpoint},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(v.lifetime)),
+		},
+	}).SignedString(v.key)
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		Type:       TypeProofOfWork,
+		Token:      token,
+		Difficulty: v.difficulty,
+	}, nil
+}
+
+func (v *ProofOfWorkVerifier) Verify(ctx context.Context, endpoint string, response string, remoteIp string) error {
+	i := strings.LastIndex(response, ":")
+	if i < 0 {
+		return ErrChallengeFailed
+	}
+	claims := &powClaims{}
+	_, err := jwt.ParseWithClaims(response[:i], claims, func(token *jwt.Token) (interface{}, error) {
+		return v.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !claims.VerifyAudience(endpoint, true) || claims.ExpiresAt == nil {
+		return ErrChallengeFailed
+	}
+	if LeadingZeroBits([]byte(response)) < claims.Difficulty {
+		return ErrChallengeFailed
+	}
+	ok, err := v.spent.Spend(claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrChallengeFailed
+	}
+	return nil
+}
+
+// LeadingZeroBits counts the zero bits the SHA-256 hash of data starts with.
+func LeadingZeroBits(data []byte) int {
+	sum := sha256.Sum256(data)
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
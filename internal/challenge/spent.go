@@ -0,0 +1,11 @@
+package challenge
+
+import "time"
+
+// SpentStore remembers solved challenges until they expire, so a solution
+// can only be spent once. It has to be shared by every replica, or a
+// solution could be spent once on each of them.
+type SpentStore interface {
+	// Spend records the challenge and reports whether it wasn't spent yet.
+	Spend(id string, expiresAt time.Time) (bool, error)
+}
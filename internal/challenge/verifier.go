@@ -0,0 +1,59 @@
+package challenge
+
+import (
+	"context"
+	"errors"
+)
+
+// Endpoints that can ask clients to solve a challenge.
+const (
+	EndpointSignUp         = "signup"
+	EndpointForgotPassword = "forgot_password"
+	EndpointWaitlist       = "waitlist"
+)
+
+var ErrChallengeFailed = errors.New("challenge failed")
+
+// Challenge tells the client what it needs to solve before calling an
+// endpoint.
+type Challenge struct {
+	Type string
+	// SiteKey is the public key for a captcha widget.
+	SiteKey string
+	// Token and Difficulty describe a proof of work.
+	Token      string
+	Difficulty int
+}
+
+// Verifier checks that a client solved a challenge before it may call an
+// endpoint.
+type Verifier interface {
+	Type() string
+	// Challenge returns what the client needs to solve for the endpoint.
+	Challenge(endpoint string) (*Challenge, error)
+	// Verify checks the response the client sent with its request.
+	Verify(ctx context.Context, endpoint string, response string, remoteIp string) error
+}
+
+type Registry struct {
+	verifiers map[string]Verifier
+	fallback  Verifier
+}
+
+// CreateRegistry returns a registry that uses the fallback for endpoints
+// without a verifier of their own.
+func CreateRegistry(fallback Verifier) *Registry {
+	return &Registry{map[string]Verifier{}, fallback}
+}
+
+func (r *Registry) Register(endpoint string, verifier Verifier) {
+	r.verifiers[endpoint] = verifier
+}
+
+func (r *Registry) Get(endpoint string) Verifier {
+	verifier, ok := r.verifiers[endpoint]
+	if !ok {
+		return r.fallback
+	}
+	return verifier
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// CreateAccessTokenV1 - create a personal access token
+func CreateAccessTokenV1(c *gin.Context) {
+	newToken, err := model.DecodeRequestToNewAccessToken(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if err = util.RequireRecentAuth(session, service.RecentAuthMaxAge); err != nil {
+		c.JSON(http.StatusForbidden, err)
+		return
+	}
+	token, err := service.CreateAccessTokenService().CreateAccessToken(session, newToken)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+// GetAccessTokensV1 - get the session user's personal access tokens
+func GetAccessTokensV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	tokens, err := service.CreateAccessTokenService().GetAccessTokens(session)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeAccessTokenV1 - revoke a personal access token
+func RevokeAccessTokenV1(c *gin.Context) {
+	tokenUuid, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	err = service.CreateAccessTokenService().RevokeAccessToken(session, tokenUuid)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+	}
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// CheckAuthorizationV1 - decide whether a subject may perform an action
+func CheckAuthorizationV1(c *gin.Context) {
+	check, err := model.DecodeRequestToAuthzCheck(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	decision, err := service.CreateAuthzService().Check(session, check)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, decision)
+}
+
+// CheckAuthorizationBatchV1 - decide many actions for one subject at once
+func CheckAuthorizationBatchV1(c *gin.Context) {
+	batch, err := model.DecodeRequestToAuthzBatchCheck(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	decisions, err := service.CreateAuthzService().CheckBatch(session, batch)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, decisions)
+}
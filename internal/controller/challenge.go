@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// GetChallengeV1 - get the challenge to solve before calling an endpoint
+func GetChallengeV1(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	challenge, err := service.CreateChallengeService().GetChallenge(c.Param("endpoint"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, challenge)
+}
+
+func verifyChallenge(c *gin.Context, endpoint string) error {
+	return service.CreateChallengeService().Verify(
+		endpoint,
+		c.GetHeader("x-challenge-response"),
+		util.GetClientInfo(c),
+	)
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// GetEmailDomainRulesV1 - list blocked and allowed email domains
+func GetEmailDomainRulesV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	rules, err := service.CreateEmailDomainService().GetRules(session, offset)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// SetEmailDomainRuleV1 - block or allow an email domain
+func SetEmailDomainRuleV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	newRule, err := model.DecodeRequestToNewEmailDomainRule(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	rule, err := service.CreateEmailDomainService().SetRule(session, c.Param("domain"), newRule)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteEmailDomainRuleV1 - stop blocking or allowing an email domain
+func DeleteEmailDomainRuleV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	err = service.CreateEmailDomainService().DeleteRule(session, c.Param("domain"))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/mailer"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"io"
+	"net/http"
+)
+
+// SendGrid posts events in batches of up to about 1MB.
+const maxEmailEventPayload = 4 << 20
+
+// HandleSendGridEventsV1 - record bounces and spam reports from SendGrid's
+// event webhook
+func HandleSendGridEventsV1(c *gin.Context) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEmailEventPayload))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	err = service.CreateEmailEventService().HandleSendGridEvents(
+		payload,
+		c.GetHeader(mailer.SendGridSignatureHeader),
+		c.GetHeader(mailer.SendGridTimestampHeader),
+	)
+	if err != nil {
+		if errors.Is(err, service.ErrWebhookNotConfigured) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, mailer.ErrInvalidSignature) {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		// let SendGrid retry
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
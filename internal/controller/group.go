@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// CreateGroupV1 - create a new group owned by the session user
+func CreateGroupV1(c *gin.Context) {
+	groupModel, err := model.DecodeRequestToGroup(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	group, err := service.CreateGroupService().CreateGroup(session, groupModel)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateGroupV1 - update a group
+func UpdateGroupV1(c *gin.Context) {
+	groupUpdate, err := model.DecodeRequestToGroupUpdate(c.Request)
+	if err != nil || groupUpdate == nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	group, err := service.CreateGroupService().UpdateGroup(session, c.Param("slug"), groupUpdate)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// GetGroupV1 - get a group by slug
+func GetGroupV1(c *gin.Context) {
+	session, _ := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	group, err := service.CreateGroupService().GetGroup(session, c.Param("slug"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// GetGroupMembersV1 - get the members of a group
+func GetGroupMembersV1(c *gin.Context) {
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, _ := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	members, err := service.CreateGroupService().GetMembers(session, c.Param("slug"), offset)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// GetUserGroupsV1 - get the groups a user belongs to
+func GetUserGroupsV1(c *gin.Context) {
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, _ := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	groups, err := service.CreateGroupService().GetUserGroups(session, c.Param("username"), offset)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// AddGroupMemberV1 - add a user to a group
+func AddGroupMemberV1(c *gin.Context) {
+	newMember, err := model.DecodeRequestToNewGroupMember(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	member, err := service.CreateGroupService().AddMember(session, c.Param("slug"), newMember)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// InviteGroupMemberV1 - invite a user to a group
+func InviteGroupMemberV1(c *gin.Context) {
+	newMember, err := model.DecodeRequestToNewGroupMember(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	member, err := service.CreateGroupService().InviteMember(session, c.Param("slug"), newMember)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// JoinGroupV1 - accept an invitation or join a public group
+func JoinGroupV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	member, err := service.CreateGroupService().JoinGroup(session, c.Param("slug"))
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// LeaveGroupV1 - leave a group
+func LeaveGroupV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	err = service.CreateGroupService().LeaveGroup(session, c.Param("slug"))
+	if err != nil {
+		writeGroupError(c, err)
+	}
+}
+
+// RemoveGroupMemberV1 - remove a user from a group
+func RemoveGroupMemberV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	err = service.CreateGroupService().RemoveMember(session, c.Param("slug"), c.Param("username"))
+	if err != nil {
+		writeGroupError(c, err)
+	}
+}
+
+func writeGroupError(c *gin.Context, err error) {
+	if _, ok := err.(*util.InputFieldError); ok {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+	c.Status(http.StatusForbidden)
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// SocialAuthorizeV1 - get the url to sign in with an identity provider
+func SocialAuthorizeV1(c *gin.Context) {
+	authorization, err := service.CreateIdentityService().Authorize(c.Param("provider"), c.Query("invite_code"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	util.SetSocialNonceCookie(c, authorization.Nonce, service.SocialStateLifetime)
+	c.JSON(http.StatusOK, authorization)
+}
+
+// SocialCallbackV1 - sign in, sign up or link an account with the code from
+// an identity provider
+func SocialCallbackV1(c *gin.Context) {
+	callback, err := model.DecodeRequestToSocialCallback(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	callback.Client = util.GetClientInfo(c)
+	callback.Nonce = util.GetSocialNonceCookie(c)
+	util.ClearSocialNonceCookie(c)
+	var session *model.Session
+	if sessionToken := util.GetSessionTokenModel(c); sessionToken != nil {
+		session, err = service.CreateUserService().GetSession(sessionToken)
+		if err != nil {
+			c.Status(http.StatusForbidden)
+			return
+		}
+	}
+	result, err := service.CreateIdentityService().Callback(session, c.Param("provider"), callback)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		if _, ok := err.(*util.ReauthRequiredError); ok {
+			c.JSON(http.StatusForbidden, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if callback.UseCookie || util.IsCookieSession(c) {
+		moveSessionToCookies(c, result)
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetLinkedIdentitiesV1 - get the session user's linked identities
+func GetLinkedIdentitiesV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	identities, err := service.CreateIdentityService().GetLinkedIdentities(session)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, identities)
+}
+
+// UnlinkIdentityV1 - remove a linked identity
+func UnlinkIdentityV1(c *gin.Context) {
+	identityUuid, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	err = service.CreateIdentityService().Unlink(session, identityUuid)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusNotFound)
+	}
+}
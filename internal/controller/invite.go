@@ -2,10 +2,16 @@ package controller
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/model"
 	"github.com/third-place/user-service/internal/service"
 	"github.com/third-place/user-service/internal/util"
+	"io"
 	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,28 +21,27 @@ func init() {
 
 // CreateInviteV1 -- create new invites for new users
 func CreateInviteV1(c *gin.Context) {
-	userService := service.CreateUserService()
-	session, err := util.GetSession(c)
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
 	if err != nil {
 		c.Status(http.StatusForbidden)
 		return
 	}
-	code := util.GenerateCode()
-	attempt := 0
-	for {
-		_, err = userService.GetInvite(code)
-		if err.Error() == "no invite found" {
-			break
-		}
-		code = util.GenerateCode()
-		attempt += 1
-		if attempt > 5 {
-			c.Status(http.StatusInternalServerError)
-			return
-		}
+	newInvite, err := model.DecodeRequestToNewInvite(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	code, ok := generateInviteCode()
+	if !ok {
+		c.Status(http.StatusInternalServerError)
+		return
 	}
-	invite, err := userService.CreateInviteFromCode(session, code)
+	invite, err := service.CreateInviteService().CreateInvite(session, code, newInvite)
 	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
 		c.Status(http.StatusForbidden)
 		return
 	}
@@ -45,7 +50,7 @@ func CreateInviteV1(c *gin.Context) {
 
 // GetInvitesV1 -- get a list of invites
 func GetInvitesV1(c *gin.Context) {
-	session, err := util.GetSession(c)
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
 	offset, err := util.GetOffsetParam(c)
 	if err != nil {
 		c.Status(http.StatusBadRequest)
@@ -58,3 +63,305 @@ func GetInvitesV1(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, invites)
 }
+
+// RevokeInviteV1 -- revoke an invite so nobody else can sign up with it
+func RevokeInviteV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	err = service.CreateUserService().RevokeInvite(session, c.Param("code"))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetInviteesV1 -- get the users who signed up with a user's invites
+func GetInviteesV1(c *gin.Context) {
+	session, err := service.CreateUserService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	invitees, err := service.CreateInviteService().GetInvitees(session, c.Param("username"), offset)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, invitees)
+}
+
+// GetInviteTreeV1 -- get everyone a user let in, at any depth
+func GetInviteTreeV1(c *gin.Context) {
+	session, err := service.CreateUserService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	tree, err := service.CreateInviteService().GetInviteTree(session, c.Param("username"))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// BanUserTreeV1 -- ban a user along with everyone in their invite tree
+func BanUserTreeV1(c *gin.Context) {
+	session, err := service.CreateUserService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	result, err := service.CreateInviteService().BanUserTree(session, c.Param("username"))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetInviteQuotaV1 -- get how many invites the session user has left
+func GetInviteQuotaV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	quota, err := service.CreateInviteService().GetInviteQuota(session)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, quota)
+}
+
+// GetOwnInvitesV1 -- get the invites the session user created
+func GetOwnInvitesV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	invites, err := service.CreateInviteService().GetOwnInvites(session, offset)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, invites)
+}
+
+// GrantInvitesV1 -- give a user invites on top of their monthly allowance
+func GrantInvitesV1(c *gin.Context) {
+	newGrant, err := model.DecodeRequestToNewInviteGrant(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateUserService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	quota, err := service.CreateInviteService().GrantInvites(session, c.Param("username"), newGrant)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusCreated, quota)
+}
+
+// SendEmailInviteV1 -- send an invite to someone's email address
+func SendEmailInviteV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	emailInvite, err := model.DecodeRequestToEmailInvite(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	code, ok := generateInviteCode()
+	if !ok {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	invite, err := service.CreateInviteService().SendEmailInvite(session, code, emailInvite)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusCreated, invite)
+}
+
+// ResendEmailInviteV1 -- send a pending email invite again
+func ResendEmailInviteV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	invite, err := service.CreateInviteService().ResendEmailInvite(session, c.Param("code"))
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, invite)
+}
+
+// TrackInviteOpenV1 -- record that an email invite was opened
+func TrackInviteOpenV1(c *gin.Context) {
+	_, _ = service.CreateInviteService().TrackEmailInvite(c.Param("token"), enum.InviteStatusOpened)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/gif", trackingPixel)
+}
+
+// TrackInviteClickV1 -- record that an email invite's link was clicked, and
+// send the recipient on to sign up
+func TrackInviteClickV1(c *gin.Context) {
+	link, err := service.CreateInviteService().TrackEmailInvite(c.Param("token"), enum.InviteStatusClicked)
+	if err != nil {
+		link = signUpUrl
+	}
+	c.Redirect(http.StatusFound, link)
+}
+
+// CreateInviteBatchV1 -- generate many invites at once, like codes printed
+// for an event
+func CreateInviteBatchV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	newBatch, err := model.DecodeRequestToNewInviteBatch(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	batch, err := service.CreateInviteService().CreateInviteBatch(session, newBatch)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusCreated, batch)
+}
+
+// GetInviteBatchCsvV1 -- download the invites in a batch as CSV
+func GetInviteBatchCsvV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	batchUuid, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	batch, err := service.CreateInviteService().GetInviteBatch(session, batchUuid)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\"invites-"+batch.Uuid+".csv\"")
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	writeCsvRow(c.Writer, "code", "campaign", "max_uses", "uses", "expires_at")
+	for _, invite := range batch.Invites {
+		expiresAt := ""
+		if invite.ExpiresAt != nil {
+			expiresAt = invite.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		writeCsvRow(
+			c.Writer,
+			invite.Code,
+			invite.Campaign,
+			strconv.Itoa(invite.MaxUses),
+			strconv.Itoa(invite.Uses),
+			expiresAt,
+		)
+	}
+}
+
+// writeCsvRow writes every field quoted. Fields starting with = + - @, a tab
+// or a carriage return get a leading apostrophe, so spreadsheets show them
+// instead of running them as formulas.
+func writeCsvRow(w io.Writer, fields ...string) {
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+			field = "'" + field
+		}
+		quoted[i] = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+	}
+	_, _ = io.WriteString(w, strings.Join(quoted, ",")+"\r\n")
+}
+
+// GetCampaignStatsV1 -- count how the invites of a campaign were redeemed
+func GetCampaignStatsV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	stats, err := service.CreateInviteService().GetCampaignStats(session, c.Param("campaign"))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+const signUpUrl = "https://thirdplaceapp.com/signup/"
+
+// trackingPixel is a transparent 1x1 gif.
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00,
+	0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+	0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// generateInviteCode finds a code that isn't taken yet.
+func generateInviteCode() (string, bool) {
+	userService := service.CreateUserService()
+	for attempt := 0; attempt <= 5; attempt++ {
+		code := util.GenerateCode()
+		if _, err := userService.GetInvite(code); err != nil {
+			return code, true
+		}
+	}
+	return "", false
+}
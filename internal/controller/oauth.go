@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"net/http"
+)
+
+// CreateOAuthTokenV1 - issue a token through an OAuth2 client credentials grant
+func CreateOAuthTokenV1(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	request, err := model.DecodeRequestToOAuthTokenRequest(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewOAuthError(model.OAuthErrorInvalidRequest, err.Error()))
+		return
+	}
+	token, err := service.CreateServiceAccountService().IssueToken(request)
+	if err != nil {
+		oauthErr, ok := err.(*model.OAuthError)
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		if oauthErr.Code == model.OAuthErrorInvalidClient {
+			c.Header("WWW-Authenticate", "Basic")
+			c.JSON(http.StatusUnauthorized, oauthErr)
+			return
+		}
+		c.JSON(http.StatusBadRequest, oauthErr)
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// GetRegistrationV1 - get who can sign up right now
+func GetRegistrationV1(c *gin.Context) {
+	c.Header("Cache-Control", "max-age=30")
+	c.JSON(http.StatusOK, service.CreateRegistrationService().GetRegistration())
+}
+
+// UpdateRegistrationV1 - change who can sign up
+func UpdateRegistrationV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	update, err := model.DecodeRequestToRegistrationUpdate(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	registration, err := service.CreateRegistrationService().UpdateRegistration(session, update)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, registration)
+}
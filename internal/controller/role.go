@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// GetRolesV1 - get the list of roles and their permissions
+func GetRolesV1(c *gin.Context) {
+	_, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, service.CreateRoleService().GetRoles())
+}
+
+// CreateRoleV1 - create a custom role
+func CreateRoleV1(c *gin.Context) {
+	roleModel, err := model.DecodeRequestToRoleDefinition(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	role, err := service.CreateRoleService().CreateRole(session, roleModel)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRoleV1 - update a role's description, level and permissions
+func UpdateRoleV1(c *gin.Context) {
+	roleModel, err := model.DecodeRequestToRoleDefinition(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	role, err := service.CreateRoleService().UpdateRole(session, c.Param("name"), roleModel)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// CreateServiceAccountV1 - create a service account and its client credentials
+func CreateServiceAccountV1(c *gin.Context) {
+	newAccount, err := model.DecodeRequestToNewServiceAccount(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if err = util.RequireRecentAuth(session, service.RecentAuthMaxAge); err != nil {
+		c.JSON(http.StatusForbidden, err)
+		return
+	}
+	account, err := service.CreateServiceAccountService().CreateServiceAccount(session, newAccount)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusCreated, account)
+}
+
+// GetServiceAccountsV1 - get active service accounts
+func GetServiceAccountsV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	accounts, err := service.CreateServiceAccountService().GetServiceAccounts(session, offset)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// RotateServiceAccountSecretV1 - replace a service account's client secret
+func RotateServiceAccountSecretV1(c *gin.Context) {
+	accountUuid, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if err = util.RequireRecentAuth(session, service.RecentAuthMaxAge); err != nil {
+		c.JSON(http.StatusForbidden, err)
+		return
+	}
+	account, err := service.CreateServiceAccountService().RotateServiceAccountSecret(session, accountUuid)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// DisableServiceAccountV1 - disable a service account and revoke its tokens
+func DisableServiceAccountV1(c *gin.Context) {
+	accountUuid, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	err = service.CreateServiceAccountService().DisableServiceAccount(session, accountUuid)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+	}
+}
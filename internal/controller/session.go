@@ -4,6 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/third-place/user-service/internal/model"
 	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"log"
 	"net/http"
 )
 
@@ -14,32 +16,160 @@ func CreateNewSessionV1(c *gin.Context) {
 		c.Status(http.StatusBadRequest)
 		return
 	}
+	newSessionModel.Client = util.GetClientInfo(c)
 	result, err := service.CreateUserService().CreateSession(newSessionModel)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
+	if newSessionModel.UseCookie {
+		moveSessionToCookies(c, result)
+	}
 	c.JSON(http.StatusCreated, result)
 }
 
+// DeleteSessionV1 - log out, revoking the session token and clearing the
+// session cookies
+func DeleteSessionV1(c *gin.Context) {
+	err := service.CreateSessionService().Logout(util.GetSessionTokenModel(c))
+	if err != nil {
+		log.Print("error revoking session :: ", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	util.ClearSessionCookies(c)
+	c.Status(http.StatusNoContent)
+}
+
 // GetSessionV1 - validate a session token
 func GetSessionV1(c *gin.Context) {
 	sessionToken := model.DecodeRequestToSessionToken(c.Request)
-	session, err := service.CreateUserService().GetSession(sessionToken)
+	if sessionToken == nil {
+		sessionToken = util.GetSessionTokenModel(c)
+	}
+	userService := service.CreateUserService()
+	session, err := userService.GetSession(sessionToken)
 	if err != nil {
 		c.Status(http.StatusUnauthorized)
 		return
 	}
+	userService.SetEmailDeliveryStatus(session)
 	c.JSON(http.StatusOK, session)
 }
 
 // RefreshSessionV1 - refresh a session token
 func RefreshSessionV1(c *gin.Context) {
 	sessionToken := model.DecodeRequestToSessionToken(c.Request)
+	useCookie := sessionToken == nil && util.IsCookieSession(c)
+	if useCookie {
+		sessionToken = util.GetSessionTokenModel(c)
+	}
+	if sessionToken == nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
 	session, err := service.CreateUserService().RefreshSession(sessionToken)
 	if err != nil {
 		c.Status(http.StatusBadRequest)
 		return
 	}
+	if useCookie {
+		session.CsrfToken = util.SetSessionCookies(c, session.Token)
+		session.Token = ""
+	}
 	c.JSON(http.StatusOK, session)
 }
+
+// ImpersonateUserV1 - create a short-lived session acting as another user
+func ImpersonateUserV1(c *gin.Context) {
+	impersonation, err := model.DecodeRequestToImpersonation(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	impersonation.IpAddress = c.ClientIP()
+	userService := service.CreateUserService()
+	session, err := userService.GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	result, err := userService.ImpersonateUser(session, c.Param("username"), impersonation)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// ReauthenticateSessionV1 - enter the password again to get a freshly
+// authenticated session
+func ReauthenticateSessionV1(c *gin.Context) {
+	reauth, err := model.DecodeRequestToReauth(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	reauth.Client = util.GetClientInfo(c)
+	userService := service.CreateUserService()
+	session, err := userService.GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	result, err := userService.Reauthenticate(session, reauth)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if util.IsCookieSession(c) {
+		moveSessionToCookies(c, result)
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetLoginHistoryV1 - get the session user's recent login attempts
+func GetLoginHistoryV1(c *gin.Context) {
+	userService := service.CreateUserService()
+	session, err := userService.GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	history, err := userService.GetLoginHistory(session, offset)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// RevokeSessionsV1 - revoke every session of a user with the code from a new
+// sign-in notice
+func RevokeSessionsV1(c *gin.Context) {
+	revocation, err := model.DecodeRequestToSessionRevocation(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	err = service.CreateUserService().RevokeSessionsFromNotice(revocation)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+	}
+}
+
+// moveSessionToCookies keeps the session token out of reach of scripts by
+// setting it as a cookie instead of returning it.
+func moveSessionToCookies(c *gin.Context, session *model.Session) {
+	session.CsrfToken = util.SetSessionCookies(c, session.Token)
+	session.Token = ""
+}
@@ -2,7 +2,10 @@ package controller
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/challenge"
 	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/mapper"
 	"github.com/third-place/user-service/internal/model"
 	"github.com/third-place/user-service/internal/repository"
 	"github.com/third-place/user-service/internal/service"
@@ -18,6 +21,10 @@ func CreateNewUserV1(c *gin.Context) {
 		c.Status(http.StatusBadRequest)
 		return
 	}
+	if err = verifyChallenge(c, challenge.EndpointSignUp); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
 	user, err := service.CreateUserService().CreateUser(newUserModel)
 	if err != nil {
 		if _, ok := err.(*util.InputFieldError); ok {
@@ -36,14 +43,14 @@ func GetUserByUsernameV1(c *gin.Context) {
 	username := c.Param("username")
 	userService := service.CreateUserService()
 	token := util.GetSessionTokenModel(c)
-	var viewerUser *model.User = nil
+	var viewer *model.Session = nil
 	if token != nil {
 		session, err := userService.GetSession(token)
-		if err != nil {
-			viewerUser = session.User
+		if err == nil {
+			viewer = session
 		}
 	}
-	user, err := service.CreateUserService().GetUserFromUsername(viewerUser, username)
+	user, err := service.CreateUserService().GetUserFromUsername(viewer, username)
 	if err != nil {
 		c.Status(http.StatusNotFound)
 		return
@@ -65,12 +72,12 @@ func GetUsersV1(c *gin.Context) {
 		return
 	}
 	session, err := userService.GetSession(util.GetSessionTokenModel(c))
-	if err != nil || session.User.Role == model.USER {
+	if err != nil || !service.CreateSecurityService().Can(session, model.PermissionUserList, nil) {
 		c.Status(http.StatusForbidden)
 		return
 	}
 	c.Header("Cache-Control", "max-age=30")
-	users := service.CreateUserService().GetUsers(session.User, offset)
+	users := service.CreateUserService().GetUsers(session, offset)
 	c.JSON(http.StatusOK, users)
 }
 
@@ -96,6 +103,33 @@ func UpdateUserV1(c *gin.Context) {
 	c.JSON(http.StatusOK, userModel)
 }
 
+// ChangeEmailV1 - change the session user's email address
+func ChangeEmailV1(c *gin.Context) {
+	emailChange, err := model.DecodeRequestToEmailChange(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	userService := service.CreateUserService()
+	session, err := userService.GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if err = util.RequireRecentAuth(session, service.RecentAuthMaxAge); err != nil {
+		c.JSON(http.StatusForbidden, err)
+		return
+	}
+	err = userService.ChangeEmail(session, emailChange)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+	}
+}
+
 // BanUserV1 - ban a user
 func BanUserV1(c *gin.Context) {
 	usernameParam, success := c.Params.Get("username")
@@ -110,17 +144,12 @@ func BanUserV1(c *gin.Context) {
 		c.Status(http.StatusBadRequest)
 		return
 	}
-	sessionUser, err := userRepository.GetUserFromUsername(session.User.Username)
-	if err != nil || sessionUser.IsBanned {
-		c.Status(http.StatusBadRequest)
-		return
-	}
 	userEntity, err := userRepository.GetUserFromUsername(usernameParam)
 	if err != nil {
 		c.Status(http.StatusBadRequest)
 		return
 	}
-	err = userService.BanUser(sessionUser, userEntity)
+	err = userService.BanUser(session, userEntity)
 	if err != nil {
 		c.Status(http.StatusBadRequest)
 	}
@@ -137,20 +166,70 @@ func UnbanUserV1(c *gin.Context) {
 		c.Status(http.StatusBadRequest)
 		return
 	}
-	sessionUser, err := userRepository.GetUserFromUsername(session.User.Username)
-	if err != nil || sessionUser.IsBanned {
+	userEntity, err := userRepository.GetUserFromUsername(usernameParam)
+	if err != nil {
 		c.Status(http.StatusBadRequest)
 		return
 	}
-	userEntity, err := userRepository.GetUserFromUsername(usernameParam)
+	err = userService.UnbanUser(session, userEntity)
 	if err != nil {
 		c.Status(http.StatusBadRequest)
-		return
 	}
-	err = userService.UnbanUser(sessionUser, userEntity)
+}
+
+// ChangeUserRoleV1 - replace a user's roles with a single role
+func ChangeUserRoleV1(c *gin.Context) {
+	roleChange, err := model.DecodeRequestToRoleChange(c.Request)
 	if err != nil {
 		c.Status(http.StatusBadRequest)
+		return
 	}
+	updateUserRoles(c, func(userService *service.UserService, session *model.Session, userEntity *entity.User) error {
+		return userService.ChangeRole(session, userEntity, roleChange.Role)
+	})
+}
+
+// GrantUserRoleV1 - give a user an additional role
+func GrantUserRoleV1(c *gin.Context) {
+	role := model.Role(c.Param("role"))
+	updateUserRoles(c, func(userService *service.UserService, session *model.Session, userEntity *entity.User) error {
+		return userService.GrantRole(session, userEntity, role)
+	})
+}
+
+// RevokeUserRoleV1 - take a role away from a user
+func RevokeUserRoleV1(c *gin.Context) {
+	role := model.Role(c.Param("role"))
+	updateUserRoles(c, func(userService *service.UserService, session *model.Session, userEntity *entity.User) error {
+		return userService.RevokeRole(session, userEntity, role)
+	})
+}
+
+func updateUserRoles(c *gin.Context, update func(*service.UserService, *model.Session, *entity.User) error) {
+	usernameParam := c.Param("username")
+	userService := service.CreateUserService()
+	userRepository := repository.CreateUserRepository(db.CreateDefaultConnection())
+	sessionModel := util.GetSessionTokenModel(c)
+	session, err := userService.GetSession(sessionModel)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	userEntity, err := userRepository.GetUserFromUsername(usernameParam)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	err = update(userService, session, userEntity)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, mapper.MapUserEntityToModel(userEntity))
 }
 
 // SubmitOTPV1 - Submit a new OTP
@@ -175,6 +254,10 @@ func SubmitForgotPasswordV1(c *gin.Context) {
 		c.Status(http.StatusBadRequest)
 		return
 	}
+	if err = verifyChallenge(c, challenge.EndpointForgotPassword); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
 	err = service.CreateUserService().ForgotPassword(userModel)
 	if err != nil {
 		c.Status(http.StatusBadRequest)
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/challenge"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// JoinWaitlistV1 -- ask to join without an invite
+func JoinWaitlistV1(c *gin.Context) {
+	newEntry, err := model.DecodeRequestToNewWaitlistEntry(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	if err = verifyChallenge(c, challenge.EndpointWaitlist); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+	entry, err := service.CreateWaitlistService().JoinWaitlist(newEntry)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+// ConfirmWaitlistV1 -- confirm the email address of a waitlist entry
+func ConfirmWaitlistV1(c *gin.Context) {
+	confirmation, err := model.DecodeRequestToWaitlistConfirmation(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	entry, err := service.CreateWaitlistService().ConfirmWaitlistEntry(confirmation)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetWaitlistV1 -- list waitlist entries, oldest first
+func GetWaitlistV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	offset, err := util.GetOffsetParam(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	entries, err := service.CreateWaitlistService().GetWaitlist(session, c.Query("status"), offset)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// ApproveWaitlistV1 -- invite a batch of the oldest confirmed entries
+func ApproveWaitlistV1(c *gin.Context) {
+	session, err := service.CreateSessionService().GetSession(util.GetSessionTokenModel(c))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	approval, err := model.DecodeRequestToWaitlistApproval(c.Request)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	batch, err := service.CreateWaitlistService().ApproveWaitlistBatch(session, approval)
+	if err != nil {
+		if _, ok := err.(*util.InputFieldError); ok {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
@@ -59,12 +59,42 @@ func CreateConnection(host string, port string, dbname string, user string, pass
 			&entity.Password{},
 			&entity.Email{},
 			&entity.Invite{},
+			&entity.InviteGrant{},
+			&entity.InviteBatch{},
+			&entity.Permission{},
+			&entity.Role{},
+			&entity.Group{},
+			&entity.GroupMember{},
+			&entity.AccessToken{},
+			&entity.ServiceAccount{},
+			&entity.AuditLog{},
+			&entity.LoginAttempt{},
+			&entity.LinkedIdentity{},
+			&entity.WaitlistEntry{},
+			&entity.RegistrationSettings{},
+			&entity.EmailDomainRule{},
+			&entity.OutboxEmail{},
+			&entity.SpentChallenge{},
+			&entity.RevokedSession{},
 		)
 
 		if err != nil {
 			log.Fatal(err)
 		}
 
+		// users are looked up by email address regardless of case
+		_, err = sqlConnection.Exec("CREATE INDEX IF NOT EXISTS idx_users_lower_email ON users (LOWER(email));")
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = seedRoles(db)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		sqlConnection.SetMaxOpenConns(20)
 		sqlConnection.SetMaxIdleConns(5)
 		sqlConnection.SetConnMaxLifetime(time.Hour)
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var defaultRoles = []*model.RoleDefinition{
+	{
+		Name:        model.USER,
+		Description: "Default role for every member",
+		Level:       0,
+		Permissions: []model.Permission{},
+	},
+	{
+		Name:        model.MODERATOR,
+		Description: "Community moderator",
+		Level:       50,
+		Permissions: []model.Permission{
+			model.PermissionUserList,
+			model.PermissionUserReadPii,
+			model.PermissionUserBan,
+			model.PermissionInviteCreate,
+			model.PermissionInviteList,
+			model.PermissionInviteRevoke,
+		},
+	},
+	{
+		Name:        model.ADMIN,
+		Description: "Administrator",
+		Level:       100,
+		Permissions: model.Permissions,
+	},
+}
+
+// seedRoles makes sure every known permission and the built-in roles exist.
+// Built-in roles always keep their default permissions, but permissions added
+// to them by an admin are left alone. Users that predate role assignments get
+// the role recorded in their Role column. Service accounts are left without
+// roles, their scopes are all they can do.
+func seedRoles(conn *gorm.DB) error {
+	permissions := map[model.Permission]*entity.Permission{}
+	for _, name := range model.Permissions {
+		permission := &entity.Permission{}
+		err := conn.Where(entity.Permission{Name: string(name)}).FirstOrCreate(permission).Error
+		if err != nil {
+			return err
+		}
+		permissions[name] = permission
+	}
+	for _, definition := range defaultRoles {
+		role := &entity.Role{}
+		err := conn.Where(entity.Role{Name: string(definition.Name)}).
+			Attrs(entity.Role{Description: definition.Description, Level: definition.Level}).
+			FirstOrCreate(role).Error
+		if err != nil {
+			return err
+		}
+		rolePermissions := make([]*entity.Permission, len(definition.Permissions))
+		for i, name := range definition.Permissions {
+			rolePermissions[i] = permissions[name]
+		}
+		if len(rolePermissions) > 0 {
+			err = conn.Model(role).Association("Permissions").Append(rolePermissions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return conn.Exec(`INSERT INTO user_roles (user_id, role_id)
+		SELECT users.id, roles.id FROM users
+		JOIN roles ON roles.name = users.role
+		WHERE NOT users.is_service_account
+		AND NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)`).Error
+}
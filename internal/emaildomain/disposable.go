@@ -0,0 +1,63 @@
+package emaildomain
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+//go:embed disposable_domains.txt
+var bundledDisposableDomains string
+
+// DisposableList is a set of domains that hand out throwaway mailboxes.
+type DisposableList struct {
+	domains map[string]bool
+}
+
+// LoadDisposableList reads the file in DISPOSABLE_EMAIL_DOMAINS_FILE, so a
+// newer list can be mounted without a rebuild, and falls back to the list
+// bundled with the service.
+func LoadDisposableList() *DisposableList {
+	if path := os.Getenv("DISPOSABLE_EMAIL_DOMAINS_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err == nil {
+			defer file.Close()
+			return ParseDisposableList(file)
+		}
+		log.Print("error opening disposable email domains, using the bundled list :: ", err)
+	}
+	return ParseDisposableList(strings.NewReader(bundledDisposableDomains))
+}
+
+// ParseDisposableList reads one domain per line, skipping blank lines and
+// comments starting with #.
+func ParseDisposableList(reader io.Reader) *DisposableList {
+	list := &DisposableList{map[string]bool{}}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := Normalize(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list.domains[line] = true
+	}
+	return list
+}
+
+// Contains is true when the domain, or any domain it is a subdomain of, is
+// disposable.
+func (l *DisposableList) Contains(domain string) bool {
+	for _, candidate := range Candidates(domain) {
+		if l.domains[candidate] {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *DisposableList) Len() int {
+	return len(l.domains)
+}
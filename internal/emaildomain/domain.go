@@ -0,0 +1,50 @@
+package emaildomain
+
+import "strings"
+
+// FromEmail returns the normalized domain of the address, or an empty
+// string when it has none.
+func FromEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return Normalize(email[at+1:])
+}
+
+// Normalize lowercases the domain and strips what people tend to paste
+// along with it.
+func Normalize(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "@")
+	return strings.TrimSuffix(domain, ".")
+}
+
+// Candidates lists the domain followed by every domain it is a subdomain
+// of, most specific first, so that rules for a parent domain cover its
+// subdomains too.
+func Candidates(domain string) []string {
+	domain = Normalize(domain)
+	if domain == "" {
+		return []string{}
+	}
+	candidates := []string{domain}
+	for {
+		dot := strings.Index(domain, ".")
+		if dot < 0 || dot == len(domain)-1 {
+			return candidates
+		}
+		domain = domain[dot+1:]
+		candidates = append(candidates, domain)
+	}
+}
+
+// IsValid is true for normalized domains that look like a hostname with at
+// least two labels.
+func IsValid(domain string) bool {
+	if domain == "" || strings.ContainsAny(domain, "@ /\\") {
+		return false
+	}
+	dot := strings.Index(domain, ".")
+	return dot > 0 && dot < len(domain)-1
+}
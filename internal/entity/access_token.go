@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type AccessToken struct {
+	gorm.Model
+	Uuid       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserID     uint      `gorm:"index;not null"`
+	User       *User
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"not null"`
+	Hash       string `gorm:"unique;not null"`
+	Scopes     string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (t *AccessToken) IsValid() bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || t.ExpiresAt.After(time.Now())
+}
+
+// GetScopes returns nil when the token isn't restricted to any scopes. Tokens
+// are never created with an empty list of scopes, so an empty column always
+// means unrestricted.
+func (t *AccessToken) GetScopes() []model.Scope {
+	return model.ParseScopes(t.Scopes)
+}
+
+func (t *AccessToken) SetScopes(scopes []model.Scope) {
+	t.Scopes = model.JoinScopes(scopes)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+)
+
+// AuditLog records an action a user took on another user's account.
+type AuditLog struct {
+	gorm.Model
+	Uuid      uuid.UUID            `gorm:"type:uuid;default:uuid_generate_v4()"`
+	Action    enum.AuditActionType `gorm:"index;not null"`
+	ActorID   uint                 `gorm:"index;not null"`
+	Actor     *User
+	SubjectID uint `gorm:"index"`
+	Subject   *User
+	Reason    string
+	IpAddress string
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/third-place/user-service/internal/enum"
 	"gorm.io/gorm"
+	"time"
 )
 
 type Email struct {
@@ -12,11 +13,17 @@ type Email struct {
 	UserID         uint
 	Email          string `gorm:"unique;not null"`
 	VerifiedStatus string `gorm:"not null"`
+	// DeliveryStatus records addresses that bounced or reported our email as
+	// spam, from the mail provider's events.
+	DeliveryStatus   enum.EmailDeliveryStatusType `gorm:"not null;default:deliverable"`
+	DeliveryReason   string
+	DeliveryStatusAt *time.Time
 }
 
 func CreateEmail(email string) *Email {
 	return &Email{
 		Email:          email,
 		VerifiedStatus: string(enum.EmailStatusUnverified),
+		DeliveryStatus: enum.EmailDeliveryStatusDeliverable,
 	}
 }
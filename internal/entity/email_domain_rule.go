@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+)
+
+// EmailDomainRule blocks or allows addresses on a domain and its
+// subdomains.
+type EmailDomainRule struct {
+	gorm.Model
+	Domain      string                   `gorm:"uniqueIndex;not null"`
+	Rule        enum.EmailDomainRuleType `gorm:"not null"`
+	Reason      string
+	CreatedByID uint `gorm:"not null"`
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+)
+
+type Group struct {
+	gorm.Model
+	Uuid        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	Name        string    `gorm:"not null"`
+	Slug        string    `gorm:"unique;not null"`
+	Description string
+	OwnerID     uint
+	Owner       *User
+	Visibility  string `gorm:"not null;default:'public'"`
+}
+
+func (g *Group) IsPublic() bool {
+	return g.Visibility == string(enum.GroupVisibilityPublic)
+}
+
+type GroupMember struct {
+	gorm.Model
+	GroupID uint `gorm:"uniqueIndex:idx_group_member"`
+	Group   *Group
+	UserID  uint `gorm:"uniqueIndex:idx_group_member"`
+	User    *User
+	Role    string `gorm:"not null"`
+	Status  string `gorm:"not null"`
+}
+
+func (m *GroupMember) IsActive() bool {
+	return m.Status == string(enum.GroupMemberStatusActive)
+}
+
+// CanManage reports whether the member may change the group and its
+// membership.
+func (m *GroupMember) CanManage() bool {
+	return m.IsActive() &&
+		(m.Role == string(enum.GroupRoleAdmin) || m.Role == string(enum.GroupRoleOwner))
+}
+
+func (m *GroupMember) IsOwner() bool {
+	return m.IsActive() && m.Role == string(enum.GroupRoleOwner)
+}
+
+// CanAssignRole reports whether the member may give the role to the target,
+// who is nil when they have never been in the group. Owners may assign any
+// role. Admins must outrank both the role and the target.
+func (m *GroupMember) CanAssignRole(role string, target *GroupMember) bool {
+	if m.IsOwner() {
+		return true
+	}
+	if !m.CanManage() || groupRoleLevel(role) >= groupRoleLevel(m.Role) {
+		return false
+	}
+	return target == nil || groupRoleLevel(target.Role) < groupRoleLevel(m.Role)
+}
+
+func groupRoleLevel(role string) int {
+	switch enum.GroupRoleType(role) {
+	case enum.GroupRoleOwner:
+		return 2
+	case enum.GroupRoleAdmin:
+		return 1
+	}
+	return 0
+}
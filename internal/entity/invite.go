@@ -1,9 +1,88 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
 
 type Invite struct {
 	gorm.Model
 	Code    string `gorm:"unique;not null"`
 	Claimed bool
+	// CreatorID is the user who made the invite. Invites from before this
+	// was recorded have none.
+	CreatorID *uint `gorm:"index"`
+	// BatchID and Campaign are set on invites generated in bulk.
+	BatchID  *uint  `gorm:"index"`
+	Campaign string `gorm:"index"`
+	// Granted is set when the creator spent an invite an admin granted them,
+	// rather than their monthly allowance.
+	Granted bool `gorm:"not null;default:false"`
+	// ExpiresAt is when the invite stops working, if ever.
+	ExpiresAt *time.Time
+	// MaxUses is how many users can sign up with the invite. Community-wide
+	// codes allow many.
+	MaxUses int `gorm:"not null;default:1"`
+	Uses    int `gorm:"not null;default:0"`
+	// Email locks the invite to the one address it was sent to.
+	Email   string
+	Revoked bool
+	// Status and the fields below track invites sent by email.
+	Status            enum.InviteStatusType
+	TrackingTokenHash string `gorm:"index"`
+	SendCount         int    `gorm:"not null;default:0"`
+	SentAt            *time.Time
+	OpenedAt          *time.Time
+	ClickedAt         *time.Time
+	AcceptedAt        *time.Time
+}
+
+// IsPending is true for email invites the recipient hasn't accepted yet.
+func (i *Invite) IsPending() bool {
+	return i.Status == enum.InviteStatusSent ||
+		i.Status == enum.InviteStatusOpened ||
+		i.Status == enum.InviteStatusClicked
+}
+
+// Track records that the recipient opened the invite or clicked its link.
+// The status only moves forward, so a late open doesn't hide a click.
+func (i *Invite) Track(status enum.InviteStatusType) {
+	if !i.IsPending() {
+		return
+	}
+	now := time.Now()
+	switch status {
+	case enum.InviteStatusOpened:
+		if i.OpenedAt == nil {
+			i.OpenedAt = &now
+		}
+		if i.Status == enum.InviteStatusSent {
+			i.Status = status
+		}
+	case enum.InviteStatusClicked:
+		if i.OpenedAt == nil {
+			i.OpenedAt = &now
+		}
+		if i.ClickedAt == nil {
+			i.ClickedAt = &now
+		}
+		i.Status = status
+	case enum.InviteStatusAccepted:
+		i.AcceptedAt = &now
+		i.Status = status
+	}
+}
+
+func (i *Invite) IsExpired() bool {
+	return i.ExpiresAt != nil && i.ExpiresAt.Before(time.Now())
+}
+
+func (i *Invite) IsUsedUp() bool {
+	return i.Claimed || i.Uses >= i.MaxUses
+}
+
+func (i *Invite) IsFor(email string) bool {
+	return i.Email == "" || strings.EqualFold(i.Email, strings.TrimSpace(email))
 }
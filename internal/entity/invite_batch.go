@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// InviteBatch is a set of invites an admin generated at once, like codes
+// printed for a meetup.
+type InviteBatch struct {
+	gorm.Model
+	Uuid      uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	CreatorID uint      `gorm:"not null"`
+	// Campaign tags the invites so their redemptions can be counted
+	// together, across batches.
+	Campaign  string `gorm:"index"`
+	Count     int    `gorm:"not null"`
+	ExpiresAt *time.Time
+	MaxUses   int `gorm:"not null;default:1"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "gorm.io/gorm"
+
+// InviteGrant gives a user extra invites on top of their monthly allowance.
+type InviteGrant struct {
+	gorm.Model
+	UserID      uint `gorm:"index;not null"`
+	User        *User
+	Amount      int `gorm:"not null"`
+	Reason      string
+	GrantedByID uint `gorm:"not null"`
+}
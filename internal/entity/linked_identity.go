@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// LinkedIdentity is an account at an upstream identity provider the user
+// can sign in with.
+type LinkedIdentity struct {
+	gorm.Model
+	Uuid       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserID     uint      `gorm:"index;not null"`
+	User       *User
+	Provider   string `gorm:"uniqueIndex:idx_linked_identity_subject;not null"`
+	Subject    string `gorm:"uniqueIndex:idx_linked_identity_subject;not null"`
+	Email      string
+	LastUsedAt *time.Time
+}
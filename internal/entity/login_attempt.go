@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+	"time"
+)
+
+type LoginAttempt struct {
+	gorm.Model
+	Uuid      uuid.UUID            `gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserID    uint                 `gorm:"index;not null"`
+	Method    enum.LoginMethodType `gorm:"not null"`
+	Success   bool                 `gorm:"not null"`
+	IpAddress string
+	IpRange   string
+	UserAgent string
+	// DeviceFingerprint is a hash of the client's device id, or of its user
+	// agent when it doesn't send one.
+	DeviceFingerprint string
+	NewDevice         bool
+	// RevokeCodeHash is set when a new sign-in notice was sent. The code in
+	// the notice revokes every session of the user.
+	RevokeCodeHash string `gorm:"index"`
+	RevokedAt      *time.Time
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+	"time"
+)
+
+// OutboxEmail is an email waiting to be delivered, or the record of one that
+// was. It's saved in the same transaction as the change that caused it, and
+// rendered up front, so retries send exactly the same email. The body is
+// cleared once the email is done with, since it holds codes and links.
+type OutboxEmail struct {
+	gorm.Model
+	Uuid uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	// UserID is the recipient, when they have an account.
+	UserID   *uint  `gorm:"index"`
+	Template string `gorm:"not null"`
+	ToName   string
+	ToEmail  string `gorm:"not null"`
+	Subject  string
+	Text     string                `gorm:"type:text"`
+	Html     string                `gorm:"type:text"`
+	Status   enum.OutboxStatusType `gorm:"not null;default:pending"`
+	Attempts int                   `gorm:"not null;default:0"`
+	// NextAttemptAt is when a worker may try the email next. It's pushed back
+	// while a worker sends it, so a worker that dies mid-send doesn't lose it.
+	NextAttemptAt time.Time `gorm:"index;not null"`
+	LastError     string
+	SentAt        *time.Time
+}
+
+// ClearBody forgets what the email said, once it won't be sent again.
+func (e *OutboxEmail) ClearBody() {
+	e.Text = ""
+	e.Html = ""
+}
@@ -0,0 +1,8 @@
+package entity
+
+import "gorm.io/gorm"
+
+type Permission struct {
+	gorm.Model
+	Name string `gorm:"unique;not null"`
+}
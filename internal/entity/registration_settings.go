@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"github.com/third-place/user-service/internal/emaildomain"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+)
+
+// RegistrationSettings decide who can sign up. There is only ever one row,
+// so admins can change the mode without a redeploy.
+type RegistrationSettings struct {
+	gorm.Model
+	Mode enum.RegistrationModeType `gorm:"not null"`
+	// AllowedDomains can sign up without an invite in the
+	// open_with_domain_allowlist mode.
+	AllowedDomains []string `gorm:"serializer:json"`
+	UpdatedByID    *uint
+}
+
+// RequiresInvite is true when the email address can't sign up without an
+// invite in the current mode.
+func (r *RegistrationSettings) RequiresInvite(email string) bool {
+	switch r.Mode {
+	case enum.RegistrationModeOpen:
+		return false
+	case enum.RegistrationModeOpenWithDomainAllowlist:
+		return !r.AllowsDomain(email)
+	}
+	return true
+}
+
+// InviteRequired is true when an address that isn't on an allowed domain
+// can't sign up without an invite, so clients know to ask for one.
+func (r *RegistrationSettings) InviteRequired() bool {
+	return r.RequiresInvite("")
+}
+
+// AllowsDomain is true when the address is on one of the allowed domains.
+// Subdomains have to be allowed on their own.
+func (r *RegistrationSettings) AllowsDomain(email string) bool {
+	domain := emaildomain.FromEmail(email)
+	for _, allowed := range r.AllowedDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// RevokedSession is a session token that was logged out before it expired.
+// It is deleted once it expires, since the token is rejected by then anyway.
+type RevokedSession struct {
+	TokenHash string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
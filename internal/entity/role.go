@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type Role struct {
+	gorm.Model
+	Uuid        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	Name        string    `gorm:"unique;not null"`
+	Description string
+	Level       int           `gorm:"not null;default:0"`
+	Permissions []*Permission `gorm:"many2many:role_permissions"`
+}
+
+func (r *Role) HasPermission(permission model.Permission) bool {
+	for _, p := range r.Permissions {
+		if p.Name == string(permission) {
+			return true
+		}
+	}
+	return false
+}
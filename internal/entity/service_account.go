@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ServiceAccount holds the client credentials of a service account user.
+type ServiceAccount struct {
+	gorm.Model
+	Uuid       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserID     uint      `gorm:"unique;not null"`
+	User       *User
+	Name       string `gorm:"not null"`
+	ClientId   string `gorm:"unique;not null"`
+	SecretHash string `gorm:"not null"`
+	Scopes     string `gorm:"not null"`
+	CreatedBy  uint
+	DisabledAt *time.Time
+}
+
+func (s *ServiceAccount) IsActive() bool {
+	return s.DisabledAt == nil
+}
+
+func (s *ServiceAccount) GetScopes() []model.Scope {
+	return model.ParseScopes(s.Scopes)
+}
+
+func (s *ServiceAccount) SetScopes(scopes []model.Scope) {
+	s.Scopes = model.JoinScopes(scopes)
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// SpentChallenge is a solved challenge that can't be used again. It is
+// deleted once it expires, since the challenge is rejected by then anyway.
+type SpentChallenge struct {
+	ID        string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
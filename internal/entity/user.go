@@ -5,29 +5,43 @@ import (
 	"github.com/google/uuid"
 	"github.com/third-place/user-service/internal/model"
 	"gorm.io/gorm"
+	"time"
 )
 
 type User struct {
 	gorm.Model
-	Uuid          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
-	CognitoId     uuid.UUID
-	Name          string
-	Username      string `gorm:"unique;not null"`
-	ProfilePic    string
-	BioMessage    string
-	Role          string `gorm:"default:'user'"`
-	IsBanned      bool   `gorm:"default:false"`
-	AddressStreet string
-	AddressCity   string
-	AddressZip    string
-	Email         string `gorm:"unique;not null"`
-	Password      string `gorm:"null"`
-	Birthday      string
-	Verified      bool `gorm:"not null"`
-	InviteID      uint
-	OTP           string
-	Emails        []*Email
-	Passwords     []*Password
+	Uuid         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()"`
+	CognitoId    uuid.UUID
+	Name         string
+	Username     string `gorm:"unique;not null"`
+	ProfilePic   string
+	BioMessage   string
+	Role         string `gorm:"default:'user'"`
+	IsSuperAdmin bool   `gorm:"default:false"`
+	IsBanned     bool   `gorm:"default:false"`
+	// IsServiceAccount marks a principal used by another service. Service
+	// accounts authenticate with client credentials and never log in.
+	IsServiceAccount bool `gorm:"default:false"`
+	AddressStreet    string
+	AddressCity      string
+	AddressZip       string
+	Email            string `gorm:"unique;not null"`
+	Password         string `gorm:"null"`
+	Birthday         string
+	Verified         bool `gorm:"not null"`
+	InviteID         uint
+	// AdmittedByDomain marks a user who signed up without an invite because
+	// their address is on an allowed domain. They can't log in until the
+	// address is verified, so typing someone else's address gets nobody in.
+	AdmittedByDomain bool `gorm:"default:false"`
+	OTP              string
+	// Locale picks the language of emails to the user, like en or pt-br.
+	Locale string
+	// SessionsRevokedAt invalidates every session token issued before it.
+	SessionsRevokedAt *time.Time
+	Roles             []*Role `gorm:"many2many:user_roles"`
+	Emails            []*Email
+	Passwords         []*Password
 }
 
 func (u *User) UpdateUserFromModel(user *model.User) {
@@ -37,9 +51,66 @@ func (u *User) UpdateUserFromModel(user *model.User) {
 	if user.ProfilePic != "" {
 		u.ProfilePic = user.ProfilePic
 	}
+	if user.Locale != "" {
+		u.Locale = user.Locale
+	}
 }
 
 func (u *User) ToJson() []byte {
 	data, _ := json.Marshal(u)
 	return data
 }
+
+func (u *User) RevokeSessions() {
+	now := time.Now()
+	u.SessionsRevokedAt = &now
+}
+
+// IsSessionRevoked reports whether the token was issued before the user's
+// sessions were revoked. Issue times are kept to the millisecond, and a token
+// issued in the same millisecond as the revocation counts as revoked.
+func (u *User) IsSessionRevoked(claims *model.Claims) bool {
+	if u.SessionsRevokedAt == nil {
+		return false
+	}
+	if claims.IssuedAt == nil {
+		return true
+	}
+	return claims.IssuedAt.Time.Before(u.SessionsRevokedAt.Truncate(time.Millisecond).Add(time.Millisecond))
+}
+
+// Level is the level of the user's highest role. Roles must be loaded.
+func (u *User) Level() int {
+	level := 0
+	for _, role := range u.Roles {
+		if role.Level > level {
+			level = role.Level
+		}
+	}
+	return level
+}
+
+// HasPermission reports whether any of the user's roles grants the
+// permission. Roles and their permissions must be loaded.
+func (u *User) HasPermission(permission model.Permission) bool {
+	for _, role := range u.Roles {
+		if role.HasPermission(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRoles replaces the user's roles and keeps the Role column, which is
+// still published to other services, pointed at the highest one.
+func (u *User) SetRoles(roles []*Role) {
+	u.Roles = roles
+	u.Role = string(model.USER)
+	level := -1
+	for _, role := range roles {
+		if role.Level > level {
+			level = role.Level
+			u.Role = role.Name
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+	"time"
+)
+
+// WaitlistEntry is someone without an invite asking to join.
+type WaitlistEntry struct {
+	gorm.Model
+	Email  string `gorm:"uniqueIndex;not null"`
+	Reason string
+	Locale string
+	Status enum.WaitlistStatusType `gorm:"index;not null"`
+	// ConfirmationCodeHash is the hash of the code in the confirmation
+	// email, which proves the address belongs to whoever signed up.
+	ConfirmationCodeHash string `gorm:"index"`
+	ConfirmedAt          *time.Time
+	// InviteID is the invite sent when an admin approved the entry.
+	InviteID     *uint
+	ApprovedByID *uint
+	InvitedAt    *time.Time
+}
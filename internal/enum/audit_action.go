@@ -0,0 +1,7 @@
+package enum
+
+type AuditActionType string
+
+const (
+	AuditActionImpersonationStarted AuditActionType = "impersonation.started"
+)
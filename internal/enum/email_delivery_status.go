@@ -0,0 +1,16 @@
+package enum
+
+// EmailDeliveryStatusType is whether mail to an address gets through.
+type EmailDeliveryStatusType string
+
+const (
+	EmailDeliveryStatusDeliverable EmailDeliveryStatusType = "deliverable"
+	// EmailDeliveryStatusBounced addresses hard-bounced.
+	EmailDeliveryStatusBounced EmailDeliveryStatusType = "bounced"
+	// EmailDeliveryStatusComplained addresses reported our email as spam.
+	EmailDeliveryStatusComplained EmailDeliveryStatusType = "complained"
+)
+
+func (s EmailDeliveryStatusType) IsUndeliverable() bool {
+	return s == EmailDeliveryStatusBounced || s == EmailDeliveryStatusComplained
+}
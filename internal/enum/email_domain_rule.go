@@ -0,0 +1,15 @@
+package enum
+
+// EmailDomainRuleType is what an admin decided about an email domain.
+type EmailDomainRuleType string
+
+const (
+	EmailDomainRuleBlock EmailDomainRuleType = "block"
+	// EmailDomainRuleAllow lets a domain through even when it is on the
+	// disposable list, or below a blocked domain.
+	EmailDomainRuleAllow EmailDomainRuleType = "allow"
+)
+
+func (r EmailDomainRuleType) IsValid() bool {
+	return r == EmailDomainRuleBlock || r == EmailDomainRuleAllow
+}
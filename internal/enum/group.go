@@ -0,0 +1,23 @@
+package enum
+
+type GroupVisibilityType string
+
+const (
+	GroupVisibilityPublic  GroupVisibilityType = "public"
+	GroupVisibilityPrivate GroupVisibilityType = "private"
+)
+
+type GroupRoleType string
+
+const (
+	GroupRoleMember GroupRoleType = "member"
+	GroupRoleAdmin  GroupRoleType = "admin"
+	GroupRoleOwner  GroupRoleType = "owner"
+)
+
+type GroupMemberStatusType string
+
+const (
+	GroupMemberStatusInvited GroupMemberStatusType = "invited"
+	GroupMemberStatusActive  GroupMemberStatusType = "active"
+)
@@ -0,0 +1,13 @@
+package enum
+
+// InviteStatusType tracks an invite sent by email. Invites handed out as
+// bare codes have no status.
+type InviteStatusType string
+
+const (
+	InviteStatusSent      InviteStatusType = "sent"
+	InviteStatusOpened    InviteStatusType = "opened"
+	InviteStatusClicked   InviteStatusType = "clicked"
+	InviteStatusAccepted  InviteStatusType = "accepted"
+	InviteStatusCancelled InviteStatusType = "cancelled"
+)
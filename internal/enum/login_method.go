@@ -0,0 +1,9 @@
+package enum
+
+type LoginMethodType string
+
+const (
+	LoginMethodPassword LoginMethodType = "password"
+	LoginMethodReauth   LoginMethodType = "reauth"
+	LoginMethodSocial   LoginMethodType = "social"
+)
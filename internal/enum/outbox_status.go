@@ -0,0 +1,21 @@
+package enum
+
+// OutboxStatusType is where an outgoing email is in its delivery.
+type OutboxStatusType string
+
+const (
+	OutboxStatusPending OutboxStatusType = "pending"
+	OutboxStatusSent    OutboxStatusType = "sent"
+	// OutboxStatusFailed emails ran out of attempts and won't be retried.
+	OutboxStatusFailed OutboxStatusType = "failed"
+	// OutboxStatusSuppressed emails weren't sent because the address bounced
+	// or reported our email as spam.
+	OutboxStatusSuppressed OutboxStatusType = "suppressed"
+)
+
+func (s OutboxStatusType) IsValid() bool {
+	return s == OutboxStatusPending ||
+		s == OutboxStatusSent ||
+		s == OutboxStatusFailed ||
+		s == OutboxStatusSuppressed
+}
@@ -0,0 +1,20 @@
+package enum
+
+// RegistrationModeType decides who can create an account.
+type RegistrationModeType string
+
+const (
+	RegistrationModeInviteOnly RegistrationModeType = "invite_only"
+	RegistrationModeOpen       RegistrationModeType = "open"
+	// RegistrationModeOpenWithDomainAllowlist lets addresses on allowed
+	// domains sign up without an invite. Everyone else still needs one.
+	RegistrationModeOpenWithDomainAllowlist RegistrationModeType = "open_with_domain_allowlist"
+	RegistrationModeClosed                  RegistrationModeType = "closed"
+)
+
+func (m RegistrationModeType) IsValid() bool {
+	return m == RegistrationModeInviteOnly ||
+		m == RegistrationModeOpen ||
+		m == RegistrationModeOpenWithDomainAllowlist ||
+		m == RegistrationModeClosed
+}
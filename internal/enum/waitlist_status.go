@@ -0,0 +1,18 @@
+package enum
+
+// WaitlistStatusType is how far a waitlist entry got towards an invite.
+type WaitlistStatusType string
+
+const (
+	// WaitlistStatusUnconfirmed entries haven't followed the confirmation
+	// link yet, and can't be approved.
+	WaitlistStatusUnconfirmed WaitlistStatusType = "unconfirmed"
+	WaitlistStatusConfirmed   WaitlistStatusType = "confirmed"
+	WaitlistStatusInvited     WaitlistStatusType = "invited"
+)
+
+func (s WaitlistStatusType) IsValid() bool {
+	return s == WaitlistStatusUnconfirmed ||
+		s == WaitlistStatusConfirmed ||
+		s == WaitlistStatusInvited
+}
@@ -0,0 +1,88 @@
+package identity
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+var presets = map[string]*Config{
+	"google": {
+		AuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:    "https://oauth2.googleapis.com/token",
+		UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:      []string{"openid", "email", "profile"},
+	},
+	"github": {
+		AuthURL:  "https://github.com/login/oauth/authorize",
+		TokenURL: "https://github.com/login/oauth/access_token",
+		Scopes:   []string{"read:user", "user:email"},
+	},
+	"apple": {
+		AuthURL:    "https://appleid.apple.com/auth/authorize",
+		TokenURL:   "https://appleid.apple.com/auth/token",
+		Issuer:     "https://appleid.apple.com",
+		JwksURL:    "https://appleid.apple.com/auth/keys",
+		Scopes:     []string{"name", "email"},
+		AuthParams: map[string]string{"response_mode": "form_post"},
+	},
+}
+
+const gitHubApiURL = "https://api.github.com"
+
+// CreateRegistryFromEnv sets up the providers named in IDENTITY_PROVIDERS,
+// a comma separated list. Each is configured with IDP_<NAME>_* variables,
+// which override the presets for google, github and apple. Other names are
+// treated as generic OpenID Connect providers.
+func CreateRegistryFromEnv() *Registry {
+	registry := CreateRegistry()
+	for _, name := range strings.Split(os.Getenv("IDENTITY_PROVIDERS"), ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		config := loadConfig(name)
+		if config.ClientId == "" || config.TokenURL == "" {
+			log.Print("identity provider is missing configuration :: ", name)
+			continue
+		}
+		if name == "github" {
+			registry.Register(NewGitHubProvider(config, getEnv(name, "API_URL", gitHubApiURL)))
+			continue
+		}
+		registry.Register(NewOAuth2Provider(config))
+	}
+	return registry
+}
+
+func loadConfig(name string) *Config {
+	preset, ok := presets[name]
+	if !ok {
+		preset = &Config{Scopes: []string{"openid", "email", "profile"}}
+	}
+	config := &Config{
+		Name:         name,
+		ClientId:     getEnv(name, "CLIENT_ID", ""),
+		ClientSecret: getEnv(name, "CLIENT_SECRET", ""),
+		AuthURL:      getEnv(name, "AUTH_URL", preset.AuthURL),
+		TokenURL:     getEnv(name, "TOKEN_URL", preset.TokenURL),
+		RedirectURL:  getEnv(name, "REDIRECT_URL", "https://thirdplaceapp.com/social/"+name+"/callback"),
+		Scopes:       preset.Scopes,
+		AuthParams:   preset.AuthParams,
+		UserInfoURL:  getEnv(name, "USERINFO_URL", preset.UserInfoURL),
+		Issuer:       getEnv(name, "ISSUER", preset.Issuer),
+		JwksURL:      getEnv(name, "JWKS_URL", preset.JwksURL),
+	}
+	if scopes := getEnv(name, "SCOPES", ""); scopes != "" {
+		config.Scopes = strings.Fields(scopes)
+	}
+	return config
+}
+
+func getEnv(name string, key string, fallback string) string {
+	value, ok := os.LookupEnv("IDP_" + strings.ToUpper(name) + "_" + key)
+	if !ok {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package identity
+
+import (
+	"context"
+	"strconv"
+)
+
+// GitHubProvider signs users in with GitHub, which is OAuth2 but not OpenID
+// Connect, so the user is read from its REST API.
+type GitHubProvider struct {
+	*OAuth2Provider
+	apiURL string
+}
+
+func NewGitHubProvider(config *Config, apiURL string) *GitHubProvider {
+	return &GitHubProvider{NewOAuth2Provider(config), apiURL}
+}
+
+type gitHubUser struct {
+	Id    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type gitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code string) (*Identity, error) {
+	token, err := p.oauth2.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	client := p.oauth2.Client(ctx, token)
+	user := &gitHubUser{}
+	err = getJson(ctx, client, p.apiURL+"/user", user)
+	if err != nil {
+		return nil, err
+	}
+	var emails []*gitHubEmail
+	err = getJson(ctx, client, p.apiURL+"/user/emails", &emails)
+	if err != nil {
+		return nil, err
+	}
+	identity := &Identity{
+		Subject: strconv.FormatInt(user.Id, 10),
+		Name:    user.Name,
+	}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,216 @@
+package identity
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+	"math/big"
+	"net/http"
+)
+
+type Config struct {
+	Name         string
+	ClientId     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	RedirectURL  string
+	Scopes       []string
+	// AuthParams are added to the AuthCodeURL.
+	AuthParams map[string]string
+	// UserInfoURL is queried with the access token for the user's claims.
+	UserInfoURL string
+	// Issuer and JwksURL are used instead of UserInfoURL for providers that
+	// only return the user's claims in an ID token, like Apple.
+	Issuer  string
+	JwksURL string
+}
+
+// OAuth2Provider signs users in with the authorization code flow of an
+// OAuth2 or OpenID Connect provider.
+type OAuth2Provider struct {
+	config *Config
+	oauth2 *oauth2.Config
+}
+
+func NewOAuth2Provider(config *Config) *OAuth2Provider {
+	return &OAuth2Provider{
+		config: config,
+		oauth2: &oauth2.Config{
+			ClientID:     config.ClientId,
+			ClientSecret: config.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  config.AuthURL,
+				TokenURL: config.TokenURL,
+			},
+			RedirectURL: config.RedirectURL,
+			Scopes:      config.Scopes,
+		},
+	}
+}
+
+func (p *OAuth2Provider) Name() string {
+	return p.config.Name
+}
+
+func (p *OAuth2Provider) AuthCodeURL(state string) string {
+	var options []oauth2.AuthCodeOption
+	for key, value := range p.config.AuthParams {
+		options = append(options, oauth2.SetAuthURLParam(key, value))
+	}
+	return p.oauth2.AuthCodeURL(state, options...)
+}
+
+func (p *OAuth2Provider) Exchange(ctx context.Context, code string) (*Identity, error) {
+	token, err := p.oauth2.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if p.config.UserInfoURL != "" {
+		claims := &userClaims{}
+		err = getJson(ctx, p.oauth2.Client(ctx, token), p.config.UserInfoURL, claims)
+		if err != nil {
+			return nil, err
+		}
+		return claims.toIdentity(), nil
+	}
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no id token in response")
+	}
+	return p.verifyIdToken(ctx, idToken)
+}
+
+func (p *OAuth2Provider) verifyIdToken(ctx context.Context, idToken string) (*Identity, error) {
+	keys, err := getJwks(ctx, p.config.JwksURL)
+	if err != nil {
+		return nil, err
+	}
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claims.VerifyIssuer(p.config.Issuer, true) || !claims.VerifyAudience(p.config.ClientId, true) {
+		return nil, errors.New("id token was not issued for this client")
+	}
+	return claims.toIdentity(), nil
+}
+
+type userClaims struct {
+	Subject       string       `json:"sub"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+}
+
+func (c *userClaims) toIdentity() *Identity {
+	return &Identity{
+		Subject:       c.Subject,
+		Email:         c.Email,
+		EmailVerified: bool(c.EmailVerified),
+		Name:          c.Name,
+	}
+}
+
+type idTokenClaims struct {
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+	jwt.RegisteredClaims
+}
+
+func (c *idTokenClaims) toIdentity() *Identity {
+	return &Identity{
+		Subject:       c.Subject,
+		Email:         c.Email,
+		EmailVerified: bool(c.EmailVerified),
+		Name:          c.Name,
+	}
+}
+
+// flexibleBool accepts both true and "true", since Apple sends booleans as
+// strings.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		*b = v == "true"
+	}
+	return nil
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func getJwks(ctx context.Context, url string) (map[string]*rsa.PublicKey, error) {
+	set := &jwks{}
+	err := getJson(ctx, http.DefaultClient, url, set)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, err
+		}
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func getJson(ctx context.Context, client *http.Client, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", response.StatusCode, url)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
@@ -0,0 +1,49 @@
+package identity
+
+import (
+	"context"
+	"errors"
+)
+
+// Identity is a user as described by an upstream identity provider.
+type Identity struct {
+	// Subject is the provider's stable id for the user.
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an upstream identity provider users can sign in with.
+type Provider interface {
+	Name() string
+	// AuthCodeURL is where the user is sent to sign in. The provider sends
+	// them back to its redirect URL with a code and the state.
+	AuthCodeURL(state string) string
+	// Exchange trades the code from the redirect for the user's identity.
+	Exchange(ctx context.Context, code string) (*Identity, error)
+}
+
+type Registry struct {
+	providers map[string]Provider
+}
+
+func CreateRegistry(providers ...Provider) *Registry {
+	registry := &Registry{map[string]Provider{}}
+	for _, provider := range providers {
+		registry.Register(provider)
+	}
+	return registry
+}
+
+func (r *Registry) Register(provider Provider) {
+	r.providers[provider.Name()] = provider
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, errors.New("identity provider not found")
+	}
+	return provider, nil
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	defaultSmtpPort = "587"
+	defaultFileDir  = "mail"
+)
+
+// CreateTransportFromEnv picks the transport named in MAIL_TRANSPORT, one of
+// sendgrid, smtp, file or console, and defaults to sendgrid. SendGrid needs
+// SENDGRID_API_KEY, SMTP is configured with MAIL_SMTP_HOST, MAIL_SMTP_PORT,
+// MAIL_SMTP_USERNAME and MAIL_SMTP_PASSWORD, and the file transport writes
+// to MAIL_FILE_DIR.
+func CreateTransportFromEnv() Transport {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_TRANSPORT")))
+	switch name {
+	case "", TransportSendGrid:
+		return NewSendGridTransport(os.Getenv("SENDGRID_API_KEY"))
+	case TransportSmtp:
+		return NewSmtpTransport(
+			os.Getenv("MAIL_SMTP_HOST"),
+			getEnv("MAIL_SMTP_PORT", defaultSmtpPort),
+			os.Getenv("MAIL_SMTP_USERNAME"),
+			os.Getenv("MAIL_SMTP_PASSWORD"),
+		)
+	case TransportFile:
+		return NewFileTransport(getEnv("MAIL_FILE_DIR", defaultFileDir))
+	case TransportConsole:
+		return NewConsoleTransport(os.Stdout)
+	}
+	log.Fatal("unknown mail transport :: ", name)
+	return nil
+}
+
+func getEnv(key string, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,23 @@
+package mailer
+
+import "time"
+
+type DeliveryEventType string
+
+// Delivery events that make an address undeliverable.
+const (
+	// EventBounce is a hard bounce, the address doesn't exist or rejects
+	// mail permanently.
+	EventBounce DeliveryEventType = "bounce"
+	// EventComplaint is the recipient reporting an email as spam.
+	EventComplaint DeliveryEventType = "complaint"
+)
+
+// DeliveryEvent is what a mail provider reported about an email after it
+// was sent.
+type DeliveryEvent struct {
+	Email  string
+	Type   DeliveryEventType
+	Reason string
+	At     time.Time
+}
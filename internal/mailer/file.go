@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileTransport writes each email to an .eml file in a directory instead of
+// sending it, for local development. Mail clients open the files as is.
+type FileTransport struct {
+	dir string
+}
+
+func NewFileTransport(dir string) *FileTransport {
+	return &FileTransport{dir}
+}
+
+func (t *FileTransport) Name() string {
+	return TransportFile
+}
+
+func (t *FileTransport) Send(message *Message) error {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	name := time.Now().UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+	return os.WriteFile(filepath.Join(t.dir, name), message.Bytes(), 0o644)
+}
+
+// ConsoleTransport prints each email, for local development without a
+// mail server.
+type ConsoleTransport struct {
+	out io.Writer
+	mu  sync.Mutex
+}
+
+func NewConsoleTransport(out io.Writer) *ConsoleTransport {
+	return &ConsoleTransport{out: out}
+}
+
+func (t *ConsoleTransport) Name() string {
+	return TransportConsole
+}
+
+func (t *ConsoleTransport) Send(message *Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := t.out.Write(append(message.Bytes(), '\n'))
+	return err
+}
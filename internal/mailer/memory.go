@@ -0,0 +1,43 @@
+package mailer
+
+import "sync"
+
+// MemoryTransport keeps the emails it's given, so tests can check exactly
+// what was sent.
+type MemoryTransport struct {
+	messages []*Message
+	mu       sync.Mutex
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Name() string {
+	return TransportMemory
+}
+
+func (t *MemoryTransport) Send(message *Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, message)
+	return nil
+}
+
+// Messages returns the emails sent so far, oldest first.
+func (t *MemoryTransport) Messages() []*Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Message{}, t.messages...)
+}
+
+// To returns the emails sent to an address, oldest first.
+func (t *MemoryTransport) To(email string) []*Message {
+	var messages []*Message
+	for _, message := range t.Messages() {
+		if message.To.Email == email {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+type Address struct {
+	Name  string
+	Email string
+}
+
+func (a Address) String() string {
+	return (&mail.Address{Name: a.Name, Address: a.Email}).String()
+}
+
+// Message is an email independent of how it gets delivered.
+type Message struct {
+	From    Address
+	To      Address
+	Subject string
+	Text    string
+	Html    string
+}
+
+// Bytes renders the message as a MIME email with a plain text and an html
+// part, the way SMTP servers and mail clients expect it.
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	writePart(parts, "text/plain", m.Text)
+	if m.Html != "" {
+		writePart(parts, "text/html", m.Html)
+	}
+	_ = parts.Close()
+
+	var msg bytes.Buffer
+	writeHeader(&msg, "From", m.From.String())
+	writeHeader(&msg, "To", m.To.String())
+	writeHeader(&msg, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&msg, "Date", time.Now().UTC().Format(time.RFC1123Z))
+	writeHeader(&msg, "Message-ID", createMessageId(m.From.Email))
+	writeHeader(&msg, "MIME-Version", "1.0")
+	writeHeader(&msg, "Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes()
+}
+
+func writeHeader(buf *bytes.Buffer, key string, value string) {
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+func writePart(parts *multipart.Writer, contentType string, content string) {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	part, err := parts.CreatePart(header)
+	if err != nil {
+		return
+	}
+	writer := quotedprintable.NewWriter(part)
+	_, _ = writer.Write([]byte(content))
+	_ = writer.Close()
+}
+
+func createMessageId(from string) string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	return "<" + hex.EncodeToString(id) + "@" + domain + ">"
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"fmt"
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// SendGridTransport sends emails through the SendGrid API.
+type SendGridTransport struct {
+	client *sendgrid.Client
+}
+
+func NewSendGridTransport(apiKey string) *SendGridTransport {
+	return &SendGridTransport{sendgrid.NewSendClient(apiKey)}
+}
+
+func (t *SendGridTransport) Name() string {
+	return TransportSendGrid
+}
+
+func (t *SendGridTransport) Send(message *Message) error {
+	email := mail.NewSingleEmail(
+		mail.NewEmail(message.From.Name, message.From.Email),
+		message.Subject,
+		mail.NewEmail(message.To.Name, message.To.Email),
+		message.Text,
+		message.Html,
+	)
+	response, err := t.client.Send(email)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("sendgrid responded with %d :: %s", response.StatusCode, response.Body)
+	}
+	return nil
+}
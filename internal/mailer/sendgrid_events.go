@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+	"strconv"
+	"time"
+)
+
+// Headers SendGrid signs event webhook requests with.
+const (
+	SendGridSignatureHeader = eventwebhook.VerificationHTTPHeader
+	SendGridTimestampHeader = eventwebhook.TimestampHTTPHeader
+)
+
+// sendGridMaxTimestampAge is how far the signed timestamp may be from now, so
+// a captured request can't be replayed later.
+const sendGridMaxTimestampAge = 5 * time.Minute
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// SendGridWebhookVerifier checks that event webhook requests were signed by
+// SendGrid.
+type SendGridWebhookVerifier struct {
+	publicKey *ecdsa.PublicKey
+}
+
+func NewSendGridWebhookVerifier(publicKey *ecdsa.PublicKey) *SendGridWebhookVerifier {
+	return &SendGridWebhookVerifier{publicKey}
+}
+
+// ParseSendGridWebhookKey reads the verification key SendGrid shows in its
+// signed event webhook settings, a base64 encoded ECDSA public key.
+func ParseSendGridWebhookKey(base64Key string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(base64Key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("sendgrid webhook key is not an ECDSA key")
+	}
+	return publicKey, nil
+}
+
+// Verify checks the signature of the payload and that it was signed in the
+// last few minutes.
+func (v *SendGridWebhookVerifier) Verify(payload []byte, signature string, timestamp string) error {
+	if signature == "" || timestamp == "" {
+		return ErrInvalidSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	age := time.Since(time.Unix(seconds, 0))
+	if age > sendGridMaxTimestampAge || age < -sendGridMaxTimestampAge {
+		return ErrInvalidSignature
+	}
+	ok, err := eventwebhook.VerifySignature(v.publicKey, payload, signature, timestamp)
+	if err != nil || !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type sendGridEvent struct {
+	Email     string `json:"email"`
+	Event     string `json:"event"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// ParseSendGridEvents reads the bounces and spam reports from an event
+// webhook payload. Other events, and bounces SendGrid reports as blocked,
+// which are temporary, are left out.
+func ParseSendGridEvents(payload []byte) ([]*DeliveryEvent, error) {
+	var events []*sendGridEvent
+	if err := json.Unmarshal(payload, &events); err != nil {
+		return nil, err
+	}
+	var deliveryEvents []*DeliveryEvent
+	for _, event := range events {
+		var eventType DeliveryEventType
+		switch {
+		case event.Event == "bounce" && event.Type != "blocked":
+			eventType = EventBounce
+		case event.Event == "spamreport":
+			eventType = EventComplaint
+		default:
+			continue
+		}
+		if event.Email == "" {
+			continue
+		}
+		deliveryEvents = append(deliveryEvents, &DeliveryEvent{
+			Email:  event.Email,
+			Type:   eventType,
+			Reason: event.Reason,
+			At:     time.Unix(event.Timestamp, 0),
+		})
+	}
+	return deliveryEvents, nil
+}
@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+)
+
+// SmtpTransport hands emails to an SMTP server. The connection is upgraded
+// with STARTTLS when the server supports it.
+type SmtpTransport struct {
+	addr string
+	auth smtp.Auth
+}
+
+func NewSmtpTransport(host string, port string, username string, password string) *SmtpTransport {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SmtpTransport{net.JoinHostPort(host, port), auth}
+}
+
+func (t *SmtpTransport) Name() string {
+	return TransportSmtp
+}
+
+func (t *SmtpTransport) Send(message *Message) error {
+	return smtp.SendMail(t.addr, t.auth, message.From.Email, []string{message.To.Email}, message.Bytes())
+}
@@ -0,0 +1,16 @@
+package mailer
+
+// Transports that can be picked with MAIL_TRANSPORT.
+const (
+	TransportSendGrid = "sendgrid"
+	TransportSmtp     = "smtp"
+	TransportFile     = "file"
+	TransportConsole  = "console"
+	TransportMemory   = "memory"
+)
+
+// Transport delivers emails.
+type Transport interface {
+	Name() string
+	Send(message *Message) error
+}
@@ -0,0 +1,148 @@
+package mailtemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+const subjectHeader = "Subject:"
+
+// Email is a rendered template, ready to send.
+type Email struct {
+	Subject string
+	Text    string
+	Html    string
+}
+
+type template struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer renders emails from templates laid out as
+// <locale>/<name>.txt and <locale>/<name>.html. The text template starts
+// with a "Subject:" line followed by a blank line. HTML templates escape
+// every variable for the context it appears in.
+type Renderer struct {
+	defaultLocale string
+	templates     map[string]map[string]*template
+}
+
+// Load parses every template in the file system. Each of the required
+// templates must exist in the default locale, other locales can translate
+// as many as they like.
+func Load(fsys fs.FS, defaultLocale string, required []string) (*Renderer, error) {
+	renderer := &Renderer{
+		defaultLocale: normalizeLocale(defaultLocale),
+		templates:     map[string]map[string]*template{},
+	}
+	locales, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, locale := range locales {
+		if !locale.IsDir() {
+			continue
+		}
+		templates, err := loadLocale(fsys, locale.Name())
+		if err != nil {
+			return nil, err
+		}
+		renderer.templates[normalizeLocale(locale.Name())] = templates
+	}
+	for _, name := range required {
+		if _, ok := renderer.templates[renderer.defaultLocale][name]; !ok {
+			return nil, fmt.Errorf("email template %s/%s is missing", renderer.defaultLocale, name)
+		}
+	}
+	return renderer, nil
+}
+
+func loadLocale(fsys fs.FS, locale string) (map[string]*template, error) {
+	templates := map[string]*template{}
+	files, err := fs.ReadDir(fsys, locale)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || path.Ext(file.Name()) != ".txt" {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), ".txt")
+		textPath := path.Join(locale, name+".txt")
+		htmlPath := path.Join(locale, name+".html")
+		text, err := texttemplate.New(name).Option("missingkey=error").ParseFS(fsys, textPath)
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.New(name).Option("missingkey=error").ParseFS(fsys, htmlPath)
+		if err != nil {
+			return nil, fmt.Errorf("email template %s needs an html version :: %w", textPath, err)
+		}
+		templates[name] = &template{
+			text.Lookup(name + ".txt"),
+			html.Lookup(name + ".html"),
+		}
+	}
+	return templates, nil
+}
+
+// Render fills in the named template in the locale closest to the one
+// asked for, falling back to the default locale.
+func (r *Renderer) Render(name string, locale string, data interface{}) (*Email, error) {
+	t := r.find(name, locale)
+	if t == nil {
+		return nil, fmt.Errorf("email template %s not found", name)
+	}
+	var text bytes.Buffer
+	if err := t.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	var html bytes.Buffer
+	if err := t.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	subject, body, err := splitSubject(text.String())
+	if err != nil {
+		return nil, fmt.Errorf("email template %s :: %w", name, err)
+	}
+	return &Email{subject, body, html.String()}, nil
+}
+
+// find tries the locale, then its language without the region, then the
+// default locale.
+func (r *Renderer) find(name string, locale string) *template {
+	locale = normalizeLocale(locale)
+	candidates := []string{locale}
+	if dash := strings.Index(locale, "-"); dash > 0 {
+		candidates = append(candidates, locale[:dash])
+	}
+	candidates = append(candidates, r.defaultLocale)
+	for _, candidate := range candidates {
+		if t, ok := r.templates[candidate][name]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+func splitSubject(text string) (string, string, error) {
+	text = strings.TrimLeft(text, "\n")
+	if !strings.HasPrefix(text, subjectHeader) {
+		return "", "", errors.New("the text template must start with a subject line")
+	}
+	header, body, _ := strings.Cut(text, "\n")
+	subject := strings.TrimSpace(strings.TrimPrefix(header, subjectHeader))
+	return subject, strings.TrimLeft(body, "\n"), nil
+}
+
+// normalizeLocale turns en_US and EN-us into en-us.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
@@ -0,0 +1,26 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapAccessTokenEntityToModel(token *entity.AccessToken) *model.AccessToken {
+	return &model.AccessToken{
+		Uuid:       token.Uuid.String(),
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.GetScopes(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func MapAccessTokenEntitiesToModels(tokens []*entity.AccessToken) []*model.AccessToken {
+	tokenModels := make([]*model.AccessToken, len(tokens))
+	for i, v := range tokens {
+		tokenModels[i] = MapAccessTokenEntityToModel(v)
+	}
+	return tokenModels
+}
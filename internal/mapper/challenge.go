@@ -0,0 +1,16 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/challenge"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapChallengeToModel(endpoint string, c *challenge.Challenge) *model.Challenge {
+	return &model.Challenge{
+		Endpoint:   endpoint,
+		Type:       c.Type,
+		SiteKey:    c.SiteKey,
+		Token:      c.Token,
+		Difficulty: c.Difficulty,
+	}
+}
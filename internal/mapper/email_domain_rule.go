@@ -0,0 +1,23 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapEmailDomainRuleEntityToModel(rule *entity.EmailDomainRule) *model.EmailDomainRule {
+	return &model.EmailDomainRule{
+		Domain:    rule.Domain,
+		Rule:      string(rule.Rule),
+		Reason:    rule.Reason,
+		CreatedAt: rule.CreatedAt,
+	}
+}
+
+func MapEmailDomainRuleEntitiesToModels(rules []*entity.EmailDomainRule) []*model.EmailDomainRule {
+	ruleModels := make([]*model.EmailDomainRule, len(rules))
+	for i, v := range rules {
+		ruleModels[i] = MapEmailDomainRuleEntityToModel(v)
+	}
+	return ruleModels
+}
@@ -0,0 +1,44 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapGroupEntityToModel(group *entity.Group) *model.Group {
+	groupModel := &model.Group{
+		Uuid:        group.Uuid.String(),
+		Name:        group.Name,
+		Slug:        group.Slug,
+		Description: group.Description,
+		Visibility:  group.Visibility,
+		CreatedAt:   group.CreatedAt,
+	}
+	if group.Owner != nil {
+		groupModel.Owner = MapUserEntityToModel(group.Owner)
+	}
+	return groupModel
+}
+
+func MapGroupMemberEntityToModel(member *entity.GroupMember) *model.GroupMember {
+	memberModel := &model.GroupMember{
+		Role:      member.Role,
+		Status:    member.Status,
+		CreatedAt: member.CreatedAt,
+	}
+	if member.User != nil {
+		memberModel.User = MapUserEntityToModel(member.User)
+	}
+	if member.Group != nil {
+		memberModel.Group = MapGroupEntityToModel(member.Group)
+	}
+	return memberModel
+}
+
+func MapGroupMemberEntitiesToModels(members []*entity.GroupMember) []*model.GroupMember {
+	memberModels := make([]*model.GroupMember, len(members))
+	for i, v := range members {
+		memberModels[i] = MapGroupMemberEntityToModel(v)
+	}
+	return memberModels
+}
@@ -7,8 +7,20 @@ import (
 
 func MapInviteEntityToModel(invite *entity.Invite) *model.Invite {
 	return &model.Invite{
-		Code:    invite.Code,
-		Claimed: invite.Claimed,
+		Code:       invite.Code,
+		Claimed:    invite.Claimed,
+		ExpiresAt:  invite.ExpiresAt,
+		MaxUses:    invite.MaxUses,
+		Uses:       invite.Uses,
+		Email:      invite.Email,
+		Revoked:    invite.Revoked,
+		Campaign:   invite.Campaign,
+		CreatedAt:  invite.CreatedAt,
+		Status:     string(invite.Status),
+		SentAt:     invite.SentAt,
+		OpenedAt:   invite.OpenedAt,
+		ClickedAt:  invite.ClickedAt,
+		AcceptedAt: invite.AcceptedAt,
 	}
 }
 
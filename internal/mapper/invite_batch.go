@@ -0,0 +1,18 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapInviteBatchEntityToModel(batch *entity.InviteBatch, invites []*entity.Invite) *model.InviteBatch {
+	return &model.InviteBatch{
+		Uuid:      batch.Uuid.String(),
+		Campaign:  batch.Campaign,
+		Count:     batch.Count,
+		ExpiresAt: batch.ExpiresAt,
+		MaxUses:   batch.MaxUses,
+		CreatedAt: batch.CreatedAt,
+		Invites:   MapInviteEntitiesToModels(invites),
+	}
+}
@@ -0,0 +1,24 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapLinkedIdentityEntityToModel(linkedIdentity *entity.LinkedIdentity) *model.LinkedIdentity {
+	return &model.LinkedIdentity{
+		Uuid:       linkedIdentity.Uuid.String(),
+		Provider:   linkedIdentity.Provider,
+		Email:      linkedIdentity.Email,
+		LastUsedAt: linkedIdentity.LastUsedAt,
+		CreatedAt:  linkedIdentity.CreatedAt,
+	}
+}
+
+func MapLinkedIdentityEntitiesToModels(linkedIdentities []*entity.LinkedIdentity) []*model.LinkedIdentity {
+	identityModels := make([]*model.LinkedIdentity, len(linkedIdentities))
+	for i, v := range linkedIdentities {
+		identityModels[i] = MapLinkedIdentityEntityToModel(v)
+	}
+	return identityModels
+}
@@ -0,0 +1,26 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapLoginAttemptEntityToModel(attempt *entity.LoginAttempt) *model.LoginAttempt {
+	return &model.LoginAttempt{
+		Uuid:      attempt.Uuid.String(),
+		Method:    string(attempt.Method),
+		Success:   attempt.Success,
+		IpAddress: attempt.IpAddress,
+		UserAgent: attempt.UserAgent,
+		NewDevice: attempt.NewDevice,
+		CreatedAt: attempt.CreatedAt,
+	}
+}
+
+func MapLoginAttemptEntitiesToModels(attempts []*entity.LoginAttempt) []*model.LoginAttempt {
+	attemptModels := make([]*model.LoginAttempt, len(attempts))
+	for i, v := range attempts {
+		attemptModels[i] = MapLoginAttemptEntityToModel(v)
+	}
+	return attemptModels
+}
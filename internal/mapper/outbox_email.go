@@ -0,0 +1,34 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapOutboxEmailEntityToModel(email *entity.OutboxEmail) *model.OutboxEmail {
+	emailModel := &model.OutboxEmail{
+		Uuid:      email.Uuid.String(),
+		Template:  email.Template,
+		To:        email.ToEmail,
+		Subject:   email.Subject,
+		Status:    string(email.Status),
+		Attempts:  email.Attempts,
+		LastError: email.LastError,
+		SentAt:    email.SentAt,
+		CreatedAt: email.CreatedAt,
+	}
+	if email.Status == enum.OutboxStatusPending {
+		nextAttemptAt := email.NextAttemptAt
+		emailModel.NextAttemptAt = &nextAttemptAt
+	}
+	return emailModel
+}
+
+func MapOutboxEmailEntitiesToModels(emails []*entity.OutboxEmail) []*model.OutboxEmail {
+	emailModels := make([]*model.OutboxEmail, len(emails))
+	for i, v := range emails {
+		emailModels[i] = MapOutboxEmailEntityToModel(v)
+	}
+	return emailModels
+}
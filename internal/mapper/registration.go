@@ -0,0 +1,21 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapRegistrationSettingsEntityToModel(settings *entity.RegistrationSettings) *model.Registration {
+	registration := &model.Registration{
+		Mode:           string(settings.Mode),
+		InviteRequired: settings.InviteRequired(),
+		AllowedDomains: settings.AllowedDomains,
+	}
+	if registration.AllowedDomains == nil {
+		registration.AllowedDomains = []string{}
+	}
+	if settings.ID != 0 {
+		registration.UpdatedAt = &settings.UpdatedAt
+	}
+	return registration
+}
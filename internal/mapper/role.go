@@ -0,0 +1,27 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapRoleEntityToModel(role *entity.Role) *model.RoleDefinition {
+	permissions := make([]model.Permission, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = model.Permission(permission.Name)
+	}
+	return &model.RoleDefinition{
+		Name:        model.Role(role.Name),
+		Description: role.Description,
+		Level:       role.Level,
+		Permissions: permissions,
+	}
+}
+
+func MapRoleEntitiesToModels(roles []*entity.Role) []*model.RoleDefinition {
+	roleModels := make([]*model.RoleDefinition, len(roles))
+	for i, v := range roles {
+		roleModels[i] = MapRoleEntityToModel(v)
+	}
+	return roleModels
+}
@@ -0,0 +1,24 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapServiceAccountEntityToModel(account *entity.ServiceAccount) *model.ServiceAccount {
+	return &model.ServiceAccount{
+		Uuid:      account.Uuid.String(),
+		Name:      account.Name,
+		ClientId:  account.ClientId,
+		Scopes:    account.GetScopes(),
+		CreatedAt: account.CreatedAt,
+	}
+}
+
+func MapServiceAccountEntitiesToModels(accounts []*entity.ServiceAccount) []*model.ServiceAccount {
+	accountModels := make([]*model.ServiceAccount, len(accounts))
+	for i, v := range accounts {
+		accountModels[i] = MapServiceAccountEntityToModel(v)
+	}
+	return accountModels
+}
@@ -6,15 +6,21 @@ import (
 )
 
 func MapUserEntityToModel(user *entity.User) *model.User {
+	var roles []model.Role
+	for _, role := range user.Roles {
+		roles = append(roles, model.Role(role.Name))
+	}
 	return &model.User{
 		Uuid:       user.Uuid.String(),
 		Name:       user.Name,
 		Username:   user.Username,
 		ProfilePic: user.ProfilePic,
 		Role:       model.Role(user.Role),
+		Roles:      roles,
 		IsBanned:   user.IsBanned,
 		BioMessage: user.BioMessage,
 		Birthday:   user.Birthday,
+		Locale:     user.Locale,
 		CreatedAt:  user.CreatedAt,
 	}
 }
@@ -32,5 +38,6 @@ func MapNewUserModelToEntity(user *model.NewUser) *entity.User {
 		Name:     user.Name,
 		Username: user.Username,
 		Email:    user.Email,
+		Locale:   user.Locale,
 	}
 }
@@ -0,0 +1,25 @@
+package mapper
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+)
+
+func MapWaitlistEntryEntityToModel(entry *entity.WaitlistEntry) *model.WaitlistEntry {
+	return &model.WaitlistEntry{
+		Email:       entry.Email,
+		Reason:      entry.Reason,
+		Status:      string(entry.Status),
+		CreatedAt:   entry.CreatedAt,
+		ConfirmedAt: entry.ConfirmedAt,
+		InvitedAt:   entry.InvitedAt,
+	}
+}
+
+func MapWaitlistEntryEntitiesToModels(entries []*entity.WaitlistEntry) []*model.WaitlistEntry {
+	entryModels := make([]*model.WaitlistEntry, len(entries))
+	for i, v := range entries {
+		entryModels[i] = MapWaitlistEntryEntityToModel(v)
+	}
+	return entryModels
+}
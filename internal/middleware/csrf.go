@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+)
+
+// CsrfMiddleware rejects state changing requests that are authenticated by
+// the session cookie unless they carry the matching x-csrf-token header.
+// Requests that send their token in a header can't be forged by another
+// site, so they're let through.
+func CsrfMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if util.IsCookieSession(c) && !util.CheckCsrfToken(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, util.NewInputFieldError(util.CsrfHeaderName, "csrf token not valid"))
+			return
+		}
+		c.Next()
+	}
+}
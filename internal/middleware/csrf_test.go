@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testCsrfSessionToken = "session"
+
+func createTestCsrfRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/", CsrfMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func createCookieSessionRequest() *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	request.AddCookie(&http.Cookie{Name: util.SessionCookieName, Value: testCsrfSessionToken})
+	return request
+}
+
+func doCsrfRequest(request *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	createTestCsrfRouter().ServeHTTP(recorder, request)
+	return recorder
+}
+
+func Test_Csrf_Allows_Cookie_Session_With_Matching_Token(t *testing.T) {
+	// given
+	request := createCookieSessionRequest()
+	csrfToken := util.GetCsrfToken(testCsrfSessionToken)
+	request.AddCookie(&http.Cookie{Name: util.CsrfCookieName, Value: csrfToken})
+	request.Header.Set(util.CsrfHeaderName, csrfToken)
+
+	// when
+	response := doCsrfRequest(request)
+
+	// then
+	if response.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_Csrf_Rejects_Cookie_Session_Without_Token(t *testing.T) {
+	// given
+	request := createCookieSessionRequest()
+
+	// when
+	response := doCsrfRequest(request)
+
+	// then
+	if response.Code != http.StatusForbidden {
+		t.Errorf("expected %d, got %d", http.StatusForbidden, response.Code)
+	}
+}
+
+func Test_Csrf_Rejects_Cookie_Session_With_Other_Authorization_Header(t *testing.T) {
+	// given
+	request := createCookieSessionRequest()
+	request.Header.Set("Authorization", "Basic x")
+
+	// when
+	response := doCsrfRequest(request)
+
+	// then
+	if response.Code != http.StatusForbidden {
+		t.Errorf("expected %d, got %d", http.StatusForbidden, response.Code)
+	}
+}
+
+func Test_Csrf_Allows_Bearer_Token(t *testing.T) {
+	// given
+	request := createCookieSessionRequest()
+	request.Header.Set("Authorization", "Bearer "+testCsrfSessionToken)
+
+	// when
+	response := doCsrfRequest(request)
+
+	// then
+	if response.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, response.Code)
+	}
+}
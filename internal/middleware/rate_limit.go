@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/ratelimit"
+	"github.com/third-place/user-service/internal/service"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitMiddleware limits calls to the named route by the policy. When
+// the store can't be reached, requests are let through.
+func RateLimitMiddleware(name string, policy *ratelimit.Policy, store ratelimit.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := store.Take(c.Request.Context(), name+":"+getRateLimitSubject(c, policy), policy)
+		if err != nil {
+			log.Print("error checking rate limit :: ", err)
+			c.Next()
+			return
+		}
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", toSeconds(result.Reset))
+		c.Header("RateLimit-Policy", policy.String())
+		if !result.Allowed {
+			c.Header("Retry-After", toSeconds(result.RetryAfter))
+			c.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+		c.Next()
+	}
+}
+
+func getRateLimitSubject(c *gin.Context, policy *ratelimit.Policy) string {
+	switch policy.Key {
+	case ratelimit.KeyRoute:
+		return "route"
+	case ratelimit.KeyUser:
+		if sessionToken := util.GetSessionTokenModel(c); sessionToken != nil {
+			if session, err := service.CreateSessionService().GetSession(sessionToken); err == nil {
+				return "user:" + session.User.Uuid
+			}
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func toSeconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
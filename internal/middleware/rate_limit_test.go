@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func createTestRateLimitRouter(store ratelimit.Store, policy *ratelimit.Policy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.SetTrustedProxies(nil)
+	router.GET("/first", RateLimitMiddleware("first", policy, store), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/second", RateLimitMiddleware("second", policy, store), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func doRateLimitedRequest(router *gin.Engine, path string, remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func Test_RateLimit_Denies_Requests_Over_The_Limit(t *testing.T) {
+	// setup
+	router := createTestRateLimitRouter(ratelimit.NewMemoryStore(), ratelimit.PerIp(1, time.Minute))
+
+	// given
+	doRateLimitedRequest(router, "/first", "192.0.2.1:1234", "")
+
+	// when
+	response := doRateLimitedRequest(router, "/first", "192.0.2.1:1234", "")
+
+	// then
+	if response.Code != http.StatusTooManyRequests {
+		t.Errorf("expected %d, got %d", http.StatusTooManyRequests, response.Code)
+	}
+	if response.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+}
+
+func Test_RateLimit_Ignores_Forwarded_For_From_Untrusted_Proxies(t *testing.T) {
+	// setup
+	router := createTestRateLimitRouter(ratelimit.NewMemoryStore(), ratelimit.PerIp(1, time.Minute))
+
+	// given
+	doRateLimitedRequest(router, "/first", "192.0.2.1:1234", "198.51.100.1")
+
+	// when
+	response := doRateLimitedRequest(router, "/first", "192.0.2.1:1234", "198.51.100.2")
+
+	// then
+	if response.Code != http.StatusTooManyRequests {
+		t.Errorf("expected %d, got %d", http.StatusTooManyRequests, response.Code)
+	}
+}
+
+func Test_RateLimit_Keeps_A_Bucket_Per_Ip_And_Route(t *testing.T) {
+	// setup
+	router := createTestRateLimitRouter(ratelimit.NewMemoryStore(), ratelimit.PerIp(1, time.Minute))
+
+	// given
+	doRateLimitedRequest(router, "/first", "192.0.2.1:1234", "")
+
+	// when
+	otherIp := doRateLimitedRequest(router, "/first", "192.0.2.2:1234", "")
+	otherRoute := doRateLimitedRequest(router, "/second", "192.0.2.1:1234", "")
+
+	// then
+	if otherIp.Code != http.StatusOK {
+		t.Errorf("expected another ip to be allowed, got %d", otherIp.Code)
+	}
+	if otherRoute.Code != http.StatusOK {
+		t.Errorf("expected another route to be allowed, got %d", otherRoute.Code)
+	}
+}
+
+func Test_RateLimit_Per_Route_Is_Shared_Between_Ips(t *testing.T) {
+	// setup
+	router := createTestRateLimitRouter(ratelimit.NewMemoryStore(), ratelimit.PerRoute(1, time.Minute))
+
+	// given
+	doRateLimitedRequest(router, "/first", "192.0.2.1:1234", "")
+
+	// when
+	response := doRateLimitedRequest(router, "/first", "192.0.2.2:1234", "")
+
+	// then
+	if response.Code != http.StatusTooManyRequests {
+		t.Errorf("expected %d, got %d", http.StatusTooManyRequests, response.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type AccessToken struct {
+	Uuid string `json:"uuid"`
+
+	Name string `json:"name"`
+
+	// Prefix is the start of the token, kept so users can tell their tokens
+	// apart after the full token has been shown to them once.
+	Prefix string `json:"prefix"`
+
+	Scopes []Scope `json:"scopes,omitempty"`
+
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Token is only set in the response that creates the token.
+	Token string `json:"token,omitempty"`
+}
+
+type NewAccessToken struct {
+	Name string `json:"name"`
+
+	Scopes []Scope `json:"scopes,omitempty"`
+
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func DecodeRequestToNewAccessToken(r *http.Request) (*NewAccessToken, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *NewAccessToken
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AuthzCheck asks whether the subject identified by Token may perform Action
+// on Resource. Resource is empty or a typed reference such as "user:<uuid>".
+type AuthzCheck struct {
+	Token string `json:"token"`
+
+	Action Permission `json:"action"`
+
+	Resource string `json:"resource,omitempty"`
+}
+
+type AuthzBatchCheck struct {
+	Token string `json:"token"`
+
+	Checks []*AuthzCheck `json:"checks"`
+}
+
+type AuthzDecision struct {
+	Action Permission `json:"action"`
+
+	Resource string `json:"resource,omitempty"`
+
+	Allowed bool `json:"allowed"`
+
+	Reason string `json:"reason"`
+}
+
+func CreateAuthzDecision(allowed bool, reason string) *AuthzDecision {
+	return &AuthzDecision{
+		Allowed: allowed,
+		Reason:  reason,
+	}
+}
+
+func DecodeRequestToAuthzCheck(r *http.Request) (*AuthzCheck, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *AuthzCheck
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func DecodeRequestToAuthzBatchCheck(r *http.Request) (*AuthzBatchCheck, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *AuthzBatchCheck
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
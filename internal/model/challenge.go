@@ -0,0 +1,16 @@
+package model
+
+// Challenge is what the client has to solve before calling an endpoint, and
+// send back in the x-challenge-response header.
+type Challenge struct {
+	Endpoint string `json:"endpoint"`
+
+	// Type is none, pow, hcaptcha or turnstile.
+	Type string `json:"type"`
+
+	SiteKey string `json:"siteKey,omitempty"`
+
+	Token string `json:"token,omitempty"`
+
+	Difficulty int `json:"difficulty,omitempty"`
+}
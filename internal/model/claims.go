@@ -8,15 +8,60 @@ import (
 
 type Claims struct {
 	UserUuid string `json:"userUuid"`
+	// Scopes is only set on service account tokens.
+	Scopes []Scope `json:"scopes,omitempty"`
+	// ImpersonatorUuid is set when an admin is acting as the user.
+	ImpersonatorUuid string `json:"impersonatorUuid,omitempty"`
+	// AuthTime is when the user last entered their credentials. It is kept
+	// when the token is refreshed.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// NewClaims creates the claims for a session started by entering the user's
+// credentials. Each session gets its own ID, so logging out of one doesn't
+// revoke another issued at the same time.
 func NewClaims(userUuid uuid.UUID) *Claims {
-	expirationTime := time.Now().Add(24 * 7 * time.Hour)
+	now := time.Now()
+	expirationTime := now.Add(24 * 7 * time.Hour)
 	return &Claims{
 		UserUuid: userUuid.String(),
+		AuthTime: jwt.NewNumericDate(now),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 }
+
+func NewServiceAccountClaims(userUuid uuid.UUID, scopes []Scope, lifetime time.Duration) *Claims {
+	now := time.Now()
+	return &Claims{
+		UserUuid: userUuid.String(),
+		Scopes:   scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+}
+
+func NewImpersonationClaims(userUuid uuid.UUID, impersonatorUuid uuid.UUID, lifetime time.Duration) *Claims {
+	now := time.Now()
+	return &Claims{
+		UserUuid:         userUuid.String(),
+		ImpersonatorUuid: impersonatorUuid.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+}
+
+func (c *Claims) GetAuthTime() *time.Time {
+	if c.AuthTime == nil {
+		return nil
+	}
+	return &c.AuthTime.Time
+}
@@ -0,0 +1,9 @@
+package model
+
+// ClientInfo describes the client making a request. It is filled in by the
+// controllers, never decoded from the request body.
+type ClientInfo struct {
+	IpAddress string
+	UserAgent string
+	DeviceId  string
+}
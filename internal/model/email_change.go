@@ -0,0 +1,20 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type EmailChange struct {
+	Email string `json:"email"`
+}
+
+func DecodeRequestToEmailChange(r *http.Request) (*EmailChange, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *EmailChange
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// EmailDomainRule blocks or allows sign-ups from a domain and its
+// subdomains.
+type EmailDomainRule struct {
+	Domain string `json:"domain"`
+
+	// Rule is block or allow. Allowing a domain overrides the disposable
+	// list.
+	Rule string `json:"rule"`
+
+	Reason string `json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type NewEmailDomainRule struct {
+	Rule string `json:"rule"`
+
+	Reason string `json:"reason"`
+}
+
+func DecodeRequestToNewEmailDomainRule(r *http.Request) (*NewEmailDomainRule, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *NewEmailDomainRule
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type Group struct {
+	Uuid string `json:"uuid,omitempty"`
+
+	Name string `json:"name"`
+
+	Slug string `json:"slug,omitempty"`
+
+	Description string `json:"description,omitempty"`
+
+	Owner *User `json:"owner,omitempty"`
+
+	Visibility string `json:"visibility,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// GroupUpdate changes a group. Fields left out of the request are nil and
+// keep their current value.
+type GroupUpdate struct {
+	Name *string `json:"name,omitempty"`
+
+	Description *string `json:"description,omitempty"`
+
+	Visibility *string `json:"visibility,omitempty"`
+}
+
+type GroupMember struct {
+	User *User `json:"user,omitempty"`
+
+	Group *Group `json:"group,omitempty"`
+
+	Role string `json:"role"`
+
+	Status string `json:"status"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+type NewGroupMember struct {
+	Username string `json:"username"`
+
+	Role string `json:"role,omitempty"`
+}
+
+func DecodeRequestToGroup(r *http.Request) (*Group, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *Group
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func DecodeRequestToGroupUpdate(r *http.Request) (*GroupUpdate, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *GroupUpdate
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func DecodeRequestToNewGroupMember(r *http.Request) (*NewGroupMember, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *NewGroupMember
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type GroupEventType string
+
+const (
+	GroupEventCreated       GroupEventType = "group.created"
+	GroupEventUpdated       GroupEventType = "group.updated"
+	GroupEventMemberInvited GroupEventType = "group.member_invited"
+	GroupEventMemberAdded   GroupEventType = "group.member_added"
+	GroupEventMemberRemoved GroupEventType = "group.member_removed"
+)
+
+// GroupEvent is published to the groups topic whenever a group or its
+// membership changes.
+type GroupEvent struct {
+	Type      GroupEventType `json:"type"`
+	Group     *Group         `json:"group"`
+	Member    *GroupMember   `json:"member,omitempty"`
+	ActorUuid string         `json:"actor_uuid,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func CreateGroupEvent(eventType GroupEventType, group *Group, member *GroupMember, actorUuid string) *GroupEvent {
+	return &GroupEvent{
+		Type:      eventType,
+		Group:     group,
+		Member:    member,
+		ActorUuid: actorUuid,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type Impersonation struct {
+	// Reason is recorded in the audit log, e.g. the support ticket being
+	// worked on.
+	Reason string `json:"reason"`
+
+	IpAddress string `json:"-"`
+}
+
+// DecodeRequestToImpersonation reads an optional impersonation request body.
+func DecodeRequestToImpersonation(r *http.Request) (*Impersonation, error) {
+	data := &Impersonation{}
+	if r.ContentLength == 0 {
+		return data, nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
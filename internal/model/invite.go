@@ -9,7 +9,69 @@
 
 package model
 
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
 type Invite struct {
-	Code    string `json:"code"`
-	Claimed bool   `json:"claimed"`
+	Code      string     `json:"code"`
+	Claimed   bool       `json:"claimed"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	MaxUses   int        `json:"maxUses"`
+	Uses      int        `json:"uses"`
+	Email     string     `json:"email,omitempty"`
+	Revoked   bool       `json:"revoked"`
+	Campaign  string     `json:"campaign,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	// Status is sent, opened, clicked, accepted or cancelled for invites
+	// sent by email.
+	Status     string     `json:"status,omitempty"`
+	SentAt     *time.Time `json:"sentAt,omitempty"`
+	OpenedAt   *time.Time `json:"openedAt,omitempty"`
+	ClickedAt  *time.Time `json:"clickedAt,omitempty"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+}
+
+// NewInvite sets limits on an invite. Every field is optional, and an
+// invite without any can be used once and never expires.
+type NewInvite struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// MaxUses is how many users can sign up with the invite, one by default.
+	MaxUses int    `json:"maxUses"`
+	Email   string `json:"email"`
+}
+
+// DecodeRequestToNewInvite reads the invite's limits, allowing an empty body.
+func DecodeRequestToNewInvite(r *http.Request) (*NewInvite, error) {
+	decoder := json.NewDecoder(r.Body)
+	data := &NewInvite{}
+	err := decoder.Decode(data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// EmailInvite sends an invite to someone's email address.
+type EmailInvite struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	// Message is a personal note from the inviter.
+	Message string `json:"message"`
+	// Locale picks the language of the email, the inviter's by default.
+	Locale string `json:"locale"`
+}
+
+func DecodeRequestToEmailInvite(r *http.Request) (*EmailInvite, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *EmailInvite
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
 }
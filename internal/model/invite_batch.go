@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// InviteBatch is a set of invites generated at once.
+type InviteBatch struct {
+	Uuid string `json:"uuid"`
+
+	Campaign string `json:"campaign,omitempty"`
+
+	Count int `json:"count"`
+
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	MaxUses int `json:"maxUses"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Invites []*Invite `json:"invites"`
+}
+
+// NewInviteBatch asks for count invites sharing the same limits.
+type NewInviteBatch struct {
+	Count int `json:"count"`
+
+	// Campaign tags the invites, to count their redemptions later.
+	Campaign string `json:"campaign"`
+
+	ExpiresAt *time.Time `json:"expiresAt"`
+
+	// MaxUses is how many users can sign up with each code, one by default.
+	MaxUses int `json:"maxUses"`
+}
+
+// CampaignStats shows how the invites tagged with a campaign were used.
+type CampaignStats struct {
+	Campaign string `json:"campaign"`
+
+	Codes int `json:"codes"`
+
+	// Redeemed is how many codes were used at least once.
+	Redeemed int `json:"redeemed"`
+
+	// Uses is how many users signed up with the codes.
+	Uses int `json:"uses"`
+
+	Expired int `json:"expired"`
+
+	Revoked int `json:"revoked"`
+}
+
+func DecodeRequestToNewInviteBatch(r *http.Request) (*NewInviteBatch, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *NewInviteBatch
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// InviteQuota is how many invites a user can still create.
+type InviteQuota struct {
+	// Unlimited is set for moderators, who don't spend a quota.
+	Unlimited bool `json:"unlimited"`
+
+	// Monthly is the allowance for this month, zero until the account is
+	// old enough.
+	Monthly int `json:"monthly"`
+
+	MonthlyUsed int `json:"monthlyUsed"`
+
+	// Granted is every extra invite an admin has given the user.
+	Granted int `json:"granted"`
+
+	GrantedUsed int `json:"grantedUsed"`
+
+	Remaining int `json:"remaining"`
+
+	// ResetsAt is when the monthly allowance starts over.
+	ResetsAt time.Time `json:"resetsAt"`
+}
+
+type NewInviteGrant struct {
+	Amount int `json:"amount"`
+
+	Reason string `json:"reason"`
+}
+
+func DecodeRequestToNewInviteGrant(r *http.Request) (*NewInviteGrant, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *NewInviteGrant
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
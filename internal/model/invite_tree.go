@@ -0,0 +1,17 @@
+package model
+
+// InviteTree is a user and everyone who signed up through their invites,
+// and through those users' invites in turn.
+type InviteTree struct {
+	User *User `json:"user"`
+
+	Invitees []*InviteTree `json:"invitees"`
+}
+
+// TreeBan reports the result of banning a user with their invite tree.
+// Users the moderator isn't allowed to ban are skipped.
+type TreeBan struct {
+	Banned []string `json:"banned"`
+
+	Skipped []string `json:"skipped"`
+}
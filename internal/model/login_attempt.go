@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type LoginAttempt struct {
+	Uuid string `json:"uuid"`
+
+	Method string `json:"method"`
+
+	Success bool `json:"success"`
+
+	IpAddress string `json:"ip_address,omitempty"`
+
+	UserAgent string `json:"user_agent,omitempty"`
+
+	NewDevice bool `json:"new_device"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionRevocation is the "this wasn't me" request from a new sign-in
+// notice.
+type SessionRevocation struct {
+	Code string `json:"code"`
+}
+
+func DecodeRequestToSessionRevocation(r *http.Request) (*SessionRevocation, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *SessionRevocation
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
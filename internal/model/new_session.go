@@ -17,6 +17,10 @@ import (
 type NewSession struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// UseCookie keeps the token out of the response and in an HttpOnly
+	// cookie instead, for the web client.
+	UseCookie bool       `json:"useCookie"`
+	Client    ClientInfo `json:"-"`
 }
 
 func DecodeRequestToNewSession(r *http.Request) (*NewSession, error) {
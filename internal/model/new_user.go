@@ -24,6 +24,9 @@ type NewUser struct {
 	Password string `json:"password"`
 
 	InviteCode string `json:"invite_code"`
+
+	// Locale picks the language of emails to the user.
+	Locale string `json:"locale,omitempty"`
 }
 
 func DecodeRequestToNewUser(r *http.Request) (*NewUser, error) {
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+)
+
+const GrantTypeClientCredentials = "client_credentials"
+
+// List of OAuthError codes, from RFC 6749 section 5.2.
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+)
+
+// OAuthTokenRequest is an OAuth2 token request. Client credentials can be
+// sent in the form or with HTTP basic auth.
+type OAuthTokenRequest struct {
+	GrantType    string
+	ClientId     string
+	ClientSecret string
+	Scopes       []Scope
+}
+
+type OAuthToken struct {
+	AccessToken string `json:"access_token"`
+
+	TokenType string `json:"token_type"`
+
+	ExpiresIn int `json:"expires_in"`
+
+	Scope string `json:"scope"`
+}
+
+type OAuthError struct {
+	Code string `json:"error"`
+
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code
+}
+
+func NewOAuthError(code string, description string) *OAuthError {
+	return &OAuthError{
+		Code:        code,
+		Description: description,
+	}
+}
+
+func DecodeRequestToOAuthTokenRequest(r *http.Request) (*OAuthTokenRequest, error) {
+	err := r.ParseForm()
+	if err != nil {
+		return nil, err
+	}
+	data := &OAuthTokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientId:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		Scopes:       ParseScopes(r.PostForm.Get("scope")),
+	}
+	if clientId, clientSecret, ok := r.BasicAuth(); ok {
+		if data.ClientId != "" {
+			return nil, errors.New("client authenticated more than once")
+		}
+		data.ClientId = clientId
+		data.ClientSecret = clientSecret
+	}
+	return data, nil
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// OutboxEmail is the delivery record of an email. The body is left out, it
+// can hold codes that only the recipient should see.
+type OutboxEmail struct {
+	Uuid string `json:"uuid"`
+
+	Template string `json:"template"`
+
+	To string `json:"to"`
+
+	Subject string `json:"subject"`
+
+	Status string `json:"status"`
+
+	Attempts int `json:"attempts"`
+
+	LastError string `json:"lastError,omitempty"`
+
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+
+	SentAt *time.Time `json:"sentAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,72 @@
+package model
+
+type Permission string
+
+// List of Permission
+const (
+	PermissionUserList       Permission = "user.list"
+	PermissionUserReadPii    Permission = "user.read_pii"
+	PermissionUserBan        Permission = "user.ban"
+	PermissionUserAssignRole Permission = "user.assign_role"
+	PermissionInviteCreate   Permission = "invite.create"
+	PermissionInviteList     Permission = "invite.list"
+	PermissionInviteRevoke   Permission = "invite.revoke"
+	// PermissionInviteAudit allows walking the whole invite tree below a
+	// user.
+	PermissionInviteAudit Permission = "invite.audit"
+	// PermissionInviteGrant allows giving users invites on top of their
+	// monthly allowance.
+	PermissionInviteGrant Permission = "invite.grant"
+	// PermissionInviteBulk allows generating batches of invites, like codes
+	// printed for events.
+	PermissionInviteBulk      Permission = "invite.bulk"
+	PermissionRoleManage      Permission = "role.manage"
+	PermissionUserImpersonate Permission = "user.impersonate"
+	// PermissionServiceAccountManage allows creating and disabling service
+	// accounts.
+	PermissionServiceAccountManage Permission = "service_account.manage"
+	// PermissionWaitlistManage allows reviewing the waitlist and inviting
+	// people from it.
+	PermissionWaitlistManage Permission = "waitlist.manage"
+	// PermissionRegistrationManage allows changing who can sign up.
+	PermissionRegistrationManage Permission = "registration.manage"
+	// PermissionEmailDomainManage allows blocking and allowing email
+	// domains.
+	PermissionEmailDomainManage Permission = "email_domain.manage"
+	// PermissionEmailDeliveryManage allows looking into the emails sent to
+	// users and sending them again.
+	PermissionEmailDeliveryManage Permission = "email_delivery.manage"
+	// PermissionAuthzCheck allows asking for authorization decisions about
+	// other users' tokens, which is meant for other platform services.
+	PermissionAuthzCheck Permission = "authz.check"
+)
+
+var Permissions = []Permission{
+	PermissionUserList,
+	PermissionUserReadPii,
+	PermissionUserBan,
+	PermissionUserAssignRole,
+	PermissionInviteCreate,
+	PermissionInviteList,
+	PermissionInviteRevoke,
+	PermissionInviteAudit,
+	PermissionInviteGrant,
+	PermissionInviteBulk,
+	PermissionRoleManage,
+	PermissionUserImpersonate,
+	PermissionServiceAccountManage,
+	PermissionWaitlistManage,
+	PermissionRegistrationManage,
+	PermissionEmailDomainManage,
+	PermissionEmailDeliveryManage,
+	PermissionAuthzCheck,
+}
+
+func (p Permission) IsValid() bool {
+	for _, permission := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type Reauth struct {
+	Password string     `json:"password"`
+	Client   ClientInfo `json:"-"`
+}
+
+func DecodeRequestToReauth(r *http.Request) (*Reauth, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *Reauth
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Registration tells clients who can sign up right now.
+type Registration struct {
+	// Mode is invite_only, open, open_with_domain_allowlist or closed.
+	Mode string `json:"mode"`
+
+	// InviteRequired is set when an invite is needed to sign up, unless the
+	// address is on one of the AllowedDomains, so clients know to ask for one.
+	InviteRequired bool `json:"inviteRequired"`
+
+	// AllowedDomains can sign up without an invite in the
+	// open_with_domain_allowlist mode.
+	AllowedDomains []string `json:"allowedDomains"`
+
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+type RegistrationUpdate struct {
+	Mode string `json:"mode"`
+
+	AllowedDomains []string `json:"allowedDomains"`
+}
+
+func DecodeRequestToRegistrationUpdate(r *http.Request) (*RegistrationUpdate, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *RegistrationUpdate
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -2,7 +2,7 @@ package model
 
 type Role string
 
-// List of Role
+// List of built-in Role
 const (
 	USER      Role = "user"
 	MODERATOR Role = "moderator"
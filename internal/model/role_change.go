@@ -0,0 +1,20 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type RoleChange struct {
+	Role Role `json:"role"`
+}
+
+func DecodeRequestToRoleChange(r *http.Request) (*RoleChange, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *RoleChange
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
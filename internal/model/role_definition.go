@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RoleDefinition is a named bundle of permissions. Level places the role in
+// the hierarchy: a user can only administer users whose highest role has a
+// lower level than their own.
+type RoleDefinition struct {
+	Name Role `json:"name"`
+
+	Description string `json:"description,omitempty"`
+
+	Level int `json:"level"`
+
+	Permissions []Permission `json:"permissions"`
+}
+
+func DecodeRequestToRoleDefinition(r *http.Request) (*RoleDefinition, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *RoleDefinition
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
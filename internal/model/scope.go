@@ -0,0 +1,82 @@
+package model
+
+import "strings"
+
+type Scope string
+
+// List of Scope. A scope grants the permissions it maps to, as long as the
+// token's owner holds them too.
+const (
+	ScopeUsersRead       Scope = "users:read"
+	ScopeUsersReadPii    Scope = "users:read_pii"
+	ScopeUsersBan        Scope = "users:ban"
+	ScopeUsersAssignRole Scope = "users:assign_role"
+	ScopeInvitesRead     Scope = "invites:read"
+	ScopeInvitesWrite    Scope = "invites:write"
+	ScopeRolesWrite      Scope = "roles:write"
+	ScopeAuthzCheck      Scope = "authz:check"
+)
+
+var ScopePermissions = map[Scope][]Permission{
+	ScopeUsersRead:       {PermissionUserList},
+	ScopeUsersReadPii:    {PermissionUserReadPii},
+	ScopeUsersBan:        {PermissionUserBan},
+	ScopeUsersAssignRole: {PermissionUserAssignRole},
+	ScopeInvitesRead:     {PermissionInviteList},
+	ScopeInvitesWrite:    {PermissionInviteCreate},
+	ScopeRolesWrite:      {PermissionRoleManage},
+	ScopeAuthzCheck:      {PermissionAuthzCheck},
+}
+
+func (s Scope) IsValid() bool {
+	_, ok := ScopePermissions[s]
+	return ok
+}
+
+// ScopesAllow reports whether any of the scopes covers the permission.
+func ScopesAllow(scopes []Scope, permission Permission) bool {
+	for _, scope := range scopes {
+		for _, p := range ScopePermissions[scope] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseScopes reads a space-separated list of scopes, as used in OAuth2
+// requests and stored on tokens. It returns nil for an empty list.
+func ParseScopes(value string) []Scope {
+	var scopes []Scope
+	for _, scope := range strings.Fields(value) {
+		scopes = append(scopes, Scope(scope))
+	}
+	return scopes
+}
+
+func JoinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, " ")
+}
+
+// ContainsScopes reports whether every one of the requested scopes is in the
+// granted scopes.
+func ContainsScopes(granted []Scope, requested []Scope) bool {
+	for _, scope := range requested {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
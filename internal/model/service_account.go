@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type ServiceAccount struct {
+	Uuid string `json:"uuid"`
+
+	Name string `json:"name"`
+
+	ClientId string `json:"client_id"`
+
+	Scopes []Scope `json:"scopes"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// ClientSecret is only set in the response that creates the account.
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+type NewServiceAccount struct {
+	Name string `json:"name"`
+
+	Scopes []Scope `json:"scopes"`
+}
+
+func DecodeRequestToNewServiceAccount(r *http.Request) (*NewServiceAccount, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *NewServiceAccount
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
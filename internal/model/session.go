@@ -9,10 +9,26 @@
 
 package model
 
+import "time"
+
 // Session struct for Session
 type Session struct {
 	User  *User  `json:"user"`
 	Token string `json:"token,omitempty"`
+	// Scopes restricts what the session can do. It is nil for sessions that
+	// carry all of the user's permissions.
+	Scopes []Scope `json:"scopes,omitempty"`
+	// Impersonator is the admin acting as the user, if any.
+	Impersonator *User `json:"impersonator,omitempty"`
+	// AuthTime is when the user last entered their credentials. It is nil
+	// for tokens that weren't issued by logging in.
+	AuthTime *time.Time `json:"auth_time,omitempty"`
+	// CsrfToken is sent back in the x-csrf-token header with state changing
+	// requests when the token is kept in a cookie.
+	CsrfToken string `json:"csrf_token,omitempty"`
+	// EmailDeliveryStatus is bounced or complained when our email doesn't
+	// reach the user's address, and the user should update it.
+	EmailDeliveryStatus string `json:"email_delivery_status,omitempty"`
 }
 
 func CreateSession(user *User, token string) *Session {
@@ -21,3 +37,7 @@ func CreateSession(user *User, token string) *Session {
 		Token: token,
 	}
 }
+
+func (s *Session) IsImpersonated() bool {
+	return s.Impersonator != nil
+}
@@ -14,7 +14,9 @@ import (
 )
 
 type SessionToken struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"`
+
+	CsrfToken string `json:"csrf_token,omitempty"`
 }
 
 func DecodeRequestToSessionToken(r *http.Request) *SessionToken {
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"time"
+)
+
+// SocialAuthorization is where to send the user to sign in with an identity
+// provider.
+type SocialAuthorization struct {
+	Url string `json:"url"`
+
+	State string `json:"state"`
+
+	// Nonce is kept in a cookie, so only the browser that started signing
+	// in can complete it.
+	Nonce string `json:"-"`
+}
+
+type SocialCallback struct {
+	Code string `json:"code"`
+
+	State string `json:"state"`
+
+	UseCookie bool `json:"useCookie"`
+
+	Nonce string `json:"-"`
+
+	Client ClientInfo `json:"-"`
+}
+
+// SocialState is signed and sent through the identity provider, so the
+// callback can't be forged and the invite code survives the round trip.
+// NonceHash ties it to the nonce cookie of the browser that started.
+type SocialState struct {
+	Provider   string `json:"provider"`
+	InviteCode string `json:"inviteCode,omitempty"`
+	NonceHash  string `json:"nonceHash"`
+	jwt.RegisteredClaims
+}
+
+func NewSocialState(provider string, inviteCode string, nonceHash string, lifetime time.Duration) *SocialState {
+	now := time.Now()
+	return &SocialState{
+		Provider:   provider,
+		InviteCode: inviteCode,
+		NonceHash:  nonceHash,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+}
+
+type LinkedIdentity struct {
+	Uuid string `json:"uuid"`
+
+	Provider string `json:"provider"`
+
+	Email string `json:"email,omitempty"`
+
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func DecodeRequestToSocialCallback(r *http.Request) (*SocialCallback, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *SocialCallback
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -32,6 +32,8 @@ type User struct {
 
 	Role Role `json:"role,omitempty"`
 
+	Roles []Role `json:"roles,omitempty"`
+
 	IsBanned bool `json:"is_banned,omitempty"`
 
 	Birthday string `json:"birthday,omitempty"`
@@ -42,6 +44,8 @@ type User struct {
 
 	AddressZip string `json:"address_zip,omitempty"`
 
+	Locale string `json:"locale,omitempty"`
+
 	CreatedAt time.Time `json:"created_at,omitempty"`
 
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
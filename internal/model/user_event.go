@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+type UserEventType string
+
+const (
+	UserEventRoleChanged  UserEventType = "user.role_changed"
+	UserEventImpersonated UserEventType = "user.impersonated"
+)
+
+// UserEvent is published to the user-events topic when something happens to
+// a user that other services may want to react to.
+type UserEvent struct {
+	Type      UserEventType     `json:"type"`
+	User      *User             `json:"user"`
+	ActorUuid string            `json:"actor_uuid,omitempty"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func CreateUserEvent(eventType UserEventType, user *User, actorUuid string, data map[string]string) *UserEvent {
+	return &UserEvent{
+		Type:      eventType,
+		User:      user,
+		ActorUuid: actorUuid,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+}
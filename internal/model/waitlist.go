@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type WaitlistEntry struct {
+	Email string `json:"email"`
+
+	Reason string `json:"reason,omitempty"`
+
+	Status string `json:"status"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	ConfirmedAt *time.Time `json:"confirmedAt,omitempty"`
+
+	InvitedAt *time.Time `json:"invitedAt,omitempty"`
+}
+
+// NewWaitlistEntry asks to join without an invite.
+type NewWaitlistEntry struct {
+	Email string `json:"email"`
+
+	// Reason tells admins why the person wants to join.
+	Reason string `json:"reason"`
+
+	// Locale picks the language of the emails they get.
+	Locale string `json:"locale"`
+}
+
+type WaitlistConfirmation struct {
+	Code string `json:"code"`
+}
+
+// WaitlistApproval lets in the oldest confirmed entries.
+type WaitlistApproval struct {
+	Count int `json:"count"`
+}
+
+// WaitlistBatch is the outcome of an approval. Entries whose invite couldn't
+// be sent stay confirmed, so the next batch picks them up again.
+type WaitlistBatch struct {
+	Invited []*WaitlistEntry `json:"invited"`
+
+	Failed []string `json:"failed"`
+}
+
+func DecodeRequestToNewWaitlistEntry(r *http.Request) (*NewWaitlistEntry, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *NewWaitlistEntry
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func DecodeRequestToWaitlistConfirmation(r *http.Request) (*WaitlistConfirmation, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *WaitlistConfirmation
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func DecodeRequestToWaitlistApproval(r *http.Request) (*WaitlistApproval, error) {
+	decoder := json.NewDecoder(r.Body)
+	var data *WaitlistApproval
+	err := decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"github.com/third-place/user-service/internal/ratelimit"
+	"time"
+)
+
+var (
+	// readRateLimit is for routes that only look things up.
+	readRateLimit = ratelimit.PerIp(300, time.Minute)
+	// writeRateLimit is for routes that change things.
+	writeRateLimit = ratelimit.PerUser(60, time.Minute)
+	// authzRateLimit is for routes other services call on every request.
+	authzRateLimit = ratelimit.PerUser(1200, time.Minute)
+	// loginRateLimit slows down password guessing.
+	loginRateLimit = ratelimit.PerIp(10, time.Minute)
+	// reauthRateLimit slows down password guessing with a stolen session.
+	reauthRateLimit = ratelimit.PerUser(10, 15*time.Minute)
+	// signUpRateLimit slows down creating accounts in bulk.
+	signUpRateLimit = ratelimit.PerIp(5, time.Hour)
+	// codeRateLimit slows down sending mail and guessing the codes in it.
+	codeRateLimit = ratelimit.PerIp(5, 15*time.Minute)
+	// credentialRateLimit is for routes that hand out long-lived credentials
+	// or act as someone else.
+	credentialRateLimit = ratelimit.PerUser(20, time.Hour)
+	// webhookRateLimit is for mail providers calling back with events,
+	// which arrive in bursts.
+	webhookRateLimit = ratelimit.PerIp(1200, time.Minute)
+)
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	period    time.Duration
+}
+
+// MemoryStore keeps buckets in this process, so each replica enforces its
+// own limits.
+type MemoryStore struct {
+	buckets map[string]*bucket
+	sweptAt time.Time
+	mutex   sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+		sweptAt: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy *Policy) (*Result, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	rate := float64(policy.Limit) / policy.Period.Seconds()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{float64(policy.Limit), now, policy.Period}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(policy.Limit), b.tokens+now.Sub(b.updatedAt).Seconds()*rate)
+	b.updatedAt = now
+	result := &Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((float64(policy.Limit) - b.tokens) / rate)
+	return result, nil
+}
+
+// sweep forgets buckets that have had time to fill up again, since a new
+// bucket would be the same.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < memorySweepInterval {
+		return
+	}
+	s.sweptAt = now
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) > b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
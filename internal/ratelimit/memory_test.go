@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_MemoryStore_Denies_Requests_Over_The_Limit(t *testing.T) {
+	// setup
+	store := NewMemoryStore()
+	policy := PerIp(2, time.Minute)
+
+	// given
+	for i := 0; i < 2; i++ {
+		result, _ := store.Take(context.Background(), "key", policy)
+		if !result.Allowed {
+			t.Fatal("expected requests within the limit to be allowed")
+		}
+	}
+
+	// when
+	result, err := store.Take(context.Background(), "key", policy)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Error("expected a request over the limit to be denied")
+	}
+	if result.Remaining != 0 || result.RetryAfter <= 0 || result.RetryAfter > 30*time.Second {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func Test_MemoryStore_Refills_Over_The_Period(t *testing.T) {
+	// setup
+	store := NewMemoryStore()
+	policy := PerIp(1, 50*time.Millisecond)
+
+	// given
+	store.Take(context.Background(), "key", policy)
+	result, _ := store.Take(context.Background(), "key", policy)
+	if result.Allowed {
+		t.Fatal("expected the bucket to be empty")
+	}
+
+	// when
+	time.Sleep(result.RetryAfter)
+	result, _ = store.Take(context.Background(), "key", policy)
+
+	// then
+	if !result.Allowed {
+		t.Error("expected the bucket to refill after the period")
+	}
+}
+
+func Test_MemoryStore_Keeps_A_Bucket_Per_Key(t *testing.T) {
+	// setup
+	store := NewMemoryStore()
+	policy := PerIp(1, time.Minute)
+
+	// given
+	store.Take(context.Background(), "first", policy)
+
+	// when
+	result, _ := store.Take(context.Background(), "second", policy)
+
+	// then
+	if !result.Allowed {
+		t.Error("expected another key to have its own bucket")
+	}
+}
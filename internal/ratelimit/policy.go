@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Key is what a policy counts requests by.
+type Key int
+
+const (
+	// KeyIp gives every client address its own bucket.
+	KeyIp Key = iota
+	// KeyUser gives every session user their own bucket, and falls back to
+	// the client address for anonymous requests.
+	KeyUser
+	// KeyRoute shares one bucket between everyone calling the route.
+	KeyRoute
+)
+
+// Policy is a token bucket that holds up to Limit requests and refills
+// completely every Period.
+type Policy struct {
+	Key    Key
+	Limit  int
+	Period time.Duration
+}
+
+func PerIp(limit int, period time.Duration) *Policy {
+	return &Policy{KeyIp, limit, period}
+}
+
+func PerUser(limit int, period time.Duration) *Policy {
+	return &Policy{KeyUser, limit, period}
+}
+
+func PerRoute(limit int, period time.Duration) *Policy {
+	return &Policy{KeyRoute, limit, period}
+}
+
+// String describes the policy for the RateLimit-Policy header.
+func (p *Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(p.Period.Seconds()))
+}
@@ -0,0 +1,23 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result is the state of a bucket after taking a request from it.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next request is allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets. Replicas that should share their limits need a
+// Store backed by something they can all reach, like redis.
+type Store interface {
+	// Take removes a request from the key's bucket if there is one left.
+	Take(ctx context.Context, key string, policy *Policy) (*Result, error)
+}
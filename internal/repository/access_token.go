@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+	"time"
+)
+
+type AccessTokenRepository struct {
+	conn *gorm.DB
+}
+
+func CreateAccessTokenRepository(conn *gorm.DB) *AccessTokenRepository {
+	return &AccessTokenRepository{conn}
+}
+
+func (r *AccessTokenRepository) FindOneByHash(hash string) (*entity.AccessToken, error) {
+	token := &entity.AccessToken{}
+	r.conn.Preload("User").Where("hash = ?", hash).Find(token)
+	if token.ID == 0 {
+		return nil, errors.New("access token not found")
+	}
+	return token, nil
+}
+
+func (r *AccessTokenRepository) FindOneByUuid(user *entity.User, tokenUuid uuid.UUID) (*entity.AccessToken, error) {
+	token := &entity.AccessToken{}
+	r.conn.Where("user_id = ? AND uuid = ?", user.ID, tokenUuid.String()).Find(token)
+	if token.ID == 0 {
+		return nil, errors.New("access token not found")
+	}
+	return token, nil
+}
+
+func (r *AccessTokenRepository) FindActiveForUser(user *entity.User) []*entity.AccessToken {
+	var tokens []*entity.AccessToken
+	r.conn.Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Order("id desc").
+		Find(&tokens)
+	return tokens
+}
+
+// RevokeForUser revokes every token of the user that isn't revoked yet.
+func (r *AccessTokenRepository) RevokeForUser(user *entity.User) *gorm.DB {
+	return r.conn.Model(&entity.AccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", time.Now())
+}
+
+func (r *AccessTokenRepository) Create(token *entity.AccessToken) *gorm.DB {
+	return r.conn.Omit("User").Create(token)
+}
+
+func (r *AccessTokenRepository) Save(token *entity.AccessToken) *gorm.DB {
+	return r.conn.Omit("User").Save(token)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository struct {
+	conn *gorm.DB
+}
+
+func CreateAuditLogRepository(conn *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{conn}
+}
+
+func (r *AuditLogRepository) Create(log *entity.AuditLog) *gorm.DB {
+	return r.conn.Omit("Actor", "Subject").Create(log)
+}
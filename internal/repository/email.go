@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type EmailRepository struct {
+	conn *gorm.DB
+}
+
+func CreateEmailRepository(conn *gorm.DB) *EmailRepository {
+	return &EmailRepository{conn}
+}
+
+// FindOneByEmail looks up an address. Addresses are stored lower case.
+func (r *EmailRepository) FindOneByEmail(address string) (*entity.Email, error) {
+	email := &entity.Email{}
+	r.conn.Where("email = ?", strings.ToLower(strings.TrimSpace(address))).Find(email)
+	if email.ID == 0 {
+		return nil, errors.New("email not found")
+	}
+	return email, nil
+}
+
+// IsUndeliverable tells whether the address bounced or reported our email
+// as spam.
+func (r *EmailRepository) IsUndeliverable(address string) bool {
+	email, err := r.FindOneByEmail(address)
+	return err == nil && email.DeliveryStatus.IsUndeliverable()
+}
+
+// MarkDeliverable clears a bounce or complaint, once the owner showed they
+// get our email.
+func (r *EmailRepository) MarkDeliverable(address string) *gorm.DB {
+	now := time.Now()
+	return r.conn.Model(&entity.Email{}).
+		Where("email = ? AND delivery_status <> ?", strings.ToLower(strings.TrimSpace(address)), enum.EmailDeliveryStatusDeliverable).
+		Updates(map[string]interface{}{
+			"delivery_status":    enum.EmailDeliveryStatusDeliverable,
+			"delivery_reason":    "",
+			"delivery_status_at": now,
+		})
+}
+
+func (r *EmailRepository) Save(email *entity.Email) *gorm.DB {
+	return r.conn.Save(email)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type EmailDomainRuleRepository struct {
+	conn *gorm.DB
+}
+
+func CreateEmailDomainRuleRepository(conn *gorm.DB) *EmailDomainRuleRepository {
+	return &EmailDomainRuleRepository{conn}
+}
+
+func (r *EmailDomainRuleRepository) FindRules(offset int) []*entity.EmailDomainRule {
+	var rules []*entity.EmailDomainRule
+	r.conn.Order("domain asc").
+		Limit(25).
+		Offset(offset).
+		Find(&rules)
+	return rules
+}
+
+func (r *EmailDomainRuleRepository) FindOneByDomain(domain string) (*entity.EmailDomainRule, error) {
+	rule := &entity.EmailDomainRule{}
+	r.conn.Where("domain = ?", domain).Find(rule)
+	if rule.ID == 0 {
+		return nil, errors.New("email domain rule not found")
+	}
+	return rule, nil
+}
+
+// FindForDomains returns the rules for any of the domains.
+func (r *EmailDomainRuleRepository) FindForDomains(domains []string) []*entity.EmailDomainRule {
+	var rules []*entity.EmailDomainRule
+	r.conn.Where("domain IN ?", domains).Find(&rules)
+	return rules
+}
+
+func (r *EmailDomainRuleRepository) Save(rule *entity.EmailDomainRule) *gorm.DB {
+	return r.conn.Save(rule)
+}
+
+func (r *EmailDomainRuleRepository) Delete(rule *entity.EmailDomainRule) *gorm.DB {
+	return r.conn.Unscoped().Delete(rule)
+}
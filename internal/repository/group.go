@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type GroupRepository struct {
+	conn *gorm.DB
+}
+
+func CreateGroupRepository(conn *gorm.DB) *GroupRepository {
+	return &GroupRepository{conn}
+}
+
+func (r *GroupRepository) FindOneBySlug(slug string) (*entity.Group, error) {
+	group := &entity.Group{}
+	r.conn.Preload("Owner").Where("slug = ?", slug).Find(group)
+	if group.ID == 0 {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+func (r *GroupRepository) FindMember(group *entity.Group, user *entity.User) (*entity.GroupMember, error) {
+	member := &entity.GroupMember{}
+	r.conn.Where("group_id = ? AND user_id = ?", group.ID, user.ID).Find(member)
+	if member.ID == 0 {
+		return nil, errors.New("member not found")
+	}
+	return member, nil
+}
+
+func (r *GroupRepository) FindMembers(group *entity.Group, offset int) []*entity.GroupMember {
+	var members []*entity.GroupMember
+	r.conn.Preload("User").
+		Where("group_id = ?", group.ID).
+		Order("id").
+		Limit(25).
+		Offset(offset).
+		Find(&members)
+	return members
+}
+
+func (r *GroupRepository) FindMembershipsForUser(user *entity.User, offset int) []*entity.GroupMember {
+	var members []*entity.GroupMember
+	r.conn.Preload("Group").
+		Preload("Group.Owner").
+		Where("user_id = ?", user.ID).
+		Order("id desc").
+		Limit(25).
+		Offset(offset).
+		Find(&members)
+	return members
+}
+
+func (r *GroupRepository) CountOwners(group *entity.Group) int64 {
+	var count int64
+	r.conn.Model(&entity.GroupMember{}).
+		Where("group_id = ? AND role = 'owner' AND status = 'active'", group.ID).
+		Count(&count)
+	return count
+}
+
+// Create saves a new group together with its owner's membership.
+func (r *GroupRepository) Create(group *entity.Group, owner *entity.GroupMember) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Owner").Create(group).Error; err != nil {
+			return err
+		}
+		owner.GroupID = group.ID
+		return tx.Omit("Group", "User").Create(owner).Error
+	})
+}
+
+func (r *GroupRepository) Save(group *entity.Group) *gorm.DB {
+	return r.conn.Omit("Owner").Save(group)
+}
+
+func (r *GroupRepository) SaveMember(member *entity.GroupMember) *gorm.DB {
+	return r.conn.Omit("Group", "User").Save(member)
+}
+
+func (r *GroupRepository) DeleteMember(member *entity.GroupMember) *gorm.DB {
+	return r.conn.Unscoped().Delete(member)
+}
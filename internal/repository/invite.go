@@ -4,6 +4,12 @@ import (
 	"errors"
 	"github.com/third-place/user-service/internal/entity"
 	"gorm.io/gorm"
+	"time"
+)
+
+var (
+	ErrInviteQuotaExceeded = errors.New("no invites left")
+	ErrInviteUsedUp        = errors.New("invite already used")
 )
 
 type InviteRepository struct {
@@ -34,10 +40,116 @@ func (r *InviteRepository) FindOneByCode(code string) (*entity.Invite, error) {
 	return invite, nil
 }
 
+func (r *InviteRepository) FindOneByTrackingTokenHash(hash string) (*entity.Invite, error) {
+	invite := &entity.Invite{}
+	r.conn.Where("tracking_token_hash = ?", hash).Find(invite)
+	if invite.ID == 0 {
+		return nil, errors.New("no invite found")
+	}
+	return invite, nil
+}
+
+func (r *InviteRepository) FindForCreator(creatorID uint, offset int) []*entity.Invite {
+	var invites []*entity.Invite
+	r.conn.Where("creator_id = ?", creatorID).
+		Order("id desc").
+		Limit(25).
+		Offset(offset).
+		Find(&invites)
+	return invites
+}
+
+// InviteUsage is how many invites a user has made against their allowance
+// this month, and against their grants ever.
+type InviteUsage struct {
+	Monthly int
+	Granted int
+}
+
+func (r *InviteRepository) CountForCreator(creatorID uint, since time.Time) *InviteUsage {
+	return countForCreator(r.conn, creatorID, since)
+}
+
+// CreateWithinQuota creates the invite for its creator if they have any of
+// their monthly allowance left, or else any granted invites. The creator is
+// locked while counting so that concurrent requests can't overspend.
+func (r *InviteRepository) CreateWithinQuota(invite *entity.Invite, since time.Time, monthly int, granted int) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", *invite.CreatorID).Error
+		if err != nil {
+			return err
+		}
+		usage := countForCreator(tx, *invite.CreatorID, since)
+		if usage.Monthly < monthly {
+			invite.Granted = false
+		} else if usage.Granted < granted {
+			invite.Granted = true
+		} else {
+			return ErrInviteQuotaExceeded
+		}
+		return tx.Create(invite).Error
+	})
+}
+
+func countForCreator(conn *gorm.DB, creatorID uint, since time.Time) *InviteUsage {
+	var monthly, granted int64
+	conn.Model(&entity.Invite{}).
+		Where("creator_id = ? AND NOT granted AND created_at >= ?", creatorID, since).
+		Count(&monthly)
+	conn.Model(&entity.Invite{}).
+		Where("creator_id = ? AND granted", creatorID).
+		Count(&granted)
+	return &InviteUsage{int(monthly), int(granted)}
+}
+
+// Claim uses the invite once. It fails when another sign-up used it up
+// first.
+func (r *InviteRepository) Claim(invite *entity.Invite) error {
+	result := r.conn.Model(invite).
+		Where("NOT claimed AND NOT revoked AND uses < max_uses").
+		Updates(map[string]interface{}{
+			"uses":    gorm.Expr("uses + 1"),
+			"claimed": gorm.Expr("uses + 1 >= max_uses"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInviteUsedUp
+	}
+	return r.conn.First(invite, invite.ID).Error
+}
+
 func (r *InviteRepository) Create(invite *entity.Invite) *gorm.DB {
 	return r.conn.Create(invite)
 }
 
+func (r *InviteRepository) Delete(invite *entity.Invite) *gorm.DB {
+	return r.conn.Unscoped().Delete(invite)
+}
+
 func (r *InviteRepository) Save(invite *entity.Invite) *gorm.DB {
 	return r.conn.Save(invite)
 }
+
+// CampaignStats counts how the invites tagged with a campaign were used.
+type CampaignStats struct {
+	Codes    int
+	Redeemed int
+	Uses     int
+	Expired  int
+	Revoked  int
+}
+
+func (r *InviteRepository) CountForCampaign(campaign string) *CampaignStats {
+	stats := &CampaignStats{}
+	r.conn.Model(&entity.Invite{}).
+		Where("campaign = ?", campaign).
+		Select(`COUNT(*) AS codes,
+			COUNT(*) FILTER (WHERE uses > 0) AS redeemed,
+			COALESCE(SUM(uses), 0) AS uses,
+			COUNT(*) FILTER (WHERE expires_at < NOW()) AS expired,
+			COUNT(*) FILTER (WHERE revoked) AS revoked`).
+		Scan(stats)
+	return stats
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type InviteBatchRepository struct {
+	conn *gorm.DB
+}
+
+func CreateInviteBatchRepository(conn *gorm.DB) *InviteBatchRepository {
+	return &InviteBatchRepository{conn}
+}
+
+func (r *InviteBatchRepository) FindOneByUuid(batchUuid uuid.UUID) (*entity.InviteBatch, error) {
+	batch := &entity.InviteBatch{}
+	r.conn.Where("uuid = ?", batchUuid.String()).Find(batch)
+	if batch.ID == 0 {
+		return nil, errors.New("invite batch not found")
+	}
+	return batch, nil
+}
+
+// FindInvites returns every invite in the batch, in the order they were
+// generated.
+func (r *InviteBatchRepository) FindInvites(batch *entity.InviteBatch) []*entity.Invite {
+	var invites []*entity.Invite
+	r.conn.Where("batch_id = ?", batch.ID).
+		Order("id asc").
+		Find(&invites)
+	return invites
+}
+
+// FindTakenCodes returns which of the codes already belong to an invite.
+func (r *InviteBatchRepository) FindTakenCodes(codes []string) []string {
+	var taken []string
+	r.conn.Model(&entity.Invite{}).
+		Unscoped().
+		Where("code IN ?", codes).
+		Pluck("code", &taken)
+	return taken
+}
+
+// Create saves the batch and all of its invites, or none of them.
+func (r *InviteBatchRepository) Create(batch *entity.InviteBatch, invites []*entity.Invite) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(batch).Error
+		if err != nil {
+			return err
+		}
+		for _, invite := range invites {
+			invite.BatchID = &batch.ID
+		}
+		return tx.CreateInBatches(invites, 100).Error
+	})
+}
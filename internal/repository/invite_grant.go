@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type InviteGrantRepository struct {
+	conn *gorm.DB
+}
+
+func CreateInviteGrantRepository(conn *gorm.DB) *InviteGrantRepository {
+	return &InviteGrantRepository{conn}
+}
+
+// SumForUser is how many invites admins have granted the user in total.
+func (r *InviteGrantRepository) SumForUser(user *entity.User) int {
+	var total int64
+	r.conn.Model(&entity.InviteGrant{}).
+		Where("user_id = ?", user.ID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total)
+	return int(total)
+}
+
+func (r *InviteGrantRepository) Create(grant *entity.InviteGrant) *gorm.DB {
+	return r.conn.Create(grant)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type LinkedIdentityRepository struct {
+	conn *gorm.DB
+}
+
+func CreateLinkedIdentityRepository(conn *gorm.DB) *LinkedIdentityRepository {
+	return &LinkedIdentityRepository{conn}
+}
+
+func (r *LinkedIdentityRepository) FindOneBySubject(provider string, subject string) (*entity.LinkedIdentity, error) {
+	linkedIdentity := &entity.LinkedIdentity{}
+	r.conn.Preload("User").
+		Where("provider = ? AND subject = ?", provider, subject).
+		Find(linkedIdentity)
+	if linkedIdentity.ID == 0 {
+		return nil, errors.New("linked identity not found")
+	}
+	return linkedIdentity, nil
+}
+
+func (r *LinkedIdentityRepository) FindOneByUuid(user *entity.User, identityUuid uuid.UUID) (*entity.LinkedIdentity, error) {
+	linkedIdentity := &entity.LinkedIdentity{}
+	r.conn.Where("user_id = ? AND uuid = ?", user.ID, identityUuid.String()).Find(linkedIdentity)
+	if linkedIdentity.ID == 0 {
+		return nil, errors.New("linked identity not found")
+	}
+	return linkedIdentity, nil
+}
+
+func (r *LinkedIdentityRepository) FindForUser(user *entity.User) []*entity.LinkedIdentity {
+	var linkedIdentities []*entity.LinkedIdentity
+	r.conn.Where("user_id = ?", user.ID).Order("id").Find(&linkedIdentities)
+	return linkedIdentities
+}
+
+func (r *LinkedIdentityRepository) Create(linkedIdentity *entity.LinkedIdentity) *gorm.DB {
+	return r.conn.Omit("User").Create(linkedIdentity)
+}
+
+func (r *LinkedIdentityRepository) Save(linkedIdentity *entity.LinkedIdentity) *gorm.DB {
+	return r.conn.Omit("User").Save(linkedIdentity)
+}
+
+func (r *LinkedIdentityRepository) Delete(linkedIdentity *entity.LinkedIdentity) *gorm.DB {
+	return r.conn.Unscoped().Delete(linkedIdentity)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	conn *gorm.DB
+}
+
+func CreateLoginAttemptRepository(conn *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{conn}
+}
+
+func (r *LoginAttemptRepository) FindForUser(user *entity.User, offset int) []*entity.LoginAttempt {
+	var attempts []*entity.LoginAttempt
+	r.conn.Where("user_id = ?", user.ID).
+		Order("id desc").
+		Limit(25).
+		Offset(offset).
+		Find(&attempts)
+	return attempts
+}
+
+func (r *LoginAttemptRepository) FindOneByRevokeCodeHash(hash string) (*entity.LoginAttempt, error) {
+	attempt := &entity.LoginAttempt{}
+	r.conn.Where("revoke_code_hash = ?", hash).Find(attempt)
+	if attempt.ID == 0 {
+		return nil, errors.New("login attempt not found")
+	}
+	return attempt, nil
+}
+
+func (r *LoginAttemptRepository) HasSucceeded(user *entity.User) bool {
+	return r.countSuccessful(user, "true") > 0
+}
+
+func (r *LoginAttemptRepository) IsKnownDevice(user *entity.User, fingerprint string) bool {
+	return r.countSuccessful(user, "device_fingerprint = ?", fingerprint) > 0
+}
+
+func (r *LoginAttemptRepository) IsKnownIpRange(user *entity.User, ipRange string) bool {
+	return r.countSuccessful(user, "ip_range = ?", ipRange) > 0
+}
+
+func (r *LoginAttemptRepository) countSuccessful(user *entity.User, query string, args ...interface{}) int64 {
+	var count int64
+	r.conn.Model(&entity.LoginAttempt{}).
+		Where("user_id = ? AND success", user.ID).
+		Where(query, args...).
+		Count(&count)
+	return count
+}
+
+func (r *LoginAttemptRepository) Create(attempt *entity.LoginAttempt) *gorm.DB {
+	return r.conn.Create(attempt)
+}
+
+func (r *LoginAttemptRepository) Save(attempt *entity.LoginAttempt) *gorm.DB {
+	return r.conn.Save(attempt)
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+	"time"
+)
+
+type OutboxEmailRepository struct {
+	conn *gorm.DB
+}
+
+func CreateOutboxEmailRepository(conn *gorm.DB) *OutboxEmailRepository {
+	return &OutboxEmailRepository{conn}
+}
+
+// SaveWithEmail saves a record and queues the email the change to it
+// causes in one transaction, so the email goes out if and only if the
+// change is stored.
+func (r *OutboxEmailRepository) SaveWithEmail(record interface{}, email *entity.OutboxEmail) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(record).Error; err != nil {
+			return err
+		}
+		return tx.Create(email).Error
+	})
+}
+
+func (r *OutboxEmailRepository) Create(email *entity.OutboxEmail) *gorm.DB {
+	return r.conn.Create(email)
+}
+
+func (r *OutboxEmailRepository) Save(email *entity.OutboxEmail) *gorm.DB {
+	return r.conn.Save(email)
+}
+
+func (r *OutboxEmailRepository) FindOneByUuid(emailUuid uuid.UUID) (*entity.OutboxEmail, error) {
+	email := &entity.OutboxEmail{}
+	r.conn.Where("uuid = ?", emailUuid.String()).Find(email)
+	if email.ID == 0 {
+		return nil, errors.New("email not found")
+	}
+	return email, nil
+}
+
+// FindForUser lists the emails sent to a user, newest first.
+func (r *OutboxEmailRepository) FindForUser(userID uint, offset int) []*entity.OutboxEmail {
+	var emails []*entity.OutboxEmail
+	r.conn.Where("user_id = ?", userID).
+		Order("id desc").
+		Limit(25).
+		Offset(offset).
+		Find(&emails)
+	return emails
+}
+
+// ClaimDue returns up to count pending emails that are due, and pushes
+// their next attempt back by lease so other workers leave them alone while
+// they're sent. Rows other workers have locked are skipped.
+func (r *OutboxEmailRepository) ClaimDue(count int, lease time.Duration) ([]*entity.OutboxEmail, error) {
+	var emails []*entity.OutboxEmail
+	now := time.Now()
+	err := r.conn.Raw(`UPDATE outbox_emails
+		SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_emails
+			WHERE status = ? AND next_attempt_at <= ? AND deleted_at IS NULL
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease),
+		now,
+		enum.OutboxStatusPending,
+		now,
+		count,
+	).Scan(&emails).Error
+	return emails, err
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+)
+
+type RegistrationSettingsRepository struct {
+	conn *gorm.DB
+}
+
+func CreateRegistrationSettingsRepository(conn *gorm.DB) *RegistrationSettingsRepository {
+	return &RegistrationSettingsRepository{conn}
+}
+
+// Get returns the registration settings, which are invite-only until an
+// admin changes them.
+func (r *RegistrationSettingsRepository) Get() *entity.RegistrationSettings {
+	settings := &entity.RegistrationSettings{}
+	r.conn.Order("id asc").Limit(1).Find(settings)
+	if settings.ID == 0 {
+		settings.Mode = enum.RegistrationModeInviteOnly
+		settings.AllowedDomains = []string{}
+	}
+	return settings
+}
+
+func (r *RegistrationSettingsRepository) Save(settings *entity.RegistrationSettings) *gorm.DB {
+	return r.conn.Save(settings)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type RevokedSessionRepository struct {
+	conn *gorm.DB
+}
+
+func CreateRevokedSessionRepository(conn *gorm.DB) *RevokedSessionRepository {
+	return &RevokedSessionRepository{conn}
+}
+
+// Revoke records the session token so it's rejected until it expires.
+// Expired tokens are deleted along the way.
+func (r *RevokedSessionRepository) Revoke(tokenHash string, expiresAt time.Time) error {
+	err := r.conn.Where("expires_at < ?", time.Now()).Delete(&entity.RevokedSession{}).Error
+	if err != nil {
+		return err
+	}
+	return r.conn.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.RevokedSession{
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// IsRevoked reports whether the session token was revoked. Tokens are
+// treated as revoked when that can't be checked.
+func (r *RevokedSessionRepository) IsRevoked(tokenHash string) bool {
+	var count int64
+	err := r.conn.Model(&entity.RevokedSession{}).Where("token_hash = ?", tokenHash).Count(&count).Error
+	return err != nil || count > 0
+}
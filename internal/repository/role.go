@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	conn *gorm.DB
+}
+
+func CreateRoleRepository(conn *gorm.DB) *RoleRepository {
+	return &RoleRepository{conn}
+}
+
+func (r *RoleRepository) FindAll() []*entity.Role {
+	var roles []*entity.Role
+	r.conn.Preload("Permissions").
+		Order("level desc, name").
+		Find(&roles)
+	return roles
+}
+
+func (r *RoleRepository) FindOneByName(name string) (*entity.Role, error) {
+	role := &entity.Role{}
+	r.conn.Preload("Permissions").Where("name = ?", name).Find(role)
+	if role.ID == 0 {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
+
+func (r *RoleRepository) FindPermissionsByName(names []string) []*entity.Permission {
+	var permissions []*entity.Permission
+	r.conn.Where("name IN ?", names).Find(&permissions)
+	return permissions
+}
+
+// LoadUserRoles populates user.Roles along with each role's permissions.
+func (r *RoleRepository) LoadUserRoles(user *entity.User) error {
+	return r.conn.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", user.ID).
+		Find(&user.Roles).Error
+}
+
+// ReplaceUserRoles saves the user, replaces their role assignments and revokes
+// their access tokens, which were issued under the old roles.
+func (r *RoleRepository) ReplaceUserRoles(user *entity.User, roles []*entity.Role) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		user.SetRoles(roles)
+		if err := tx.Omit("Roles").Save(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Association("Roles").Replace(roles); err != nil {
+			return err
+		}
+		return CreateAccessTokenRepository(tx).RevokeForUser(user).Error
+	})
+}
+
+func (r *RoleRepository) Create(role *entity.Role) *gorm.DB {
+	return r.conn.Create(role)
+}
+
+// Save saves the role and replaces its permissions.
+func (r *RoleRepository) Save(role *entity.Role) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+		return tx.Model(role).Association("Permissions").Replace(role.Permissions)
+	})
+}
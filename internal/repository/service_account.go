@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+)
+
+type ServiceAccountRepository struct {
+	conn *gorm.DB
+}
+
+func CreateServiceAccountRepository(conn *gorm.DB) *ServiceAccountRepository {
+	return &ServiceAccountRepository{conn}
+}
+
+func (r *ServiceAccountRepository) FindOneByClientId(clientId string) (*entity.ServiceAccount, error) {
+	account := &entity.ServiceAccount{}
+	r.conn.Preload("User").Where("client_id = ?", clientId).Find(account)
+	if account.ID == 0 {
+		return nil, errors.New("service account not found")
+	}
+	return account, nil
+}
+
+func (r *ServiceAccountRepository) FindOneByUuid(accountUuid uuid.UUID) (*entity.ServiceAccount, error) {
+	account := &entity.ServiceAccount{}
+	r.conn.Preload("User").Where("uuid = ?", accountUuid.String()).Find(account)
+	if account.ID == 0 {
+		return nil, errors.New("service account not found")
+	}
+	return account, nil
+}
+
+func (r *ServiceAccountRepository) FindAll(offset int) []*entity.ServiceAccount {
+	var accounts []*entity.ServiceAccount
+	r.conn.Where("disabled_at IS NULL").
+		Order("id desc").
+		Limit(25).
+		Offset(offset).
+		Find(&accounts)
+	return accounts
+}
+
+// Create saves a new service account together with the user it acts as.
+func (r *ServiceAccountRepository) Create(account *entity.ServiceAccount) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(account.User).Error; err != nil {
+			return err
+		}
+		account.UserID = account.User.ID
+		return tx.Omit("User").Create(account).Error
+	})
+}
+
+// Save saves the service account and its user, so that rotating a secret and
+// revoking the account's tokens happen together.
+func (r *ServiceAccountRepository) Save(account *entity.ServiceAccount) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Roles", "Emails", "Passwords").Save(account.User).Error; err != nil {
+			return err
+		}
+		return tx.Omit("User").Save(account).Error
+	})
+}
+
+// Disable saves the disabled account and deletes its user, which makes every
+// token issued to it invalid.
+func (r *ServiceAccountRepository) Disable(account *entity.ServiceAccount) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Save(account).Error; err != nil {
+			return err
+		}
+		return tx.Delete(account.User).Error
+	})
+}
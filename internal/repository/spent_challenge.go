@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type SpentChallengeRepository struct {
+	conn *gorm.DB
+}
+
+func CreateSpentChallengeRepository(conn *gorm.DB) *SpentChallengeRepository {
+	return &SpentChallengeRepository{conn}
+}
+
+// Spend records the challenge and reports whether it wasn't spent yet.
+// Expired challenges are deleted along the way.
+func (r *SpentChallengeRepository) Spend(id string, expiresAt time.Time) (bool, error) {
+	err := r.conn.Where("expires_at < ?", time.Now()).Delete(&entity.SpentChallenge{}).Error
+	if err != nil {
+		return false, err
+	}
+	result := r.conn.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.SpentChallenge{
+		ID:        id,
+		ExpiresAt: expiresAt,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
 	"gorm.io/gorm"
 )
 
@@ -33,9 +34,20 @@ func (r *UserRepository) GetUserFromUuid(uuid uuid.UUID) (*entity.User, error) {
 	return user, nil
 }
 
+func (r *UserRepository) GetUserFromId(id uint) (*entity.User, error) {
+	user := &entity.User{}
+	r.conn.Where("id = ?", id).Find(&user)
+	if user.ID == 0 {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// GetUserFromEmail finds the user with the email address, ignoring case.
+// Addresses are stored as the user typed them.
 func (r *UserRepository) GetUserFromEmail(email string) (*entity.User, error) {
 	user := &entity.User{}
-	r.conn.Where("email = ?", email).Find(&user)
+	r.conn.Where("LOWER(email) = LOWER(?)", email).Order("id").Limit(1).Find(&user)
 	if user.ID == 0 {
 		return nil, errors.New("user not found")
 	}
@@ -55,6 +67,7 @@ func (r *UserRepository) GetUsers(offset int) []*entity.User {
 	var users []*entity.User
 	r.conn.Table("users").
 		Where("users.deleted_at IS NULL").
+		Where("users.is_service_account = false").
 		Order("id desc").
 		Limit(25).
 		Offset(offset).
@@ -62,10 +75,87 @@ func (r *UserRepository) GetUsers(offset int) []*entity.User {
 	return users
 }
 
+// FindInvitees finds the users who signed up with an invite the user made.
+func (r *UserRepository) FindInvitees(user *entity.User, offset int) []*entity.User {
+	var users []*entity.User
+	r.conn.Table("users").
+		Joins("JOIN invites ON invites.id = users.invite_id").
+		Where("invites.creator_id = ?", user.ID).
+		Where("users.deleted_at IS NULL").
+		Order("users.id desc").
+		Limit(25).
+		Offset(offset).
+		Find(&users)
+	return users
+}
+
+// InviteEdge links a user to the user whose invite they signed up with.
+type InviteEdge struct {
+	UserID    uint
+	InviterID uint
+}
+
+// FindInviteTree walks the invite graph down from the user, to any depth,
+// and returns every link found along with the users in it.
+func (r *UserRepository) FindInviteTree(user *entity.User) ([]*InviteEdge, []*entity.User, error) {
+	var edges []*InviteEdge
+	err := r.conn.Raw(`WITH RECURSIVE tree (user_id, inviter_id, path) AS (
+			SELECT users.id, invites.creator_id, ARRAY[invites.creator_id, users.id]
+			FROM users JOIN invites ON invites.id = users.invite_id
+			WHERE invites.creator_id = ? AND users.deleted_at IS NULL
+		UNION ALL
+			SELECT users.id, invites.creator_id, tree.path || users.id
+			FROM users
+			JOIN invites ON invites.id = users.invite_id
+			JOIN tree ON invites.creator_id = tree.user_id
+			WHERE users.deleted_at IS NULL AND NOT users.id = ANY(tree.path)
+		)
+		SELECT user_id, inviter_id FROM tree`, user.ID).
+		Scan(&edges).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uint, len(edges))
+	for i, edge := range edges {
+		ids[i] = edge.UserID
+	}
+	var users []*entity.User
+	if len(ids) > 0 {
+		r.conn.Where("id IN ?", ids).Find(&users)
+	}
+	return edges, users, nil
+}
+
 func (r *UserRepository) Create(user *entity.User) *gorm.DB {
 	return r.conn.Create(user)
 }
 
+// CreateWithInvite creates the user, claims a use of the invite they signed
+// up with and queues their first email in one transaction. Either invite or
+// email may be nil.
+func (r *UserRepository) CreateWithInvite(user *entity.User, invite *entity.Invite, email *entity.OutboxEmail) error {
+	return r.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if invite != nil {
+			if err := CreateInviteRepository(tx).Claim(invite); err != nil {
+				return err
+			}
+			if invite.IsPending() {
+				invite.Track(enum.InviteStatusAccepted)
+				if err := tx.Save(invite).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if email == nil {
+			return nil
+		}
+		return tx.Create(email).Error
+	})
+}
+
 func (r *UserRepository) Delete(user *entity.User) *gorm.DB {
 	return r.conn.Unscoped().Delete(user)
 }
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"gorm.io/gorm"
+	"time"
+)
+
+type WaitlistRepository struct {
+	conn *gorm.DB
+}
+
+func CreateWaitlistRepository(conn *gorm.DB) *WaitlistRepository {
+	return &WaitlistRepository{conn}
+}
+
+// FindEntries lists entries oldest first, so the people who waited longest
+// are at the front. An empty status lists every entry.
+func (r *WaitlistRepository) FindEntries(status enum.WaitlistStatusType, offset int) []*entity.WaitlistEntry {
+	var entries []*entity.WaitlistEntry
+	query := r.conn.Order("created_at asc, id asc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Limit(25).
+		Offset(offset).
+		Find(&entries)
+	return entries
+}
+
+func (r *WaitlistRepository) FindOneByEmail(email string) (*entity.WaitlistEntry, error) {
+	entry := &entity.WaitlistEntry{}
+	r.conn.Where("email = ?", email).Find(entry)
+	if entry.ID == 0 {
+		return nil, errors.New("waitlist entry not found")
+	}
+	return entry, nil
+}
+
+func (r *WaitlistRepository) FindOneByConfirmationCodeHash(hash string) (*entity.WaitlistEntry, error) {
+	entry := &entity.WaitlistEntry{}
+	r.conn.Where("confirmation_code_hash = ?", hash).Find(entry)
+	if entry.ID == 0 {
+		return nil, errors.New("waitlist entry not found")
+	}
+	return entry, nil
+}
+
+// ApproveBatch moves up to count of the oldest confirmed entries to
+// invited and returns them. Rows other approvals have locked are skipped, so
+// concurrent batches never pick the same entry.
+func (r *WaitlistRepository) ApproveBatch(count int, approvedByID uint) ([]*entity.WaitlistEntry, error) {
+	var entries []*entity.WaitlistEntry
+	now := time.Now()
+	err := r.conn.Raw(`UPDATE waitlist_entries
+		SET status = ?, approved_by_id = ?, invited_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM waitlist_entries
+			WHERE status = ? AND deleted_at IS NULL
+			ORDER BY created_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		enum.WaitlistStatusInvited,
+		approvedByID,
+		now,
+		now,
+		enum.WaitlistStatusConfirmed,
+		count,
+	).Scan(&entries).Error
+	return entries, err
+}
+
+// DeleteUnconfirmedBefore deletes unconfirmed entries that were last sent a
+// confirmation link before the given time.
+func (r *WaitlistRepository) DeleteUnconfirmedBefore(t time.Time) *gorm.DB {
+	return r.conn.Unscoped().
+		Where("status = ? AND updated_at < ?", enum.WaitlistStatusUnconfirmed, t).
+		Delete(&entity.WaitlistEntry{})
+}
+
+func (r *WaitlistRepository) Create(entry *entity.WaitlistEntry) *gorm.DB {
+	return r.conn.Create(entry)
+}
+
+func (r *WaitlistRepository) Delete(entry *entity.WaitlistEntry) *gorm.DB {
+	return r.conn.Unscoped().Delete(entry)
+}
+
+func (r *WaitlistRepository) Save(entry *entity.WaitlistEntry) *gorm.DB {
+	return r.conn.Save(entry)
+}
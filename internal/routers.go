@@ -11,7 +11,12 @@ package internal
 
 import (
 	"github.com/third-place/user-service/internal/controller"
+	"github.com/third-place/user-service/internal/middleware"
+	"github.com/third-place/user-service/internal/ratelimit"
+	"log"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,32 +31,70 @@ type Route struct {
 	Pattern string
 	// HandlerFunc is the handler function of this route.
 	HandlerFunc gin.HandlerFunc
+	// RateLimit is how often the route may be called.
+	RateLimit *ratelimit.Policy
 }
 
 // Routes is the list of the generated Route.
 type Routes []Route
 
-// NewRouter returns a new router.
+// csrfExemptRoutes don't act on the session cookie, so a stale cookie can't
+// stop anyone from logging in or out.
+var csrfExemptRoutes = map[string]bool{
+	"CreateNewSesssion": true,
+	"DeleteSessionV1":   true,
+}
+
+// NewRouter returns a new router that keeps rate limits in memory.
 func NewRouter() *gin.Engine {
+	return NewRouterWithRateLimitStore(ratelimit.NewMemoryStore())
+}
+
+// NewRouterWithRateLimitStore returns a new router that keeps rate limits
+// in the store, which replicas can share.
+func NewRouterWithRateLimitStore(store ratelimit.Store) *gin.Engine {
 	router := gin.Default()
+	err := router.SetTrustedProxies(getTrustedProxies())
+	if err != nil {
+		log.Fatal("invalid TRUSTED_PROXIES :: ", err)
+	}
 	for _, route := range routes {
+		handlers := []gin.HandlerFunc{middleware.RateLimitMiddleware(route.Name, route.RateLimit, store)}
+		if !csrfExemptRoutes[route.Name] {
+			handlers = append(handlers, middleware.CsrfMiddleware())
+		}
+		handlers = append(handlers, route.HandlerFunc)
 		switch route.Method {
 		case http.MethodGet:
-			router.GET(route.Pattern, route.HandlerFunc)
+			router.GET(route.Pattern, handlers...)
 		case http.MethodPost:
-			router.POST(route.Pattern, route.HandlerFunc)
+			router.POST(route.Pattern, handlers...)
 		case http.MethodPut:
-			router.PUT(route.Pattern, route.HandlerFunc)
+			router.PUT(route.Pattern, handlers...)
 		case http.MethodPatch:
-			router.PATCH(route.Pattern, route.HandlerFunc)
+			router.PATCH(route.Pattern, handlers...)
 		case http.MethodDelete:
-			router.DELETE(route.Pattern, route.HandlerFunc)
+			router.DELETE(route.Pattern, handlers...)
 		}
 	}
 
 	return router
 }
 
+// getTrustedProxies reads the comma separated addresses or CIDRs in
+// TRUSTED_PROXIES. X-Forwarded-For is only believed from these, so clients
+// can't pick their own address to dodge per IP rate limits. None are trusted
+// by default.
+func getTrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 // Index is the index handler.
 func Index(c *gin.Context) {
 	c.String(http.StatusOK, "Hello World!")
@@ -63,6 +106,31 @@ var routes = Routes{
 		http.MethodGet,
 		"/",
 		Index,
+		readRateLimit,
+	},
+
+	{
+		"AddGroupMemberV1",
+		http.MethodPost,
+		"/group/:slug/member",
+		controller.AddGroupMemberV1,
+		writeRateLimit,
+	},
+
+	{
+		"ApproveWaitlistV1",
+		http.MethodPost,
+		"/waitlist/approve",
+		controller.ApproveWaitlistV1,
+		writeRateLimit,
+	},
+
+	{
+		"BanUserTreeV1",
+		http.MethodPost,
+		"/ban/:username/tree",
+		controller.BanUserTreeV1,
+		writeRateLimit,
 	},
 
 	{
@@ -70,6 +138,39 @@ var routes = Routes{
 		http.MethodPost,
 		"/ban/:username",
 		controller.BanUserV1,
+		writeRateLimit,
+	},
+
+	{
+		"ChangeEmailV1",
+		http.MethodPut,
+		"/user/email",
+		controller.ChangeEmailV1,
+		credentialRateLimit,
+	},
+
+	{
+		"ChangeUserRoleV1",
+		http.MethodPut,
+		"/user/:username/role",
+		controller.ChangeUserRoleV1,
+		writeRateLimit,
+	},
+
+	{
+		"CheckAuthorizationV1",
+		http.MethodPost,
+		"/authz/check",
+		controller.CheckAuthorizationV1,
+		authzRateLimit,
+	},
+
+	{
+		"CheckAuthorizationBatchV1",
+		http.MethodPost,
+		"/authz/check/batch",
+		controller.CheckAuthorizationBatchV1,
+		authzRateLimit,
 	},
 
 	{
@@ -77,6 +178,39 @@ var routes = Routes{
 		http.MethodPut,
 		"/forgot-password",
 		controller.ConfirmForgotPasswordV1,
+		codeRateLimit,
+	},
+
+	{
+		"ConfirmWaitlistV1",
+		http.MethodPost,
+		"/waitlist/confirm",
+		controller.ConfirmWaitlistV1,
+		codeRateLimit,
+	},
+
+	{
+		"CreateAccessTokenV1",
+		http.MethodPost,
+		"/token",
+		controller.CreateAccessTokenV1,
+		credentialRateLimit,
+	},
+
+	{
+		"CreateGroupV1",
+		http.MethodPost,
+		"/group",
+		controller.CreateGroupV1,
+		writeRateLimit,
+	},
+
+	{
+		"CreateInviteBatchV1",
+		http.MethodPost,
+		"/invite/batch",
+		controller.CreateInviteBatchV1,
+		writeRateLimit,
 	},
 
 	{
@@ -84,6 +218,31 @@ var routes = Routes{
 		http.MethodPost,
 		"/invite",
 		controller.CreateInviteV1,
+		writeRateLimit,
+	},
+
+	{
+		"CreateOAuthTokenV1",
+		http.MethodPost,
+		"/oauth/token",
+		controller.CreateOAuthTokenV1,
+		loginRateLimit,
+	},
+
+	{
+		"CreateRoleV1",
+		http.MethodPost,
+		"/role",
+		controller.CreateRoleV1,
+		writeRateLimit,
+	},
+
+	{
+		"CreateServiceAccountV1",
+		http.MethodPost,
+		"/service-account",
+		controller.CreateServiceAccountV1,
+		credentialRateLimit,
 	},
 
 	{
@@ -91,6 +250,7 @@ var routes = Routes{
 		http.MethodPost,
 		"/session",
 		controller.CreateNewSessionV1,
+		loginRateLimit,
 	},
 
 	{
@@ -98,6 +258,111 @@ var routes = Routes{
 		http.MethodPost,
 		"/user",
 		controller.CreateNewUserV1,
+		signUpRateLimit,
+	},
+
+	{
+		"DeleteEmailDomainRuleV1",
+		http.MethodDelete,
+		"/email-domain/:domain",
+		controller.DeleteEmailDomainRuleV1,
+		writeRateLimit,
+	},
+
+	{
+		"DeleteSessionV1",
+		http.MethodDelete,
+		"/session",
+		controller.DeleteSessionV1,
+		writeRateLimit,
+	},
+
+	{
+		"DisableServiceAccountV1",
+		http.MethodDelete,
+		"/service-account/:uuid",
+		controller.DisableServiceAccountV1,
+		writeRateLimit,
+	},
+
+	{
+		"GetAccessTokensV1",
+		http.MethodGet,
+		"/token",
+		controller.GetAccessTokensV1,
+		readRateLimit,
+	},
+
+	{
+		"GetCampaignStatsV1",
+		http.MethodGet,
+		"/invite/campaign/:campaign",
+		controller.GetCampaignStatsV1,
+		readRateLimit,
+	},
+
+	{
+		"GetChallengeV1",
+		http.MethodGet,
+		"/challenge/:endpoint",
+		controller.GetChallengeV1,
+		readRateLimit,
+	},
+
+	{
+		"GetEmailDomainRulesV1",
+		http.MethodGet,
+		"/email-domain",
+		controller.GetEmailDomainRulesV1,
+		readRateLimit,
+	},
+
+	{
+		"GetGroupV1",
+		http.MethodGet,
+		"/group/:slug",
+		controller.GetGroupV1,
+		readRateLimit,
+	},
+
+	{
+		"GetGroupMembersV1",
+		http.MethodGet,
+		"/group/:slug/member",
+		controller.GetGroupMembersV1,
+		readRateLimit,
+	},
+
+	{
+		"GetInviteBatchCsvV1",
+		http.MethodGet,
+		"/invite/batch/:uuid/csv",
+		controller.GetInviteBatchCsvV1,
+		readRateLimit,
+	},
+
+	{
+		"GetInviteQuotaV1",
+		http.MethodGet,
+		"/invite/quota",
+		controller.GetInviteQuotaV1,
+		readRateLimit,
+	},
+
+	{
+		"GetInviteTreeV1",
+		http.MethodGet,
+		"/user/:username/invite-tree",
+		controller.GetInviteTreeV1,
+		readRateLimit,
+	},
+
+	{
+		"GetInviteesV1",
+		http.MethodGet,
+		"/user/:username/invitees",
+		controller.GetInviteesV1,
+		readRateLimit,
 	},
 
 	{
@@ -105,6 +370,55 @@ var routes = Routes{
 		http.MethodGet,
 		"/invite",
 		controller.GetInvitesV1,
+		readRateLimit,
+	},
+
+	{
+		"GetLinkedIdentitiesV1",
+		http.MethodGet,
+		"/identity",
+		controller.GetLinkedIdentitiesV1,
+		readRateLimit,
+	},
+
+	{
+		"GetLoginHistoryV1",
+		http.MethodGet,
+		"/session/history",
+		controller.GetLoginHistoryV1,
+		readRateLimit,
+	},
+
+	{
+		"GetOwnInvitesV1",
+		http.MethodGet,
+		"/invite/mine",
+		controller.GetOwnInvitesV1,
+		readRateLimit,
+	},
+
+	{
+		"GetRegistrationV1",
+		http.MethodGet,
+		"/registration",
+		controller.GetRegistrationV1,
+		readRateLimit,
+	},
+
+	{
+		"GetRolesV1",
+		http.MethodGet,
+		"/role",
+		controller.GetRolesV1,
+		readRateLimit,
+	},
+
+	{
+		"GetServiceAccountsV1",
+		http.MethodGet,
+		"/service-account",
+		controller.GetServiceAccountsV1,
+		readRateLimit,
 	},
 
 	{
@@ -112,6 +426,23 @@ var routes = Routes{
 		http.MethodGet,
 		"/session",
 		controller.GetSessionV1,
+		authzRateLimit,
+	},
+
+	{
+		"GrantInvitesV1",
+		http.MethodPost,
+		"/user/:username/invite-grant",
+		controller.GrantInvitesV1,
+		writeRateLimit,
+	},
+
+	{
+		"GrantUserRoleV1",
+		http.MethodPost,
+		"/user/:username/role/:role",
+		controller.GrantUserRoleV1,
+		writeRateLimit,
 	},
 
 	{
@@ -119,6 +450,23 @@ var routes = Routes{
 		http.MethodGet,
 		"/user/:username",
 		controller.GetUserByUsernameV1,
+		readRateLimit,
+	},
+
+	{
+		"GetUserEmailsV1",
+		http.MethodGet,
+		"/user/:username/outbox",
+		controller.GetUserEmailsV1,
+		readRateLimit,
+	},
+
+	{
+		"GetUserGroupsV1",
+		http.MethodGet,
+		"/user/:username/group",
+		controller.GetUserGroupsV1,
+		readRateLimit,
 	},
 
 	{
@@ -126,6 +474,71 @@ var routes = Routes{
 		http.MethodGet,
 		"/user",
 		controller.GetUsersV1,
+		readRateLimit,
+	},
+
+	{
+		"GetWaitlistV1",
+		http.MethodGet,
+		"/waitlist",
+		controller.GetWaitlistV1,
+		readRateLimit,
+	},
+
+	{
+		"HandleSendGridEventsV1",
+		http.MethodPost,
+		"/email/events/sendgrid",
+		controller.HandleSendGridEventsV1,
+		webhookRateLimit,
+	},
+
+	{
+		"ImpersonateUserV1",
+		http.MethodPost,
+		"/session/impersonate/:username",
+		controller.ImpersonateUserV1,
+		credentialRateLimit,
+	},
+
+	{
+		"InviteGroupMemberV1",
+		http.MethodPost,
+		"/group/:slug/invite",
+		controller.InviteGroupMemberV1,
+		writeRateLimit,
+	},
+
+	{
+		"JoinGroupV1",
+		http.MethodPost,
+		"/group/:slug/join",
+		controller.JoinGroupV1,
+		writeRateLimit,
+	},
+
+	{
+		"JoinWaitlistV1",
+		http.MethodPost,
+		"/waitlist",
+		controller.JoinWaitlistV1,
+		signUpRateLimit,
+	},
+
+	{
+		"LeaveGroupV1",
+		http.MethodDelete,
+		"/group/:slug/member",
+		controller.LeaveGroupV1,
+		writeRateLimit,
+	},
+
+	{
+		"ReauthenticateSessionV1",
+		http.MethodPost,
+		"/session/reauth",
+		controller.ReauthenticateSessionV1,
+		reauthRateLimit,
 	},
 
 	{
@@ -133,6 +546,103 @@ var routes = Routes{
 		http.MethodPut,
 		"/session",
 		controller.RefreshSessionV1,
+		writeRateLimit,
+	},
+
+	{
+		"RemoveGroupMemberV1",
+		http.MethodDelete,
+		"/group/:slug/member/:username",
+		controller.RemoveGroupMemberV1,
+		writeRateLimit,
+	},
+
+	{
+		"ResendEmailInviteV1",
+		http.MethodPost,
+		"/invite/:code/resend",
+		controller.ResendEmailInviteV1,
+		credentialRateLimit,
+	},
+
+	{
+		"ResendOutboxEmailV1",
+		http.MethodPost,
+		"/outbox/:uuid/resend",
+		controller.ResendOutboxEmailV1,
+		writeRateLimit,
+	},
+
+	{
+		"RevokeAccessTokenV1",
+		http.MethodDelete,
+		"/token/:uuid",
+		controller.RevokeAccessTokenV1,
+		writeRateLimit,
+	},
+
+	{
+		"RevokeInviteV1",
+		http.MethodDelete,
+		"/invite/:code",
+		controller.RevokeInviteV1,
+		writeRateLimit,
+	},
+
+	{
+		"RevokeSessionsV1",
+		http.MethodPost,
+		"/session/revoke",
+		controller.RevokeSessionsV1,
+		codeRateLimit,
+	},
+
+	{
+		"RevokeUserRoleV1",
+		http.MethodDelete,
+		"/user/:username/role/:role",
+		controller.RevokeUserRoleV1,
+		writeRateLimit,
+	},
+
+	{
+		"RotateServiceAccountSecretV1",
+		http.MethodPost,
+		"/service-account/:uuid/secret",
+		controller.RotateServiceAccountSecretV1,
+		credentialRateLimit,
+	},
+
+	{
+		"SendEmailInviteV1",
+		http.MethodPost,
+		"/invite/email",
+		controller.SendEmailInviteV1,
+		credentialRateLimit,
+	},
+
+	{
+		"SetEmailDomainRuleV1",
+		http.MethodPut,
+		"/email-domain/:domain",
+		controller.SetEmailDomainRuleV1,
+		writeRateLimit,
+	},
+
+	{
+		"SocialAuthorizeV1",
+		http.MethodGet,
+		"/social/:provider/authorize",
+		controller.SocialAuthorizeV1,
+		readRateLimit,
+	},
+
+	{
+		"SocialCallbackV1",
+		http.MethodPost,
+		"/social/:provider/callback",
+		controller.SocialCallbackV1,
+		loginRateLimit,
 	},
 
 	{
@@ -140,6 +650,7 @@ var routes = Routes{
 		http.MethodPost,
 		"/forgot-password",
 		controller.SubmitForgotPasswordV1,
+		codeRateLimit,
 	},
 
 	{
@@ -147,6 +658,23 @@ var routes = Routes{
 		http.MethodPost,
 		"/otp",
 		controller.SubmitOTPV1,
+		codeRateLimit,
+	},
+
+	{
+		"TrackInviteClickV1",
+		http.MethodGet,
+		"/invite/track/:token/click",
+		controller.TrackInviteClickV1,
+		readRateLimit,
+	},
+
+	{
+		"TrackInviteOpenV1",
+		http.MethodGet,
+		"/invite/track/:token/open",
+		controller.TrackInviteOpenV1,
+		readRateLimit,
 	},
 
 	{
@@ -154,6 +682,39 @@ var routes = Routes{
 		http.MethodDelete,
 		"/ban/:username",
 		controller.UnbanUserV1,
+		writeRateLimit,
+	},
+
+	{
+		"UnlinkIdentityV1",
+		http.MethodDelete,
+		"/identity/:uuid",
+		controller.UnlinkIdentityV1,
+		writeRateLimit,
+	},
+
+	{
+		"UpdateGroupV1",
+		http.MethodPut,
+		"/group/:slug",
+		controller.UpdateGroupV1,
+		writeRateLimit,
+	},
+
+	{
+		"UpdateRegistrationV1",
+		http.MethodPut,
+		"/registration",
+		controller.UpdateRegistrationV1,
+		writeRateLimit,
+	},
+
+	{
+		"UpdateRoleV1",
+		http.MethodPut,
+		"/role/:name",
+		controller.UpdateRoleV1,
+		writeRateLimit,
 	},
 
 	{
@@ -161,5 +722,6 @@ var routes = Routes{
 		http.MethodPut,
 		"/user",
 		controller.UpdateUserV1,
+		writeRateLimit,
 	},
 }
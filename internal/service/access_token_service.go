@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"time"
+)
+
+const maxAccessTokensPerUser = 25
+
+type AccessTokenService struct {
+	accessTokenRepository *repository.AccessTokenRepository
+	userRepository        *repository.UserRepository
+}
+
+func CreateAccessTokenService() *AccessTokenService {
+	conn := db.CreateDefaultConnection()
+	return &AccessTokenService{
+		repository.CreateAccessTokenRepository(conn),
+		repository.CreateUserRepository(conn),
+	}
+}
+
+func CreateTestAccessTokenService() *AccessTokenService {
+	conn := util.SetupTestDatabase()
+	return &AccessTokenService{
+		repository.CreateAccessTokenRepository(conn),
+		repository.CreateUserRepository(conn),
+	}
+}
+
+// CreateAccessToken creates a personal access token for the session user.
+// The token itself is only returned here, afterwards only its prefix is
+// known.
+func (s *AccessTokenService) CreateAccessToken(session *model.Session, newToken *model.NewAccessToken) (*model.AccessToken, error) {
+	user, err := s.getUser(session)
+	if err != nil {
+		return nil, err
+	}
+	if newToken.Name == "" {
+		return nil, util.NewInputFieldError(
+			"name",
+			"token name is required",
+		)
+	}
+	if newToken.Scopes != nil && len(newToken.Scopes) == 0 {
+		return nil, util.NewInputFieldError(
+			"scopes",
+			"scopes must not be empty, leave them out for a token with all of your permissions",
+		)
+	}
+	for _, scope := range newToken.Scopes {
+		if !scope.IsValid() {
+			return nil, util.NewInputFieldError(
+				"scopes",
+				"scope not recognized: "+string(scope),
+			)
+		}
+	}
+	if newToken.ExpiresAt != nil && !newToken.ExpiresAt.After(time.Now()) {
+		return nil, util.NewInputFieldError(
+			"expires_at",
+			"expiry must be in the future",
+		)
+	}
+	if len(s.accessTokenRepository.FindActiveForUser(user)) >= maxAccessTokensPerUser {
+		return nil, util.NewInputFieldError(
+			"name",
+			"too many access tokens, revoke one first",
+		)
+	}
+	token, prefix, err := util.GenerateAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	accessToken := &entity.AccessToken{
+		UserID:    user.ID,
+		Name:      newToken.Name,
+		Prefix:    prefix,
+		Hash:      util.HashAccessToken(token),
+		ExpiresAt: newToken.ExpiresAt,
+	}
+	accessToken.SetScopes(newToken.Scopes)
+	result := s.accessTokenRepository.Create(accessToken)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	tokenModel := mapper.MapAccessTokenEntityToModel(accessToken)
+	tokenModel.Token = token
+	return tokenModel, nil
+}
+
+func (s *AccessTokenService) GetAccessTokens(session *model.Session) ([]*model.AccessToken, error) {
+	user, err := s.getUser(session)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapAccessTokenEntitiesToModels(s.accessTokenRepository.FindActiveForUser(user)), nil
+}
+
+func (s *AccessTokenService) RevokeAccessToken(session *model.Session, tokenUuid uuid.UUID) error {
+	user, err := s.getUser(session)
+	if err != nil {
+		return err
+	}
+	accessToken, err := s.accessTokenRepository.FindOneByUuid(user, tokenUuid)
+	if err != nil {
+		return err
+	}
+	if accessToken.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	accessToken.RevokedAt = &now
+	return s.accessTokenRepository.Save(accessToken).Error
+}
+
+// getUser returns the session user. Access tokens can't be used to manage
+// access tokens, so a leaked token can't be used to mint more, and neither
+// can an admin impersonating the user.
+func (s *AccessTokenService) getUser(session *model.Session) (*entity.User, error) {
+	if session == nil || session.User == nil || session.IsImpersonated() || util.IsAccessToken(session.Token) {
+		return nil, errors.New("not allowed")
+	}
+	user, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBanned {
+		return nil, errors.New("not allowed")
+	}
+	return user, nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"testing"
+	"time"
+)
+
+func Test_AccessToken_Can_Be_Used_As_Session(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+
+	// given
+	user, session := svc.CreateUserWithRole(model.USER)
+
+	// when
+	token, err := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name: "my bot",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	tokenSession, err := svc.GetSession(&model.SessionToken{Token: token.Token})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if tokenSession.User.Uuid != user.Uuid.String() {
+		t.Fail()
+	}
+	tokens, _ := accessTokenService.GetAccessTokens(session)
+	if len(tokens) != 1 || tokens[0].Token != "" || tokens[0].Prefix == "" {
+		t.Error("expected the token list to show only the prefix")
+	}
+}
+
+func Test_AccessToken_Scopes_Restrict_Permissions(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+	securityService := CreateTestSecurityService()
+
+	// given
+	_, session := svc.CreateUserWithRole(model.MODERATOR)
+	token, _ := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name:   "read only",
+		Scopes: []model.Scope{model.ScopeUsersRead},
+	})
+	tokenSession, _ := svc.GetSession(&model.SessionToken{Token: token.Token})
+
+	// then
+	if !securityService.Can(tokenSession, model.PermissionUserList, nil) {
+		t.Error("expected the scope to allow listing users")
+	}
+	if securityService.Can(tokenSession, model.PermissionUserBan, nil) {
+		t.Error("expected the scope to prevent banning users")
+	}
+}
+
+func Test_Revoked_And_Expired_AccessTokens_Are_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+
+	// given
+	_, session := svc.CreateUserWithRole(model.USER)
+	revoked, _ := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name: "revoked",
+	})
+	expiresAt := time.Now().Add(time.Second)
+	expired, _ := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name:      "expired",
+		ExpiresAt: &expiresAt,
+	})
+
+	// when
+	_ = accessTokenService.RevokeAccessToken(session, uuid.MustParse(revoked.Uuid))
+	time.Sleep(2 * time.Second)
+
+	// then
+	if _, err := svc.GetSession(&model.SessionToken{Token: revoked.Token}); err == nil {
+		t.Error("expected a revoked token to be rejected")
+	}
+	if _, err := svc.GetSession(&model.SessionToken{Token: expired.Token}); err == nil {
+		t.Error("expected an expired token to be rejected")
+	}
+}
+
+func Test_AccessToken_With_Empty_Scopes_Is_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+
+	// given
+	_, session := svc.CreateUserWithRole(model.USER)
+
+	// when
+	_, err := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name:   "nothing",
+		Scopes: []model.Scope{},
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected an empty list of scopes to be rejected")
+	}
+}
+
+func Test_Scoped_AccessToken_Cannot_Update_Profile(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+
+	// given
+	_, session := svc.CreateUserWithRole(model.USER)
+	token, _ := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name:   "read only",
+		Scopes: []model.Scope{model.ScopeUsersRead},
+	})
+	tokenSession, _ := svc.GetSession(&model.SessionToken{Token: token.Token})
+
+	// when
+	err := svc.UpdateUser(tokenSession, &model.User{
+		Uuid:       tokenSession.User.Uuid,
+		BioMessage: "changed by a read only token",
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected a scoped token to be unable to update the profile")
+	}
+}
+
+func Test_Banning_User_Revokes_AccessTokens(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+
+	// given
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	user, session := svc.CreateUserWithRole(model.USER)
+	token, _ := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name: "my bot",
+	})
+
+	// when
+	err := svc.BanUser(moderatorSession, user)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetSession(&model.SessionToken{Token: token.Token}); err == nil {
+		t.Error("expected the access token of a banned user to be rejected")
+	}
+	user.IsBanned = false
+	svc.userRepository.Save(user)
+	if _, err := svc.GetSession(&model.SessionToken{Token: token.Token}); err == nil {
+		t.Error("expected the access token to stay revoked after unbanning")
+	}
+}
+
+func Test_Changing_Role_Revokes_AccessTokens(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	moderator, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	token, _ := accessTokenService.CreateAccessToken(moderatorSession, &model.NewAccessToken{
+		Name: "moderation bot",
+	})
+
+	// when
+	err := svc.ChangeRole(adminSession, moderator, model.USER)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetSession(&model.SessionToken{Token: token.Token}); err == nil {
+		t.Error("expected an access token issued before a demotion to be rejected")
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"strings"
+)
+
+const maxAuthzBatchSize = 100
+
+// AuthzService answers authorization questions for other platform services
+// so that they don't need to re-implement the rules in SecurityService.
+type AuthzService struct {
+	userService     *UserService
+	userRepository  *repository.UserRepository
+	securityService *SecurityService
+}
+
+func CreateAuthzService() *AuthzService {
+	conn := db.CreateDefaultConnection()
+	return &AuthzService{
+		CreateUserService(),
+		repository.CreateUserRepository(conn),
+		CreateSecurityService(),
+	}
+}
+
+func CreateTestAuthzService() *AuthzService {
+	conn := util.SetupTestDatabase()
+	return &AuthzService{
+		CreateTestUserService(),
+		repository.CreateUserRepository(conn),
+		CreateTestSecurityService(),
+	}
+}
+
+// Check decides a single action for the subject token in the check. The
+// caller needs the authz.check permission, so end users can't use it to probe
+// other people's tokens.
+func (s *AuthzService) Check(caller *model.Session, check *model.AuthzCheck) (*model.AuthzDecision, error) {
+	if !s.securityService.Can(caller, model.PermissionAuthzCheck, nil) {
+		return nil, errors.New("not allowed")
+	}
+	if check == nil {
+		return nil, errMissingAuthzCheck()
+	}
+	session, err := s.userService.GetSession(&model.SessionToken{Token: check.Token})
+	if err != nil {
+		return s.decision(check, model.CreateAuthzDecision(false, "invalid subject token")), nil
+	}
+	return s.decide(session, check), nil
+}
+
+func (s *AuthzService) CheckBatch(caller *model.Session, batch *model.AuthzBatchCheck) ([]*model.AuthzDecision, error) {
+	if !s.securityService.Can(caller, model.PermissionAuthzCheck, nil) {
+		return nil, errors.New("not allowed")
+	}
+	if batch == nil {
+		return nil, errMissingAuthzCheck()
+	}
+	if len(batch.Checks) > maxAuthzBatchSize {
+		return nil, util.NewInputFieldError(
+			"checks",
+			"too many checks in one batch",
+		)
+	}
+	for _, check := range batch.Checks {
+		if check == nil {
+			return nil, errMissingAuthzCheck()
+		}
+	}
+	decisions := make([]*model.AuthzDecision, len(batch.Checks))
+	session, err := s.userService.GetSession(&model.SessionToken{Token: batch.Token})
+	for i, check := range batch.Checks {
+		if err != nil {
+			decisions[i] = s.decision(check, model.CreateAuthzDecision(false, "invalid subject token"))
+			continue
+		}
+		decisions[i] = s.decide(session, check)
+	}
+	return decisions, nil
+}
+
+func (s *AuthzService) decide(session *model.Session, check *model.AuthzCheck) *model.AuthzDecision {
+	if !check.Action.IsValid() {
+		return s.decision(check, model.CreateAuthzDecision(false, "unknown action"))
+	}
+	if !s.isVerified(session) {
+		return s.decision(check, model.CreateAuthzDecision(false, "subject email is not verified"))
+	}
+	target, err := s.findResource(check.Resource)
+	if err != nil {
+		return s.decision(check, model.CreateAuthzDecision(false, err.Error()))
+	}
+	return s.decision(check, s.securityService.Decide(session, check.Action, target))
+}
+
+// isVerified reports whether the subject verified their email address. Other
+// services expect unverified users to be denied, though this service only
+// checks it where a verified address matters.
+func (s *AuthzService) isVerified(session *model.Session) bool {
+	user, err := getSessionUser(s.userRepository, session)
+	return err == nil && user.Verified
+}
+
+// findResource resolves a resource reference. An empty reference means the
+// action isn't aimed at anything in particular.
+func (s *AuthzService) findResource(resource string) (*entity.User, error) {
+	if resource == "" {
+		return nil, nil
+	}
+	resourceType, id, found := strings.Cut(resource, ":")
+	if !found || resourceType != "user" {
+		return nil, errors.New("unknown resource type")
+	}
+	userUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("resource not found")
+	}
+	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	if err != nil {
+		return nil, errors.New("resource not found")
+	}
+	return user, nil
+}
+
+func (s *AuthzService) decision(check *model.AuthzCheck, decision *model.AuthzDecision) *model.AuthzDecision {
+	decision.Action = check.Action
+	decision.Resource = check.Resource
+	return decision
+}
+
+func errMissingAuthzCheck() error {
+	return util.NewInputFieldError(
+		"checks",
+		"check is required",
+	)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"testing"
+)
+
+func Test_Authz_Allows_Moderator_To_Ban_User(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	authzService := CreateTestAuthzService()
+
+	// given
+	caller, _ := svc.CreateServiceAccountSession(model.ScopeAuthzCheck)
+	moderator, _ := svc.CreateVerifiedUserWithRole(model.MODERATOR)
+	user, _ := svc.CreateUserWithRole(model.USER)
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    moderator.Email,
+		Password: dummyPassword,
+	})
+
+	// when
+	decision, err := authzService.Check(caller, &model.AuthzCheck{
+		Token:    session.Token,
+		Action:   model.PermissionUserBan,
+		Resource: "user:" + user.Uuid.String(),
+	})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decision.Allowed {
+		t.Error(decision.Reason)
+	}
+}
+
+func Test_Authz_Batch_Explains_Denials(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	authzService := CreateTestAuthzService()
+
+	// given
+	caller, _ := svc.CreateServiceAccountSession(model.ScopeAuthzCheck)
+	user, _ := svc.CreateUserWithRole(model.USER)
+	admin, _ := svc.CreateUserWithRole(model.ADMIN)
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    user.Email,
+		Password: dummyPassword,
+	})
+
+	// when
+	decisions, err := authzService.CheckBatch(caller, &model.AuthzBatchCheck{
+		Token: session.Token,
+		Checks: []*model.AuthzCheck{
+			{Action: model.PermissionUserBan, Resource: "user:" + admin.Uuid.String()},
+			{Action: "not.a.permission"},
+			{Action: model.PermissionInviteList, Resource: "group:foo"},
+		},
+	})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	for _, decision := range decisions {
+		if decision.Allowed || decision.Reason == "" {
+			t.Error("expected every check to be denied with a reason")
+		}
+	}
+}
+
+func Test_Authz_Denies_Unverified_Subject(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	authzService := CreateTestAuthzService()
+
+	// given
+	caller, _ := svc.CreateServiceAccountSession(model.ScopeAuthzCheck)
+	moderator, _ := svc.CreateUserWithRole(model.MODERATOR)
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    moderator.Email,
+		Password: dummyPassword,
+	})
+
+	// when
+	decision, err := authzService.Check(caller, &model.AuthzCheck{
+		Token:  session.Token,
+		Action: model.PermissionUserList,
+	})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Allowed {
+		t.Error("expected an unverified subject to be denied")
+	}
+}
+
+func Test_Authz_Rejects_Invalid_Token(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	authzService := CreateTestAuthzService()
+
+	// given
+	caller, _ := svc.CreateServiceAccountSession(model.ScopeAuthzCheck)
+
+	// when
+	decision, err := authzService.Check(caller, &model.AuthzCheck{
+		Token:  "not-a-token",
+		Action: model.PermissionUserList,
+	})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Allowed {
+		t.Fail()
+	}
+}
+
+func Test_Authz_Requires_The_Check_Permission(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	authzService := CreateTestAuthzService()
+
+	// given
+	moderator, _ := svc.CreateVerifiedUserWithRole(model.MODERATOR)
+	_, userSession := svc.CreateVerifiedUserWithRole(model.USER)
+	unscoped, _ := svc.CreateServiceAccountSession(model.ScopeUsersRead)
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    moderator.Email,
+		Password: dummyPassword,
+	})
+	check := &model.AuthzCheck{
+		Token:  session.Token,
+		Action: model.PermissionUserList,
+	}
+
+	// when
+	_, userErr := authzService.Check(userSession, check)
+	_, unscopedErr := authzService.Check(unscoped, check)
+	_, batchErr := authzService.CheckBatch(userSession, &model.AuthzBatchCheck{
+		Token:  session.Token,
+		Checks: []*model.AuthzCheck{check},
+	})
+
+	// then
+	if userErr == nil || batchErr == nil {
+		t.Error("expected an end user to be unable to ask for decisions")
+	}
+	if unscopedErr == nil {
+		t.Error("expected a service account without the authz:check scope to be unable to ask for decisions")
+	}
+}
+
+func Test_Authz_Rejects_Missing_Checks(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	authzService := CreateTestAuthzService()
+
+	// given
+	caller, _ := svc.CreateServiceAccountSession(model.ScopeAuthzCheck)
+
+	// when
+	_, checkErr := authzService.Check(caller, nil)
+	_, batchErr := authzService.CheckBatch(caller, nil)
+	_, entryErr := authzService.CheckBatch(caller, &model.AuthzBatchCheck{
+		Token:  "not-a-token",
+		Checks: []*model.AuthzCheck{nil},
+	})
+
+	// then
+	for _, err := range []error{checkErr, batchErr, entryErr} {
+		if _, ok := err.(*util.InputFieldError); !ok {
+			t.Error("expected a missing check to be an input error")
+		}
+	}
+}
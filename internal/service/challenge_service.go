@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/third-place/user-service/internal/challenge"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"sync"
+	"time"
+)
+
+const challengeVerifyTimeout = 10 * time.Second
+
+var (
+	challenges     *challenge.Registry
+	challengesOnce sync.Once
+)
+
+type ChallengeService struct {
+	challenges *challenge.Registry
+}
+
+// CreateChallengeService shares one registry across requests. Spent proofs
+// of work are kept in the database, so every replica rejects them.
+func CreateChallengeService() *ChallengeService {
+	challengesOnce.Do(func() {
+		challenges = challenge.CreateRegistryFromEnv(
+			util.JwtKey,
+			repository.CreateSpentChallengeRepository(db.CreateDefaultConnection()),
+		)
+	})
+	return &ChallengeService{challenges}
+}
+
+func CreateTestChallengeService() *ChallengeService {
+	return &ChallengeService{challenge.CreateRegistry(challenge.NewNoopVerifier())}
+}
+
+// GetChallenge returns what the client has to solve before calling the
+// endpoint.
+func (s *ChallengeService) GetChallenge(endpoint string) (*model.Challenge, error) {
+	switch endpoint {
+	case challenge.EndpointSignUp, challenge.EndpointForgotPassword, challenge.EndpointWaitlist:
+	default:
+		return nil, errors.New("challenge endpoint not found")
+	}
+	c, err := s.challenges.Get(endpoint).Challenge(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapChallengeToModel(endpoint, c), nil
+}
+
+// Verify checks the client's response to the endpoint's challenge.
+func (s *ChallengeService) Verify(endpoint string, response string, client model.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), challengeVerifyTimeout)
+	defer cancel()
+	err := s.challenges.Get(endpoint).Verify(ctx, endpoint, response, client.IpAddress)
+	if err != nil {
+		if err != challenge.ErrChallengeFailed {
+			log.Print("error verifying challenge :: ", err)
+		}
+		return util.NewInputFieldError("challenge", "please complete the challenge and try again")
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"github.com/third-place/user-service/internal/challenge"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func createTestProofOfWorkService() *ChallengeService {
+	spent := repository.CreateSpentChallengeRepository(util.SetupTestDatabase())
+	registry := challenge.CreateRegistry(challenge.NewNoopVerifier())
+	registry.Register(challenge.EndpointSignUp, challenge.NewProofOfWorkVerifier([]byte("test"), 8, time.Minute, spent))
+	return &ChallengeService{registry}
+}
+
+func solveProofOfWork(c *model.Challenge) string {
+	for nonce := 0; ; nonce++ {
+		response := c.Token + ":" + strconv.Itoa(nonce)
+		if challenge.LeadingZeroBits([]byte(response)) >= c.Difficulty {
+			return response
+		}
+	}
+}
+
+func Test_Challenge_Proof_Of_Work_Is_Spent_On_Every_Replica(t *testing.T) {
+	// setup
+	challengeService := createTestProofOfWorkService()
+	otherReplica := createTestProofOfWorkService()
+
+	// given
+	c, err := challengeService.GetChallenge(challenge.EndpointSignUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := solveProofOfWork(c)
+	err = challengeService.Verify(challenge.EndpointSignUp, response, model.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	err = otherReplica.Verify(challenge.EndpointSignUp, response, model.ClientInfo{})
+
+	// then
+	if err == nil {
+		t.Error("expected a proof of work spent on another replica to be rejected")
+	}
+}
+
+func Test_Challenge_Noop_Accepts_Anything(t *testing.T) {
+	// setup
+	challengeService := CreateTestChallengeService()
+
+	// when
+	err := challengeService.Verify(challenge.EndpointSignUp, "", model.ClientInfo{})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Challenge_Proof_Of_Work_Can_Be_Solved_Once(t *testing.T) {
+	// setup
+	challengeService := createTestProofOfWorkService()
+
+	// given
+	c, err := challengeService.GetChallenge(challenge.EndpointSignUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := solveProofOfWork(c)
+
+	// when
+	err = challengeService.Verify(challenge.EndpointSignUp, response, model.ClientInfo{})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if challengeService.Verify(challenge.EndpointSignUp, response, model.ClientInfo{}) == nil {
+		t.Error("expected a spent proof of work to be rejected")
+	}
+}
+
+func Test_Challenge_Proof_Of_Work_Rejects_Bad_Responses(t *testing.T) {
+	// setup
+	challengeService := createTestProofOfWorkService()
+
+	// given
+	c, _ := challengeService.GetChallenge(challenge.EndpointSignUp)
+	response := solveProofOfWork(c)
+
+	// expect
+	if challengeService.Verify(challenge.EndpointSignUp, "", model.ClientInfo{}) == nil {
+		t.Error("expected a missing response to be rejected")
+	}
+	if challengeService.Verify(challenge.EndpointSignUp, c.Token+":nonce", model.ClientInfo{}) == nil &&
+		challenge.LeadingZeroBits([]byte(c.Token+":nonce")) < c.Difficulty {
+		t.Error("expected an unsolved challenge to be rejected")
+	}
+	if challengeService.Verify(challenge.EndpointForgotPassword, response, model.ClientInfo{}) != nil {
+		t.Error("expected an unprotected endpoint to accept anything")
+	}
+	if createTestProofOfWorkService().Verify(challenge.EndpointSignUp, "x"+response, model.ClientInfo{}) == nil {
+		t.Error("expected a tampered token to be rejected")
+	}
+}
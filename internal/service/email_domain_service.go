@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/emaildomain"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"strings"
+	"sync"
+)
+
+var (
+	disposableDomains     *emaildomain.DisposableList
+	disposableDomainsOnce sync.Once
+)
+
+type EmailDomainService struct {
+	emailDomainRuleRepository *repository.EmailDomainRuleRepository
+	userRepository            *repository.UserRepository
+	securityService           *SecurityService
+	disposableDomains         *emaildomain.DisposableList
+}
+
+// CreateEmailDomainService shares one disposable list across requests, it
+// is only read once.
+func CreateEmailDomainService() *EmailDomainService {
+	disposableDomainsOnce.Do(func() {
+		disposableDomains = emaildomain.LoadDisposableList()
+		log.Printf("loaded %d disposable email domains", disposableDomains.Len())
+	})
+	conn := db.CreateDefaultConnection()
+	return &EmailDomainService{
+		repository.CreateEmailDomainRuleRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateSecurityService(),
+		disposableDomains,
+	}
+}
+
+func CreateTestEmailDomainService() *EmailDomainService {
+	conn := util.SetupTestDatabase()
+	return &EmailDomainService{
+		repository.CreateEmailDomainRuleRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateTestSecurityService(),
+		emaildomain.ParseDisposableList(strings.NewReader("mailinator.com\n")),
+	}
+}
+
+// CheckEmail returns an InputFieldError on the email field when addresses
+// on the email's domain can't be used. The most specific admin rule wins,
+// so allowing a subdomain of a blocked domain lets it through. Without a
+// rule, disposable domains are rejected.
+func (s *EmailDomainService) CheckEmail(email string) error {
+	domain := emaildomain.FromEmail(email)
+	if !emaildomain.IsValid(domain) {
+		return util.NewInputFieldError(
+			"email",
+			"a valid email address is required",
+		)
+	}
+	rules := map[string]*entity.EmailDomainRule{}
+	candidates := emaildomain.Candidates(domain)
+	for _, rule := range s.emailDomainRuleRepository.FindForDomains(candidates) {
+		rules[rule.Domain] = rule
+	}
+	for _, candidate := range candidates {
+		rule, ok := rules[candidate]
+		if !ok {
+			continue
+		}
+		if rule.Rule == enum.EmailDomainRuleAllow {
+			return nil
+		}
+		return util.NewInputFieldError(
+			"email",
+			"email addresses from "+domain+" can't be used",
+		)
+	}
+	if s.disposableDomains.Contains(domain) {
+		return util.NewInputFieldError(
+			"email",
+			"disposable email addresses can't be used",
+		)
+	}
+	return nil
+}
+
+// GetRules lists the blocked and allowed domains.
+func (s *EmailDomainService) GetRules(session *model.Session, offset int) ([]*model.EmailDomainRule, error) {
+	if !s.securityService.Can(session, model.PermissionEmailDomainManage, nil) {
+		return nil, errors.New("not allowed")
+	}
+	return mapper.MapEmailDomainRuleEntitiesToModels(s.emailDomainRuleRepository.FindRules(offset)), nil
+}
+
+// SetRule blocks or allows the domain, replacing any rule it had.
+func (s *EmailDomainService) SetRule(session *model.Session, domain string, newRule *model.NewEmailDomainRule) (*model.EmailDomainRule, error) {
+	if !s.securityService.Can(session, model.PermissionEmailDomainManage, nil) {
+		return nil, errors.New("not allowed")
+	}
+	domain = emaildomain.Normalize(domain)
+	if !emaildomain.IsValid(domain) {
+		return nil, util.NewInputFieldError(
+			"domain",
+			"a valid domain is required",
+		)
+	}
+	ruleType := enum.EmailDomainRuleType(newRule.Rule)
+	if !ruleType.IsValid() {
+		return nil, util.NewInputFieldError(
+			"rule",
+			"rule must be block or allow",
+		)
+	}
+	userUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := s.userRepository.GetUserFromUuid(userUuid)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := s.emailDomainRuleRepository.FindOneByDomain(domain)
+	if err != nil {
+		rule = &entity.EmailDomainRule{Domain: domain}
+	}
+	rule.Rule = ruleType
+	rule.Reason = strings.TrimSpace(newRule.Reason)
+	rule.CreatedByID = admin.ID
+	result := s.emailDomainRuleRepository.Save(rule)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mapper.MapEmailDomainRuleEntityToModel(rule), nil
+}
+
+// DeleteRule removes the rule for the domain, leaving it to the disposable
+// list again.
+func (s *EmailDomainService) DeleteRule(session *model.Session, domain string) error {
+	if !s.securityService.Can(session, model.PermissionEmailDomainManage, nil) {
+		return errors.New("not allowed")
+	}
+	rule, err := s.emailDomainRuleRepository.FindOneByDomain(emaildomain.Normalize(domain))
+	if err != nil {
+		return err
+	}
+	return s.emailDomainRuleRepository.Delete(rule).Error
+}
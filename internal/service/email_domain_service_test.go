@@ -0,0 +1,140 @@
+package service
+
+import (
+	"github.com/third-place/user-service/internal/emaildomain"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func isEmailFieldError(err error) bool {
+	fieldErr, ok := err.(*util.InputFieldError)
+	return ok && fieldErr.Input == "email"
+}
+
+func Test_Domain_Candidates_Walk_Up_To_Parent_Domains(t *testing.T) {
+	candidates := emaildomain.Candidates("Mail.Example.co.uk.")
+
+	expected := []string{"mail.example.co.uk", "example.co.uk", "co.uk", "uk"}
+	if !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("expected %v, got %v", expected, candidates)
+	}
+}
+
+func Test_Bundled_Disposable_List_Loads(t *testing.T) {
+	list := emaildomain.LoadDisposableList()
+
+	if !list.Contains("mailinator.com") || !list.Contains("eu.mailinator.com") {
+		t.Error("expected the bundled list to cover known domains and their subdomains")
+	}
+	if list.Contains("thirdplaceapp.com") {
+		t.Error("expected other domains not to be disposable")
+	}
+}
+
+func Test_Disposable_Addresses_Are_Rejected(t *testing.T) {
+	// setup
+	emailDomainService := CreateTestEmailDomainService()
+
+	// when
+	disposableErr := emailDomainService.CheckEmail("someone@mailinator.com")
+	subdomainErr := emailDomainService.CheckEmail("someone@eu.Mailinator.com")
+	err := emailDomainService.CheckEmail(util.RandomEmailAddress())
+
+	// then
+	if !isEmailFieldError(disposableErr) || !isEmailFieldError(subdomainErr) {
+		t.Error("expected disposable addresses to be rejected on the email field")
+	}
+	if err != nil {
+		t.Error("expected other addresses to be accepted")
+	}
+}
+
+func Test_Blocked_Domains_Are_Checked_Everywhere(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	emailDomainService := CreateTestEmailDomainService()
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	_, session := svc.CreateUserWithRole(model.USER)
+	domain := "blocked-" + strconv.FormatInt(time.Now().UnixNano(), 10) + ".test"
+	_, err := emailDomainService.SetRule(adminSession, domain, &model.NewEmailDomainRule{
+		Rule:   "block",
+		Reason: "spam wave",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer emailDomainService.DeleteRule(adminSession, domain)
+
+	// when
+	_, signUpErr := svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    "someone@" + domain,
+		Password: dummyPassword,
+	})
+	changeErr := svc.userService.ChangeEmail(session, &model.EmailChange{Email: "someone@mail." + domain})
+	_, waitlistErr := waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: "someone@" + domain})
+
+	// then
+	if !isEmailFieldError(signUpErr) {
+		t.Error("expected sign-ups from the domain to be rejected")
+	}
+	if !isEmailFieldError(changeErr) {
+		t.Error("expected email changes to subdomains to be rejected")
+	}
+	if !isEmailFieldError(waitlistErr) {
+		t.Error("expected the waitlist to reject the domain")
+	}
+}
+
+func Test_Allowed_Domain_Overrides_Disposable_List(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	emailDomainService := CreateTestEmailDomainService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	_, err := emailDomainService.SetRule(adminSession, "@EU.mailinator.com", &model.NewEmailDomainRule{
+		Rule: "allow",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer emailDomainService.DeleteRule(adminSession, "eu.mailinator.com")
+
+	// when
+	allowedErr := emailDomainService.CheckEmail("someone@eu.mailinator.com")
+	parentErr := emailDomainService.CheckEmail("someone@mailinator.com")
+
+	// then
+	if allowedErr != nil {
+		t.Error("expected the allowed subdomain to be accepted")
+	}
+	if parentErr == nil {
+		t.Error("expected the rest of the disposable domain to be rejected")
+	}
+}
+
+func Test_Only_Admins_Can_Manage_Email_Domains(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	emailDomainService := CreateTestEmailDomainService()
+
+	// given
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+
+	// when
+	_, setErr := emailDomainService.SetRule(moderatorSession, "example.org", &model.NewEmailDomainRule{Rule: "block"})
+	_, listErr := emailDomainService.GetRules(moderatorSession, 0)
+
+	// then
+	if setErr == nil || listErr == nil {
+		t.Error("expected moderators not to manage email domains")
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mailer"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrWebhookNotConfigured = errors.New("webhook not configured")
+
+var (
+	sendGridVerifier     *mailer.SendGridWebhookVerifier
+	sendGridVerifierOnce sync.Once
+)
+
+var deliveryStatuses = map[mailer.DeliveryEventType]enum.EmailDeliveryStatusType{
+	mailer.EventBounce:    enum.EmailDeliveryStatusBounced,
+	mailer.EventComplaint: enum.EmailDeliveryStatusComplained,
+}
+
+type EmailEventService struct {
+	emailRepository  *repository.EmailRepository
+	userRepository   *repository.UserRepository
+	sendGridVerifier *mailer.SendGridWebhookVerifier
+}
+
+// CreateEmailEventService reads the key SendGrid signs its event webhook
+// with from SENDGRID_WEBHOOK_PUBLIC_KEY, once. The webhook is turned off
+// without it.
+func CreateEmailEventService() *EmailEventService {
+	sendGridVerifierOnce.Do(func() {
+		base64Key := os.Getenv("SENDGRID_WEBHOOK_PUBLIC_KEY")
+		if base64Key == "" {
+			return
+		}
+		publicKey, err := mailer.ParseSendGridWebhookKey(base64Key)
+		if err != nil {
+			log.Print("error reading sendgrid webhook key :: ", err)
+			return
+		}
+		sendGridVerifier = mailer.NewSendGridWebhookVerifier(publicKey)
+	})
+	conn := db.CreateDefaultConnection()
+	return &EmailEventService{
+		repository.CreateEmailRepository(conn),
+		repository.CreateUserRepository(conn),
+		sendGridVerifier,
+	}
+}
+
+func CreateTestEmailEventService(verifier *mailer.SendGridWebhookVerifier) *EmailEventService {
+	conn := util.SetupTestDatabase()
+	return &EmailEventService{
+		repository.CreateEmailRepository(conn),
+		repository.CreateUserRepository(conn),
+		verifier,
+	}
+}
+
+// HandleSendGridEvents records the bounces and spam reports in a signed
+// event webhook request.
+func (s *EmailEventService) HandleSendGridEvents(payload []byte, signature string, timestamp string) error {
+	if s.sendGridVerifier == nil {
+		return ErrWebhookNotConfigured
+	}
+	if err := s.sendGridVerifier.Verify(payload, signature, timestamp); err != nil {
+		return err
+	}
+	events, err := mailer.ParseSendGridEvents(payload)
+	if err != nil {
+		return util.NewInputFieldError(
+			"events",
+			"events could not be read",
+		)
+	}
+	for _, event := range events {
+		if err = s.recordEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEvent marks the address as undeliverable. Events can arrive out of
+// order, so older ones never override newer ones.
+func (s *EmailEventService) recordEvent(event *mailer.DeliveryEvent) error {
+	address := strings.ToLower(strings.TrimSpace(event.Email))
+	email, err := s.emailRepository.FindOneByEmail(address)
+	if err != nil {
+		email = entity.CreateEmail(address)
+	}
+	if email.DeliveryStatusAt != nil && event.At.Before(*email.DeliveryStatusAt) {
+		return nil
+	}
+	if user, err := s.userRepository.GetUserFromEmail(address); err == nil {
+		email.UserID = user.ID
+	}
+	at := event.At
+	email.DeliveryStatus = deliveryStatuses[event.Type]
+	email.DeliveryReason = event.Reason
+	email.DeliveryStatusAt = &at
+	return s.emailRepository.Save(email).Error
+}
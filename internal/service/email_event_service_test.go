@@ -0,0 +1,246 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mailer"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testWebhookSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func createTestWebhookSigner() *testWebhookSigner {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	return &testWebhookSigner{key}
+}
+
+func (s *testWebhookSigner) verifier() *mailer.SendGridWebhookVerifier {
+	return mailer.NewSendGridWebhookVerifier(&s.key.PublicKey)
+}
+
+func (s *testWebhookSigner) sign(payload []byte, timestamp string) string {
+	hash := sha256.Sum256(append([]byte(timestamp), payload...))
+	signature, _ := ecdsa.SignASN1(rand.Reader, s.key, hash[:])
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func createSendGridEvent(email string, event string, eventType string) []byte {
+	return []byte(fmt.Sprintf(
+		`[{"email":%q,"event":%q,"type":%q,"reason":"550 mailbox unavailable","timestamp":%d}]`,
+		email,
+		event,
+		eventType,
+		time.Now().Unix(),
+	))
+}
+
+func Test_SendGrid_Webhook_Key_Is_Read_From_Base64(t *testing.T) {
+	// setup
+	signer := createTestWebhookSigner()
+	der, _ := x509.MarshalPKIXPublicKey(&signer.key.PublicKey)
+
+	// when
+	publicKey, err := mailer.ParseSendGridWebhookKey(base64.StdEncoding.EncodeToString(der))
+
+	// then
+	if err != nil || !publicKey.Equal(&signer.key.PublicKey) {
+		t.Error("expected the key to be read")
+	}
+	if _, err = mailer.ParseSendGridWebhookKey("not a key"); err == nil {
+		t.Error("expected an invalid key to fail")
+	}
+}
+
+func Test_SendGrid_Events_Need_A_Valid_Signature(t *testing.T) {
+	// setup
+	signer := createTestWebhookSigner()
+	verifier := signer.verifier()
+	payload := createSendGridEvent("someone@example.org", "bounce", "bounce")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := signer.sign(payload, timestamp)
+
+	// expect
+	if verifier.Verify(payload, signature, timestamp) != nil {
+		t.Error("expected a signed payload to pass")
+	}
+	if verifier.Verify(append(payload, ' '), signature, timestamp) == nil {
+		t.Error("expected a changed payload to fail")
+	}
+	if verifier.Verify(payload, signature, timestamp+"1") == nil {
+		t.Error("expected a changed timestamp to fail")
+	}
+	if verifier.Verify(payload, createTestWebhookSigner().sign(payload, timestamp), timestamp) == nil {
+		t.Error("expected a payload signed with another key to fail")
+	}
+	staleTimestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	if verifier.Verify(payload, signer.sign(payload, staleTimestamp), staleTimestamp) == nil {
+		t.Error("expected a payload signed long ago to fail")
+	}
+}
+
+func Test_SendGrid_Events_Keep_Hard_Bounces_And_Spam_Reports(t *testing.T) {
+	// setup
+	payload := []byte(`[
+		{"email":"bounced@example.org","event":"bounce","type":"bounce","timestamp":1700000000},
+		{"email":"blocked@example.org","event":"bounce","type":"blocked","timestamp":1700000000},
+		{"email":"spam@example.org","event":"spamreport","timestamp":1700000000},
+		{"email":"delivered@example.org","event":"delivered","timestamp":1700000000}
+	]`)
+
+	// when
+	events, err := mailer.ParseSendGridEvents(payload)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected two events, got %d", len(events))
+	}
+	if events[0].Email != "bounced@example.org" || events[0].Type != mailer.EventBounce {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+	if events[1].Email != "spam@example.org" || events[1].Type != mailer.EventComplaint {
+		t.Errorf("unexpected event %+v", events[1])
+	}
+	if !events[0].At.Equal(time.Unix(1700000000, 0)) {
+		t.Error("expected the event time to be kept")
+	}
+}
+
+func Test_Bounce_Prompts_The_User_Until_They_Verify_Again(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	signer := createTestWebhookSigner()
+	emailEventService := CreateTestEmailEventService(signer.verifier())
+
+	// given
+	user, session := svc.CreateUserWithRole(model.USER)
+	payload := createSendGridEvent(user.Email, "bounce", "bounce")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// when
+	err := emailEventService.HandleSendGridEvents(payload, signer.sign(payload, timestamp), timestamp)
+	svc.userService.SetEmailDeliveryStatus(session)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.EmailDeliveryStatus != string(enum.EmailDeliveryStatusBounced) {
+		t.Errorf("expected the session to prompt for a new address, got %q", session.EmailDeliveryStatus)
+	}
+
+	// when
+	userEntity, _ := svc.userRepository.GetUserFromEmail(user.Email)
+	err = svc.userService.SubmitOTP(&model.Otp{User: &model.User{Email: user.Email}, Code: userEntity.OTP})
+	session.EmailDeliveryStatus = ""
+	svc.userService.SetEmailDeliveryStatus(session)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.EmailDeliveryStatus != "" {
+		t.Error("expected verifying the address to clear the bounce")
+	}
+}
+
+func Test_Events_For_Mixed_Case_Addresses_Find_The_User(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	signer := createTestWebhookSigner()
+	emailEventService := CreateTestEmailEventService(signer.verifier())
+
+	// given
+	emailAddr := "Alice." + util.RandomEmailAddress()
+	userModel, err := svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, _ := svc.userRepository.GetUserFromEmail(emailAddr)
+	session := model.CreateSession(userModel, "")
+	payload := createSendGridEvent(strings.ToLower(emailAddr), "bounce", "bounce")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// when
+	err = emailEventService.HandleSendGridEvents(payload, signer.sign(payload, timestamp), timestamp)
+	svc.userService.SetEmailDeliveryStatus(session)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	email, err := emailEventService.emailRepository.FindOneByEmail(emailAddr)
+	if err != nil || email.UserID != user.ID {
+		t.Error("expected the event to be linked to the user")
+	}
+	if session.EmailDeliveryStatus != string(enum.EmailDeliveryStatusBounced) {
+		t.Error("expected the user to be told their address bounced")
+	}
+}
+
+func Test_Unsigned_Events_Are_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	signer := createTestWebhookSigner()
+	emailEventService := CreateTestEmailEventService(signer.verifier())
+
+	// given
+	user, session := svc.CreateUserWithRole(model.USER)
+	payload := createSendGridEvent(user.Email, "spamreport", "")
+
+	// when
+	err := emailEventService.HandleSendGridEvents(payload, "", "")
+	svc.userService.SetEmailDeliveryStatus(session)
+
+	// then
+	if err != mailer.ErrInvalidSignature {
+		t.Errorf("expected the signature to be checked, got %v", err)
+	}
+	if session.EmailDeliveryStatus != "" {
+		t.Error("expected the event to be ignored")
+	}
+}
+
+func Test_Invites_Are_Not_Sent_To_Addresses_That_Complained(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+	signer := createTestWebhookSigner()
+	emailEventService := CreateTestEmailEventService(signer.verifier())
+	emailAddr := util.RandomEmailAddress()
+
+	// given
+	_, session := svc.CreateUserWithRole(model.USER)
+	payload := createSendGridEvent(emailAddr, "spamreport", "")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	_ = emailEventService.HandleSendGridEvents(payload, signer.sign(payload, timestamp), timestamp)
+
+	// when
+	_, err := inviteService.SendEmailInvite(session, util.GenerateCode(), &model.EmailInvite{
+		Email: emailAddr,
+	})
+
+	// then
+	if _, ok := err.(*util.InputFieldError); !ok {
+		t.Errorf("expected the invite to be held back, got %v", err)
+	}
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/kafka"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+)
+
+type GroupService struct {
+	groupRepository *repository.GroupRepository
+	userRepository  *repository.UserRepository
+	kafkaWriter     kafka.Producer
+	securityService *SecurityService
+}
+
+func CreateGroupService() *GroupService {
+	conn := db.CreateDefaultConnection()
+	writer, err := kafka.CreateProducer()
+	if err != nil {
+		log.Fatal("error creating kafka writer :: ", err)
+	}
+	return &GroupService{
+		repository.CreateGroupRepository(conn),
+		repository.CreateUserRepository(conn),
+		writer,
+		CreateSecurityService(),
+	}
+}
+
+func CreateTestGroupService() *GroupService {
+	conn := util.SetupTestDatabase()
+	writer, err := util.CreateTestProducer()
+	if err != nil {
+		log.Fatal("error creating test kafka writer :: ", err)
+	}
+	return &GroupService{
+		repository.CreateGroupRepository(conn),
+		repository.CreateUserRepository(conn),
+		writer,
+		CreateTestSecurityService(),
+	}
+}
+
+func (s *GroupService) CreateGroup(session *model.Session, groupModel *model.Group) (*model.Group, error) {
+	user, err := s.getActiveUser(session)
+	if err != nil {
+		return nil, err
+	}
+	if groupModel.Name == "" {
+		return nil, util.NewInputFieldError(
+			"name",
+			"group name is required",
+		)
+	}
+	slug := util.Slugify(groupModel.Slug)
+	if slug == "" {
+		slug = util.Slugify(groupModel.Name)
+	}
+	if slug == "" {
+		return nil, util.NewInputFieldError(
+			"slug",
+			"group slug must contain letters or numbers",
+		)
+	}
+	if _, err := s.groupRepository.FindOneBySlug(slug); err == nil {
+		return nil, util.NewInputFieldError(
+			"slug",
+			"group slug already in use",
+		)
+	}
+	visibility, err := s.validateVisibility(groupModel.Visibility)
+	if err != nil {
+		return nil, err
+	}
+	group := &entity.Group{
+		Name:        groupModel.Name,
+		Slug:        slug,
+		Description: groupModel.Description,
+		OwnerID:     user.ID,
+		Owner:       user,
+		Visibility:  visibility,
+	}
+	owner := &entity.GroupMember{
+		UserID: user.ID,
+		User:   user,
+		Role:   string(enum.GroupRoleOwner),
+		Status: string(enum.GroupMemberStatusActive),
+	}
+	err = s.groupRepository.Create(group, owner)
+	if err != nil {
+		return nil, err
+	}
+	s.publishGroupEvent(model.GroupEventCreated, group, owner, user)
+	return mapper.MapGroupEntityToModel(group), nil
+}
+
+func (s *GroupService) UpdateGroup(session *model.Session, slug string, groupUpdate *model.GroupUpdate) (*model.Group, error) {
+	user, group, member, err := s.getManagedGroup(session, slug)
+	if err != nil {
+		return nil, err
+	}
+	if groupUpdate.Name != nil {
+		if *groupUpdate.Name == "" {
+			return nil, util.NewInputFieldError(
+				"name",
+				"group name is required",
+			)
+		}
+		group.Name = *groupUpdate.Name
+	}
+	if groupUpdate.Description != nil {
+		group.Description = *groupUpdate.Description
+	}
+	if groupUpdate.Visibility != nil && *groupUpdate.Visibility != "" {
+		group.Visibility, err = s.validateVisibility(*groupUpdate.Visibility)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := s.groupRepository.Save(group)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	s.publishGroupEvent(model.GroupEventUpdated, group, member, user)
+	return mapper.MapGroupEntityToModel(group), nil
+}
+
+func (s *GroupService) GetGroup(session *model.Session, slug string) (*model.Group, error) {
+	group, err := s.getVisibleGroup(session, slug)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapGroupEntityToModel(group), nil
+}
+
+func (s *GroupService) GetMembers(session *model.Session, slug string, offset int) ([]*model.GroupMember, error) {
+	group, err := s.getVisibleGroup(session, slug)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapGroupMemberEntitiesToModels(s.groupRepository.FindMembers(group, offset)), nil
+}
+
+// GetUserGroups lists the groups a user belongs to. Private groups are only
+// listed for the user themselves and for fellow members.
+func (s *GroupService) GetUserGroups(session *model.Session, username string, offset int) ([]*model.GroupMember, error) {
+	user, err := s.userRepository.GetUserFromUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	viewer, _ := getSessionUser(s.userRepository, session)
+	var memberships []*entity.GroupMember
+	for _, membership := range s.groupRepository.FindMembershipsForUser(user, offset) {
+		if s.canView(viewer, membership.Group) {
+			memberships = append(memberships, membership)
+		}
+	}
+	return mapper.MapGroupMemberEntitiesToModels(memberships), nil
+}
+
+// AddMember adds a user to the group straight away.
+func (s *GroupService) AddMember(session *model.Session, slug string, newMember *model.NewGroupMember) (*model.GroupMember, error) {
+	return s.addMember(session, slug, newMember, enum.GroupMemberStatusActive)
+}
+
+// InviteMember invites a user, who becomes a member once they join.
+func (s *GroupService) InviteMember(session *model.Session, slug string, newMember *model.NewGroupMember) (*model.GroupMember, error) {
+	return s.addMember(session, slug, newMember, enum.GroupMemberStatusInvited)
+}
+
+// JoinGroup accepts a pending invitation, or joins a public group as a
+// member.
+func (s *GroupService) JoinGroup(session *model.Session, slug string) (*model.GroupMember, error) {
+	user, err := s.getActiveUser(session)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.groupRepository.FindOneBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.groupRepository.FindMember(group, user)
+	if err == nil && member.IsActive() {
+		return nil, util.NewInputFieldError(
+			"username",
+			"already a member of this group",
+		)
+	}
+	if err != nil {
+		if !group.IsPublic() {
+			return nil, errors.New("not allowed")
+		}
+		member = &entity.GroupMember{
+			GroupID: group.ID,
+			UserID:  user.ID,
+			Role:    string(enum.GroupRoleMember),
+		}
+	}
+	member.Status = string(enum.GroupMemberStatusActive)
+	result := s.groupRepository.SaveMember(member)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	member.User = user
+	s.publishGroupEvent(model.GroupEventMemberAdded, group, member, user)
+	return mapper.MapGroupMemberEntityToModel(member), nil
+}
+
+// LeaveGroup removes the session user from the group, or declines their
+// invitation. The last owner can't leave.
+func (s *GroupService) LeaveGroup(session *model.Session, slug string) error {
+	user, err := s.getActiveUser(session)
+	if err != nil {
+		return err
+	}
+	group, err := s.groupRepository.FindOneBySlug(slug)
+	if err != nil {
+		return err
+	}
+	member, err := s.groupRepository.FindMember(group, user)
+	if err != nil {
+		return err
+	}
+	if member.IsOwner() && s.groupRepository.CountOwners(group) < 2 {
+		return util.NewInputFieldError(
+			"role",
+			"the last owner cannot leave the group",
+		)
+	}
+	return s.removeMember(group, member, user, user)
+}
+
+// RemoveMember removes another user from the group. Only owners can remove
+// admins and owners.
+func (s *GroupService) RemoveMember(session *model.Session, slug string, username string) error {
+	user, group, actor, err := s.getManagedGroup(session, slug)
+	if err != nil {
+		return err
+	}
+	target, err := s.userRepository.GetUserFromUsername(username)
+	if err != nil {
+		return err
+	}
+	member, err := s.groupRepository.FindMember(group, target)
+	if err != nil {
+		return err
+	}
+	if member.Role != string(enum.GroupRoleMember) && !actor.IsOwner() {
+		return errors.New("not allowed")
+	}
+	if member.IsOwner() && s.groupRepository.CountOwners(group) < 2 {
+		return util.NewInputFieldError(
+			"role",
+			"the last owner cannot be removed",
+		)
+	}
+	return s.removeMember(group, member, target, user)
+}
+
+func (s *GroupService) addMember(session *model.Session, slug string, newMember *model.NewGroupMember, status enum.GroupMemberStatusType) (*model.GroupMember, error) {
+	user, group, actor, err := s.getManagedGroup(session, slug)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.validateRole(newMember.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanAssignRole(role, nil) {
+		return nil, errors.New("not allowed")
+	}
+	target, err := s.userRepository.GetUserFromUsername(newMember.Username)
+	if err != nil || target.IsBanned {
+		return nil, util.NewInputFieldError(
+			"username",
+			"user not found",
+		)
+	}
+	member, err := s.groupRepository.FindMember(group, target)
+	if err == nil && member.IsActive() {
+		return nil, util.NewInputFieldError(
+			"username",
+			"user is already a member of this group",
+		)
+	}
+	if err != nil {
+		member = &entity.GroupMember{
+			GroupID: group.ID,
+			UserID:  target.ID,
+		}
+	} else if !actor.CanAssignRole(role, member) {
+		return nil, errors.New("not allowed")
+	}
+	member.Role = role
+	member.Status = string(status)
+	result := s.groupRepository.SaveMember(member)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	member.User = target
+	eventType := model.GroupEventMemberAdded
+	if status == enum.GroupMemberStatusInvited {
+		eventType = model.GroupEventMemberInvited
+	}
+	s.publishGroupEvent(eventType, group, member, user)
+	return mapper.MapGroupMemberEntityToModel(member), nil
+}
+
+func (s *GroupService) removeMember(group *entity.Group, member *entity.GroupMember, target *entity.User, actor *entity.User) error {
+	result := s.groupRepository.DeleteMember(member)
+	if result.Error != nil {
+		return result.Error
+	}
+	member.User = target
+	s.publishGroupEvent(model.GroupEventMemberRemoved, group, member, actor)
+	return nil
+}
+
+func (s *GroupService) getManagedGroup(session *model.Session, slug string) (*entity.User, *entity.Group, *entity.GroupMember, error) {
+	user, err := s.getActiveUser(session)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	group, err := s.groupRepository.FindOneBySlug(slug)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	member, err := s.groupRepository.FindMember(group, user)
+	if err != nil || !member.CanManage() {
+		return nil, nil, nil, errors.New("not allowed")
+	}
+	return user, group, member, nil
+}
+
+func (s *GroupService) getVisibleGroup(session *model.Session, slug string) (*entity.Group, error) {
+	group, err := s.groupRepository.FindOneBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	viewer, _ := getSessionUser(s.userRepository, session)
+	if !s.canView(viewer, group) {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+// canView lets anyone see public groups and only members, including invited
+// ones, see private groups.
+func (s *GroupService) canView(viewer *entity.User, group *entity.Group) bool {
+	if group.IsPublic() {
+		return true
+	}
+	if viewer == nil {
+		return false
+	}
+	_, err := s.groupRepository.FindMember(group, viewer)
+	return err == nil
+}
+
+func (s *GroupService) validateVisibility(visibility string) (string, error) {
+	switch enum.GroupVisibilityType(visibility) {
+	case "":
+		return string(enum.GroupVisibilityPublic), nil
+	case enum.GroupVisibilityPublic, enum.GroupVisibilityPrivate:
+		return visibility, nil
+	}
+	return "", util.NewInputFieldError(
+		"visibility",
+		"visibility must be public or private",
+	)
+}
+
+func (s *GroupService) validateRole(role string) (string, error) {
+	switch enum.GroupRoleType(role) {
+	case "":
+		return string(enum.GroupRoleMember), nil
+	case enum.GroupRoleMember, enum.GroupRoleAdmin, enum.GroupRoleOwner:
+		return role, nil
+	}
+	return "", util.NewInputFieldError(
+		"role",
+		"role must be member, admin or owner",
+	)
+}
+
+// getActiveUser returns the session user for changing groups. Tokens
+// restricted to scopes can't, as no scope covers groups.
+func (s *GroupService) getActiveUser(session *model.Session) (*entity.User, error) {
+	if session != nil && session.Scopes != nil {
+		return nil, errors.New("not allowed")
+	}
+	user, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBanned {
+		return nil, errors.New("not allowed")
+	}
+	return user, nil
+}
+
+func (s *GroupService) publishGroupEvent(eventType model.GroupEventType, group *entity.Group, member *entity.GroupMember, actor *entity.User) {
+	topic := "groups"
+	event := model.CreateGroupEvent(
+		eventType,
+		mapper.MapGroupEntityToModel(group),
+		mapper.MapGroupMemberEntityToModel(member),
+		actor.Uuid.String(),
+	)
+	eventData, _ := json.Marshal(event)
+	err := s.kafkaWriter.Produce(kafka.CreateMessage(eventData, topic), nil)
+	if err != nil {
+		log.Print("error publishing to kafka :: ", err)
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"testing"
+)
+
+func Test_Create_Group_Makes_Creator_Owner(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	owner, session := svc.CreateUserWithRole(model.USER)
+
+	// when
+	group, err := groupService.CreateGroup(session, &model.Group{
+		Name: "Book Club " + util.RandomUsername(),
+	})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	members, _ := groupService.GetMembers(session, group.Slug, 0)
+	if len(members) != 1 || members[0].User.Uuid != owner.Uuid.String() ||
+		members[0].Role != string(enum.GroupRoleOwner) {
+		t.Error("expected the creator to be the only owner")
+	}
+}
+
+func Test_Invited_User_Can_Join_Private_Group(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, ownerSession := svc.CreateUserWithRole(model.USER)
+	invitee, inviteeSession := svc.CreateUserWithRole(model.USER)
+	_, strangerSession := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(ownerSession, &model.Group{
+		Name:       "Secret " + util.RandomUsername(),
+		Visibility: string(enum.GroupVisibilityPrivate),
+	})
+
+	// when
+	_, err := groupService.JoinGroup(strangerSession, group.Slug)
+
+	// then
+	if err == nil {
+		t.Error("expected a stranger to be unable to join a private group")
+	}
+
+	// when
+	_, err = groupService.InviteMember(ownerSession, group.Slug, &model.NewGroupMember{
+		Username: invitee.Username,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	member, err := groupService.JoinGroup(inviteeSession, group.Slug)
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if member.Status != string(enum.GroupMemberStatusActive) {
+		t.Fail()
+	}
+	groups, _ := groupService.GetUserGroups(strangerSession, invitee.Username, 0)
+	if len(groups) != 0 {
+		t.Error("expected private groups to be hidden from non-members")
+	}
+}
+
+func Test_Last_Owner_Cannot_Leave_Group(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, session := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(session, &model.Group{
+		Name: "Solo " + util.RandomUsername(),
+	})
+
+	// when
+	err := groupService.LeaveGroup(session, group.Slug)
+
+	// then
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_Admin_Cannot_Reset_Role_Of_Invited_Admin(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, ownerSession := svc.CreateUserWithRole(model.USER)
+	admin, adminSession := svc.CreateUserWithRole(model.USER)
+	invitee, _ := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(ownerSession, &model.Group{
+		Name: "Ranks " + util.RandomUsername(),
+	})
+	_, _ = groupService.AddMember(ownerSession, group.Slug, &model.NewGroupMember{
+		Username: admin.Username,
+		Role:     string(enum.GroupRoleAdmin),
+	})
+	_, _ = groupService.InviteMember(ownerSession, group.Slug, &model.NewGroupMember{
+		Username: invitee.Username,
+		Role:     string(enum.GroupRoleAdmin),
+	})
+
+	// when
+	_, err := groupService.InviteMember(adminSession, group.Slug, &model.NewGroupMember{
+		Username: invitee.Username,
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected an admin to be unable to change the role of an admin")
+	}
+}
+
+func Test_Update_Group_Keeps_Fields_Left_Out(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, session := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(session, &model.Group{
+		Name:        "Garden " + util.RandomUsername(),
+		Description: "we grow things",
+		Visibility:  string(enum.GroupVisibilityPrivate),
+	})
+	name := "Allotment " + util.RandomUsername()
+
+	// when
+	updated, err := groupService.UpdateGroup(session, group.Slug, &model.GroupUpdate{
+		Name: &name,
+	})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != name {
+		t.Error("expected the name to change")
+	}
+	if updated.Description != "we grow things" || updated.Visibility != string(enum.GroupVisibilityPrivate) {
+		t.Error("expected fields left out of the update to keep their value")
+	}
+}
+
+func Test_Member_Cannot_Update_Group(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, ownerSession := svc.CreateUserWithRole(model.USER)
+	member, memberSession := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(ownerSession, &model.Group{
+		Name: "Members " + util.RandomUsername(),
+	})
+	_, _ = groupService.AddMember(ownerSession, group.Slug, &model.NewGroupMember{
+		Username: member.Username,
+	})
+	description := "taken over"
+
+	// when
+	_, err := groupService.UpdateGroup(memberSession, group.Slug, &model.GroupUpdate{
+		Description: &description,
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected a member to be unable to update the group")
+	}
+}
+
+func Test_Admin_Cannot_Remove_Admin(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, ownerSession := svc.CreateUserWithRole(model.USER)
+	admin, adminSession := svc.CreateUserWithRole(model.USER)
+	otherAdmin, _ := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(ownerSession, &model.Group{
+		Name: "Admins " + util.RandomUsername(),
+	})
+	for _, user := range []string{admin.Username, otherAdmin.Username} {
+		_, _ = groupService.AddMember(ownerSession, group.Slug, &model.NewGroupMember{
+			Username: user,
+			Role:     string(enum.GroupRoleAdmin),
+		})
+	}
+
+	// when
+	err := groupService.RemoveMember(adminSession, group.Slug, otherAdmin.Username)
+
+	// then
+	if err == nil {
+		t.Error("expected an admin to be unable to remove another admin")
+	}
+}
+
+func Test_Non_Member_Cannot_See_Private_Group_Members(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, ownerSession := svc.CreateUserWithRole(model.USER)
+	_, strangerSession := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(ownerSession, &model.Group{
+		Name:       "Hidden " + util.RandomUsername(),
+		Visibility: string(enum.GroupVisibilityPrivate),
+	})
+
+	// when
+	members, err := groupService.GetMembers(strangerSession, group.Slug, 0)
+
+	// then
+	if err == nil || members != nil {
+		t.Error("expected a non-member to be unable to list a private group's members")
+	}
+
+	// when
+	_, err = groupService.GetMembers(nil, group.Slug, 0)
+
+	// then
+	if err == nil {
+		t.Error("expected a visitor without a session to be unable to list a private group's members")
+	}
+}
+
+func Test_Banned_User_Cannot_Join_Group(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	groupService := CreateTestGroupService()
+
+	// given
+	_, ownerSession := svc.CreateUserWithRole(model.USER)
+	banned, bannedSession := svc.CreateUserWithRole(model.USER)
+	group, _ := groupService.CreateGroup(ownerSession, &model.Group{
+		Name: "Open " + util.RandomUsername(),
+	})
+	banned.IsBanned = true
+	svc.userRepository.Save(banned)
+
+	// when
+	_, err := groupService.JoinGroup(bannedSession, group.Slug)
+
+	// then
+	if err == nil {
+		t.Error("expected a banned user to be unable to join a group")
+	}
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/identity"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"strings"
+	"time"
+)
+
+// SocialStateLifetime is how long a social sign-in may take.
+const SocialStateLifetime = 10 * time.Minute
+
+type IdentityService struct {
+	linkedIdentityRepository *repository.LinkedIdentityRepository
+	userRepository           *repository.UserRepository
+	inviteRepository         *repository.InviteRepository
+	userService              *UserService
+	providers                *identity.Registry
+}
+
+func CreateIdentityService() *IdentityService {
+	conn := db.CreateDefaultConnection()
+	return &IdentityService{
+		repository.CreateLinkedIdentityRepository(conn),
+		repository.CreateUserRepository(conn),
+		repository.CreateInviteRepository(conn),
+		CreateUserService(),
+		identity.CreateRegistryFromEnv(),
+	}
+}
+
+func CreateTestIdentityService() *IdentityService {
+	conn := util.SetupTestDatabase()
+	return &IdentityService{
+		repository.CreateLinkedIdentityRepository(conn),
+		repository.CreateUserRepository(conn),
+		repository.CreateInviteRepository(conn),
+		CreateTestUserService(),
+		identity.CreateRegistry(),
+	}
+}
+
+// Authorize returns where to send the user to sign in with the provider.
+// First-time sign-ups need an invite code, which is carried in the state.
+// The nonce must come back with the callback, from the same browser.
+func (s *IdentityService) Authorize(providerName string, inviteCode string) (*model.SocialAuthorization, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := util.GenerateSecret(16)
+	if err != nil {
+		return nil, err
+	}
+	state, err := jwt.NewWithClaims(
+		jwt.SigningMethodHS256,
+		model.NewSocialState(providerName, inviteCode, util.HashSecret(nonce), SocialStateLifetime),
+	).SignedString(util.JwtKey)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SocialAuthorization{
+		Url:   provider.AuthCodeURL(state),
+		State: state,
+		Nonce: nonce,
+	}, nil
+}
+
+// Callback completes a sign-in with the provider. With a session, an identity
+// already linked to the session user reauthenticates them, which is how users
+// without a password satisfy RequireRecentAuth. Any other identity is linked
+// to the session user after a recent authentication. Without a session, the
+// user linked to
+// the identity is signed in, or the user with the same email address gets
+// the identity linked when both sides verified it, or a new user is signed
+// up with the invite code from the state.
+func (s *IdentityService) Callback(session *model.Session, providerName string, callback *model.SocialCallback) (*model.Session, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.parseState(callback.State)
+	if err != nil || state.Provider != providerName ||
+		callback.Nonce == "" || !hmac.Equal([]byte(util.HashSecret(callback.Nonce)), []byte(state.NonceHash)) {
+		return nil, errors.New("state not valid")
+	}
+	upstream, err := provider.Exchange(context.Background(), callback.Code)
+	if err != nil {
+		log.Print("error exchanging code with identity provider :: ", providerName, err)
+		return nil, errors.New("sign in with identity provider failed")
+	}
+	if upstream.Subject == "" {
+		return nil, errors.New("sign in with identity provider failed")
+	}
+	if session != nil {
+		return s.linkToSession(session, providerName, upstream, callback.Client)
+	}
+	linkedIdentity, err := s.linkedIdentityRepository.FindOneBySubject(providerName, upstream.Subject)
+	if err == nil {
+		return s.signIn(linkedIdentity.User, linkedIdentity, enum.LoginMethodSocial, callback.Client)
+	}
+	user, _ := s.userRepository.GetUserFromEmail(upstream.Email)
+	if user != nil {
+		if !upstream.EmailVerified || !user.Verified || user.IsServiceAccount {
+			return nil, util.NewInputFieldError(
+				"email",
+				"email already registered, log in to link this account",
+			)
+		}
+		linkedIdentity, err = s.link(user, providerName, upstream)
+		if err != nil {
+			return nil, err
+		}
+		return s.signIn(user, linkedIdentity, enum.LoginMethodSocial, callback.Client)
+	}
+	user, err = s.signUp(upstream, state.InviteCode)
+	if err != nil {
+		return nil, err
+	}
+	linkedIdentity, err = s.link(user, providerName, upstream)
+	if err != nil {
+		return nil, err
+	}
+	return s.signIn(user, linkedIdentity, enum.LoginMethodSocial, callback.Client)
+}
+
+func (s *IdentityService) GetLinkedIdentities(session *model.Session) ([]*model.LinkedIdentity, error) {
+	user, err := s.getUser(session)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapLinkedIdentityEntitiesToModels(s.linkedIdentityRepository.FindForUser(user)), nil
+}
+
+// Unlink removes a linked identity, unless it's the only way the user can
+// sign in.
+func (s *IdentityService) Unlink(session *model.Session, identityUuid uuid.UUID) error {
+	user, err := s.getUser(session)
+	if err != nil {
+		return err
+	}
+	linkedIdentity, err := s.linkedIdentityRepository.FindOneByUuid(user, identityUuid)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" && len(s.linkedIdentityRepository.FindForUser(user)) == 1 {
+		return util.NewInputFieldError(
+			"identity",
+			"set a password before removing your last way to sign in",
+		)
+	}
+	return s.linkedIdentityRepository.Delete(linkedIdentity).Error
+}
+
+func (s *IdentityService) linkToSession(session *model.Session, providerName string, upstream *identity.Identity, client model.ClientInfo) (*model.Session, error) {
+	user, err := s.getUser(session)
+	if err != nil {
+		return nil, err
+	}
+	linkedIdentity, err := s.linkedIdentityRepository.FindOneBySubject(providerName, upstream.Subject)
+	if err == nil {
+		if linkedIdentity.UserID != user.ID {
+			return nil, util.NewInputFieldError(
+				"identity",
+				"this account is already linked to another user",
+			)
+		}
+		return s.signIn(user, linkedIdentity, enum.LoginMethodReauth, client)
+	}
+	if err = util.RequireRecentAuth(session, RecentAuthMaxAge); err != nil {
+		return nil, err
+	}
+	_, err = s.link(user, providerName, upstream)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *IdentityService) link(user *entity.User, providerName string, upstream *identity.Identity) (*entity.LinkedIdentity, error) {
+	linkedIdentity := &entity.LinkedIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  upstream.Subject,
+		Email:    upstream.Email,
+	}
+	result := s.linkedIdentityRepository.Create(linkedIdentity)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return linkedIdentity, nil
+}
+
+// signUp creates a user for the identity, enforcing the same registration
+// rules as CreateUser. Social users have no password until they reset it.
+func (s *IdentityService) signUp(upstream *identity.Identity, inviteCode string) (*entity.User, error) {
+	if upstream.Email == "" {
+		return nil, util.NewInputFieldError(
+			"email",
+			"the identity provider did not share an email address",
+		)
+	}
+	invite, admittedByDomain, err := s.userService.checkRegistration(inviteCode, upstream.Email)
+	if err != nil {
+		return nil, err
+	}
+	user := &entity.User{
+		Name:             upstream.Name,
+		Username:         s.generateUsername(upstream),
+		Email:            upstream.Email,
+		Verified:         upstream.EmailVerified,
+		AdmittedByDomain: admittedByDomain,
+		OTP:              util.GenerateCode(),
+	}
+	if invite != nil {
+		user.InviteID = invite.ID
+	}
+	var email *entity.OutboxEmail
+	if !user.Verified {
+		email, err = s.userService.mailService.CreateVerificationEmail(user)
+		if err != nil {
+			log.Print("error rendering verification email :: ", err)
+			return nil, errors.New("error creating user")
+		}
+	}
+	err = s.userRepository.CreateWithInvite(user, invite, email)
+	if err != nil {
+		return nil, s.userService.createUserError(invite, err)
+	}
+	s.userService.assignDefaultRole(user)
+	err = s.userService.publishUserToKafka(user)
+	if err != nil {
+		log.Print("error publishing to kafka :: ", err)
+	}
+	return user, nil
+}
+
+func (s *IdentityService) signIn(user *entity.User, linkedIdentity *entity.LinkedIdentity, method enum.LoginMethodType, client model.ClientInfo) (*model.Session, error) {
+	if user == nil || user.IsServiceAccount {
+		return nil, errors.New("authentication failed")
+	}
+	now := time.Now()
+	linkedIdentity.LastUsedAt = &now
+	s.linkedIdentityRepository.Save(linkedIdentity)
+	s.userService.recordLoginAttempt(user, method, true, client)
+	return s.userService.createSessionForUser(user)
+}
+
+// generateUsername picks an unused username based on the user's name or
+// email address.
+func (s *IdentityService) generateUsername(upstream *identity.Identity) string {
+	base := util.Slugify(upstream.Name)
+	if base == "" {
+		base = util.Slugify(strings.Split(upstream.Email, "@")[0])
+	}
+	if base == "" {
+		base = "member"
+	}
+	username := base
+	for {
+		if _, err := s.userRepository.GetUserFromUsername(username); err != nil {
+			return username
+		}
+		username = base + "-" + uuid.New().String()[:6]
+	}
+}
+
+func (s *IdentityService) parseState(value string) (*model.SocialState, error) {
+	state := &model.SocialState{}
+	token, err := jwt.ParseWithClaims(value, state, func(token *jwt.Token) (interface{}, error) {
+		return util.JwtKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("state not valid")
+	}
+	return state, nil
+}
+
+// getUser returns the session user. Linked identities are ways to sign in,
+// so they can't be managed with access tokens or while impersonating.
+func (s *IdentityService) getUser(session *model.Session) (*entity.User, error) {
+	if session == nil || session.User == nil || session.Scopes != nil ||
+		session.IsImpersonated() || util.IsAccessToken(session.Token) {
+		return nil, errors.New("not allowed")
+	}
+	return getSessionUser(s.userRepository, session)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/identity"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// startMockIdp starts an identity provider that accepts any code and
+// describes the user with the given claims.
+func startMockIdp(claims map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mock-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(claims)
+	})
+	return httptest.NewServer(mux)
+}
+
+func createMockIdentityService(idp *httptest.Server) *IdentityService {
+	identityService := CreateTestIdentityService()
+	identityService.providers.Register(identity.NewOAuth2Provider(&identity.Config{
+		Name:         "mock",
+		ClientId:     "client",
+		ClientSecret: "secret",
+		AuthURL:      idp.URL + "/authorize",
+		TokenURL:     idp.URL + "/token",
+		UserInfoURL:  idp.URL + "/userinfo",
+	}))
+	return identityService
+}
+
+func socialCallback(identityService *IdentityService, inviteCode string) (*model.Session, error) {
+	return socialCallbackWithSession(identityService, nil, inviteCode)
+}
+
+func socialCallbackWithSession(identityService *IdentityService, session *model.Session, inviteCode string) (*model.Session, error) {
+	authorization, err := identityService.Authorize("mock", inviteCode)
+	if err != nil {
+		return nil, err
+	}
+	return identityService.Callback(session, "mock", &model.SocialCallback{
+		Code:  "mock-code",
+		State: authorization.State,
+		Nonce: authorization.Nonce,
+	})
+}
+
+func Test_Social_Sign_Up_Requires_An_Invite(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	email := util.RandomEmailAddress()
+	idp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          email,
+		"email_verified": true,
+		"name":           "Social User",
+	})
+	defer idp.Close()
+	identityService := createMockIdentityService(idp)
+
+	// given
+	invite, _ := svc.CreateInvite()
+
+	// when
+	_, noInviteErr := socialCallback(identityService, "")
+	signUp, signUpErr := socialCallback(identityService, invite.Code)
+	signIn, signInErr := socialCallback(identityService, "")
+
+	// then
+	if err, ok := noInviteErr.(*util.InputFieldError); !ok || err.Input != "inviteCode" {
+		t.Error("expected an invite code error")
+	}
+	if signUpErr != nil || signInErr != nil {
+		t.Fatal(signUpErr, signInErr)
+	}
+	if signUp.User.Email != email || signIn.User.Uuid != signUp.User.Uuid {
+		t.Error("expected the second callback to sign in the same user")
+	}
+}
+
+func Test_Social_Login_Links_Account_With_Verified_Email(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	email := util.RandomEmailAddress()
+	idp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          email,
+		"email_verified": "true",
+	})
+	defer idp.Close()
+	identityService := createMockIdentityService(idp)
+
+	// given
+	user, _ := svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    email,
+		Password: dummyPassword,
+	})
+	userEntity, _ := svc.userRepository.GetUserFromEmail(email)
+	userEntity.Verified = true
+	svc.userRepository.Save(userEntity)
+
+	// when
+	session, err := socialCallback(identityService, "")
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.User.Uuid != user.Uuid {
+		t.Error("expected the identity to be linked to the existing user")
+	}
+	identities, _ := identityService.GetLinkedIdentities(session)
+	if len(identities) != 1 || identities[0].Provider != "mock" {
+		t.Error("expected one linked identity")
+	}
+}
+
+func Test_Social_Login_Does_Not_Link_Unverified_Email(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	email := util.RandomEmailAddress()
+	idp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          email,
+		"email_verified": false,
+	})
+	defer idp.Close()
+	identityService := createMockIdentityService(idp)
+
+	// given
+	_, _ = svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    email,
+		Password: dummyPassword,
+	})
+
+	// when
+	_, err := socialCallback(identityService, "")
+
+	// then
+	if err == nil {
+		t.Error("expected an unverified email not to be linked")
+	}
+}
+
+func Test_Social_Login_Does_Not_Link_Unverified_Local_User(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	email := util.RandomEmailAddress()
+	idp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          email,
+		"email_verified": true,
+	})
+	defer idp.Close()
+	identityService := createMockIdentityService(idp)
+
+	// given
+	_, _ = svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    email,
+		Password: dummyPassword,
+	})
+
+	// when
+	_, err := socialCallback(identityService, "")
+
+	// then
+	if err == nil {
+		t.Error("expected an account that never verified the email not to be linked")
+	}
+}
+
+func Test_Social_Callback_Requires_The_Nonce(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	idp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          util.RandomEmailAddress(),
+		"email_verified": true,
+	})
+	defer idp.Close()
+	identityService := createMockIdentityService(idp)
+
+	// given
+	invite, _ := svc.CreateInvite()
+	authorization, _ := identityService.Authorize("mock", invite.Code)
+
+	// when
+	_, err := identityService.Callback(nil, "mock", &model.SocialCallback{
+		Code:  "mock-code",
+		State: authorization.State,
+		Nonce: "another browser",
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected a callback from another browser to be rejected")
+	}
+}
+
+func Test_Cannot_Unlink_Only_Way_To_Sign_In(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	idp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          util.RandomEmailAddress(),
+		"email_verified": true,
+	})
+	defer idp.Close()
+	identityService := createMockIdentityService(idp)
+
+	// given
+	invite, _ := svc.CreateInvite()
+	session, _ := socialCallback(identityService, invite.Code)
+	identities, _ := identityService.GetLinkedIdentities(session)
+
+	// when
+	err := identityService.Unlink(session, uuid.MustParse(identities[0].Uuid))
+
+	// then
+	if err == nil {
+		t.Error("expected the last identity of a user without a password to stay linked")
+	}
+}
+
+func Test_Social_User_Reauthenticates_With_Linked_Identity(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	idp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          util.RandomEmailAddress(),
+		"email_verified": true,
+	})
+	defer idp.Close()
+	identityService := createMockIdentityService(idp)
+	otherIdp := startMockIdp(map[string]interface{}{
+		"sub":            util.RandomUsername(),
+		"email":          util.RandomEmailAddress(),
+		"email_verified": true,
+	})
+	defer otherIdp.Close()
+	otherIdentityService := createMockIdentityService(otherIdp)
+
+	// given
+	invite, _ := svc.CreateInvite()
+	signUp, err := socialCallback(identityService, invite.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authTime := time.Now().Add(-time.Hour)
+	stale := *signUp
+	stale.AuthTime = &authTime
+
+	// when
+	_, linkErr := socialCallbackWithSession(otherIdentityService, &stale, "")
+
+	// then
+	if _, ok := linkErr.(*util.ReauthRequiredError); !ok {
+		t.Error("expected linking another identity to need a recent authentication")
+	}
+
+	// when
+	reauth, err := socialCallbackWithSession(identityService, &stale, "")
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = util.RequireRecentAuth(reauth, RecentAuthMaxAge); err != nil {
+		t.Error("expected signing in with the linked identity to count as a recent authentication")
+	}
+	_, err = socialCallbackWithSession(otherIdentityService, reauth, "")
+	if err != nil {
+		t.Error(err)
+	}
+}
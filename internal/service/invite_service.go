@@ -0,0 +1,560 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxInviteGrant = 100
+	// maxInviteBatch is the most invites generated at once.
+	maxInviteBatch    = 1000
+	maxCampaignLength = 64
+	// maxInviteSends limits how often one email invite can be sent, so
+	// resending can't be used to spam someone.
+	maxInviteSends = 3
+)
+
+// InviteQuotaPolicy is the invite allowance of members in good standing.
+type InviteQuotaPolicy struct {
+	// Monthly is how many invites a member can create each month.
+	Monthly int
+	// MinAccountAge is how old an account must be to get the monthly
+	// allowance. Invites granted by an admin can be used right away.
+	MinAccountAge time.Duration
+}
+
+// LoadInviteQuotaPolicy reads INVITE_QUOTA_MONTHLY and
+// INVITE_QUOTA_MIN_ACCOUNT_AGE_DAYS, falling back to 3 invites a month for
+// accounts older than two weeks.
+func LoadInviteQuotaPolicy() *InviteQuotaPolicy {
+	policy := &InviteQuotaPolicy{3, 14 * 24 * time.Hour}
+	if monthly, err := strconv.Atoi(os.Getenv("INVITE_QUOTA_MONTHLY")); err == nil {
+		policy.Monthly = monthly
+	}
+	if days, err := strconv.Atoi(os.Getenv("INVITE_QUOTA_MIN_ACCOUNT_AGE_DAYS")); err == nil {
+		policy.MinAccountAge = time.Duration(days) * 24 * time.Hour
+	}
+	return policy
+}
+
+type InviteService struct {
+	inviteRepository      *repository.InviteRepository
+	inviteGrantRepository *repository.InviteGrantRepository
+	inviteBatchRepository *repository.InviteBatchRepository
+	userRepository        *repository.UserRepository
+	securityService       *SecurityService
+	userService           *UserService
+	quotaPolicy           *InviteQuotaPolicy
+}
+
+func CreateInviteService() *InviteService {
+	conn := db.CreateDefaultConnection()
+	return &InviteService{
+		repository.CreateInviteRepository(conn),
+		repository.CreateInviteGrantRepository(conn),
+		repository.CreateInviteBatchRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateSecurityService(),
+		CreateUserService(),
+		LoadInviteQuotaPolicy(),
+	}
+}
+
+func CreateTestInviteService() *InviteService {
+	conn := util.SetupTestDatabase()
+	return &InviteService{
+		repository.CreateInviteRepository(conn),
+		repository.CreateInviteGrantRepository(conn),
+		repository.CreateInviteBatchRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateTestSecurityService(),
+		CreateTestUserService(),
+		&InviteQuotaPolicy{2, 0},
+	}
+}
+
+// CreateInvite creates an invite for the session user. Moderators create as
+// many as they like, other members spend their quota on single-use invites.
+func (s *InviteService) CreateInvite(session *model.Session, code string, newInvite *model.NewInvite) (*model.Invite, error) {
+	if s.securityService.Can(session, model.PermissionInviteCreate, nil) {
+		return s.userService.CreateInviteFromCode(session, code, newInvite)
+	}
+	creator, err := s.getMember(session)
+	if err != nil {
+		return nil, err
+	}
+	if newInvite.MaxUses > 1 {
+		return nil, util.NewInputFieldError(
+			"maxUses",
+			"only moderators can create invites for more than one person",
+		)
+	}
+	invite, err := buildInvite(creator, code, newInvite)
+	if err != nil {
+		return nil, err
+	}
+	err = s.inviteRepository.CreateWithinQuota(
+		invite,
+		startOfMonth(time.Now()),
+		s.getMonthlyAllowance(creator),
+		s.inviteGrantRepository.SumForUser(creator),
+	)
+	if err == repository.ErrInviteQuotaExceeded {
+		return nil, util.NewInputFieldError(
+			"invite",
+			"you have no invites left",
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapInviteEntityToModel(invite), nil
+}
+
+// SendEmailInvite creates an invite locked to the recipient's address and
+// emails it to them. It spends the sender's quota like any other invite.
+func (s *InviteService) SendEmailInvite(session *model.Session, code string, emailInvite *model.EmailInvite) (*model.Invite, error) {
+	emailInvite.Email = strings.TrimSpace(emailInvite.Email)
+	if !strings.Contains(emailInvite.Email, "@") {
+		return nil, util.NewInputFieldError(
+			"email",
+			"a valid email address is required",
+		)
+	}
+	if search, _ := s.userRepository.GetUserFromEmail(emailInvite.Email); search != nil {
+		return nil, util.NewInputFieldError(
+			"email",
+			"this email address already has an account",
+		)
+	}
+	inviter, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.CreateInvite(session, code, &model.NewInvite{Email: emailInvite.Email})
+	if err != nil {
+		return nil, err
+	}
+	invite, err := s.inviteRepository.FindOneByCode(created.Code)
+	if err != nil {
+		return nil, err
+	}
+	err = s.sendInvite(inviter, invite, emailInvite)
+	if err != nil {
+		// give the quota back, the recipient never got the invite
+		s.inviteRepository.Delete(invite)
+		return nil, err
+	}
+	return mapper.MapInviteEntityToModel(invite), nil
+}
+
+// ResendEmailInvite sends a pending email invite again, with a new tracking
+// link. Only the inviter and moderators can resend.
+func (s *InviteService) ResendEmailInvite(session *model.Session, code string) (*model.Invite, error) {
+	invite, err := s.inviteRepository.FindOneByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if !s.userService.isInviteCreator(session, invite) && !s.securityService.Can(session, model.PermissionInviteCreate, nil) {
+		return nil, errors.New("not allowed")
+	}
+	if !invite.IsPending() || invite.Revoked || invite.IsExpired() {
+		return nil, util.NewInputFieldError(
+			"invite",
+			"only pending email invites can be resent",
+		)
+	}
+	if invite.SendCount >= maxInviteSends {
+		return nil, util.NewInputFieldError(
+			"invite",
+			"this invite has been sent too many times",
+		)
+	}
+	inviter, err := s.userRepository.GetUserFromId(*invite.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.sendInvite(inviter, invite, &model.EmailInvite{Email: invite.Email})
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapInviteEntityToModel(invite), nil
+}
+
+// TrackEmailInvite records that the recipient opened an email invite or
+// clicked its link, and returns the sign-up link for the invite.
+func (s *InviteService) TrackEmailInvite(trackingToken string, status enum.InviteStatusType) (string, error) {
+	invite, err := s.inviteRepository.FindOneByTrackingTokenHash(util.HashSecret(trackingToken))
+	if err != nil {
+		return "", err
+	}
+	invite.Track(status)
+	s.inviteRepository.Save(invite)
+	return s.userService.mailService.CreateInviteSignUpLink(invite), nil
+}
+
+func (s *InviteService) sendInvite(inviter *entity.User, invite *entity.Invite, emailInvite *model.EmailInvite) error {
+	if s.userService.emailRepository.IsUndeliverable(invite.Email) {
+		return util.NewInputFieldError(
+			"email",
+			"we can't send email to this address",
+		)
+	}
+	trackingToken, err := util.GenerateSecret(24)
+	if err != nil {
+		return err
+	}
+	email, err := s.userService.mailService.CreateInviteEmail(inviter, invite, emailInvite, trackingToken)
+	if err != nil {
+		log.Print("error rendering invite email :: ", err)
+		return errors.New("error sending invite email")
+	}
+	now := time.Now()
+	invite.TrackingTokenHash = util.HashSecret(trackingToken)
+	invite.Status = enum.InviteStatusSent
+	invite.SentAt = &now
+	invite.SendCount++
+	return s.userService.outboxRepository.SaveWithEmail(invite, email)
+}
+
+// CreateInviteBatch generates count unique invites sharing the same limits,
+// like codes printed for a meetup. The batch is saved in one transaction, so
+// it is complete or not created at all.
+func (s *InviteService) CreateInviteBatch(session *model.Session, newBatch *model.NewInviteBatch) (*model.InviteBatch, error) {
+	if !s.securityService.Can(session, model.PermissionInviteBulk, nil) {
+		return nil, errors.New("not allowed")
+	}
+	if newBatch.Count < 1 || newBatch.Count > maxInviteBatch {
+		return nil, util.NewInputFieldError(
+			"count",
+			fmt.Sprintf("batches must be between 1 and %d invites", maxInviteBatch),
+		)
+	}
+	campaign := strings.TrimSpace(newBatch.Campaign)
+	if len(campaign) > maxCampaignLength {
+		return nil, util.NewInputFieldError(
+			"campaign",
+			"campaigns can be at most 64 characters",
+		)
+	}
+	creator, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	template, err := buildInvite(creator, "", &model.NewInvite{
+		ExpiresAt: newBatch.ExpiresAt,
+		MaxUses:   newBatch.MaxUses,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt <= 5; attempt++ {
+		codes, err := s.generateCodes(newBatch.Count)
+		if err != nil {
+			return nil, err
+		}
+		batch := &entity.InviteBatch{
+			CreatorID: creator.ID,
+			Campaign:  campaign,
+			Count:     len(codes),
+			ExpiresAt: template.ExpiresAt,
+			MaxUses:   template.MaxUses,
+		}
+		invites := make([]*entity.Invite, len(codes))
+		for i, code := range codes {
+			invites[i] = &entity.Invite{
+				Code:      code,
+				CreatorID: &creator.ID,
+				Campaign:  campaign,
+				ExpiresAt: template.ExpiresAt,
+				MaxUses:   template.MaxUses,
+			}
+		}
+		// someone else can take a code between checking and saving, which
+		// fails the whole batch
+		err = s.inviteBatchRepository.Create(batch, invites)
+		if err == nil {
+			return mapper.MapInviteBatchEntityToModel(batch, invites), nil
+		}
+		log.Print("error creating invite batch :: ", err)
+	}
+	return nil, errors.New("error creating invite batch")
+}
+
+// GetInviteBatch returns a batch with all of its invites.
+func (s *InviteService) GetInviteBatch(session *model.Session, batchUuid uuid.UUID) (*model.InviteBatch, error) {
+	if !s.securityService.Can(session, model.PermissionInviteBulk, nil) {
+		return nil, errors.New("not allowed")
+	}
+	batch, err := s.inviteBatchRepository.FindOneByUuid(batchUuid)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapInviteBatchEntityToModel(batch, s.inviteBatchRepository.FindInvites(batch)), nil
+}
+
+// GetCampaignStats counts how the invites tagged with the campaign were
+// used, across every batch.
+func (s *InviteService) GetCampaignStats(session *model.Session, campaign string) (*model.CampaignStats, error) {
+	if !s.securityService.Can(session, model.PermissionInviteList, nil) {
+		return nil, errors.New("not allowed")
+	}
+	stats := s.inviteRepository.CountForCampaign(campaign)
+	return &model.CampaignStats{
+		Campaign: campaign,
+		Codes:    stats.Codes,
+		Redeemed: stats.Redeemed,
+		Uses:     stats.Uses,
+		Expired:  stats.Expired,
+		Revoked:  stats.Revoked,
+	}, nil
+}
+
+// GetInviteQuota returns how many invites the session user has left.
+func (s *InviteService) GetInviteQuota(session *model.Session) (*model.InviteQuota, error) {
+	if s.securityService.Can(session, model.PermissionInviteCreate, nil) {
+		return &model.InviteQuota{
+			Unlimited: true,
+			ResetsAt:  startOfMonth(time.Now()).AddDate(0, 1, 0),
+		}, nil
+	}
+	user, err := s.getMember(session)
+	if err != nil {
+		return nil, err
+	}
+	return s.getQuota(user), nil
+}
+
+// GetOwnInvites lists the invites the session user created.
+func (s *InviteService) GetOwnInvites(session *model.Session, offset int) ([]*model.Invite, error) {
+	user, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapInviteEntitiesToModels(s.inviteRepository.FindForCreator(user.ID, offset)), nil
+}
+
+// GrantInvites gives the user extra invites on top of their allowance.
+func (s *InviteService) GrantInvites(session *model.Session, username string, newGrant *model.NewInviteGrant) (*model.InviteQuota, error) {
+	if !s.securityService.Can(session, model.PermissionInviteGrant, nil) {
+		return nil, errors.New("not allowed")
+	}
+	if newGrant.Amount < 1 || newGrant.Amount > maxInviteGrant {
+		return nil, util.NewInputFieldError(
+			"amount",
+			fmt.Sprintf("grants must be between 1 and %d invites", maxInviteGrant),
+		)
+	}
+	admin, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepository.GetUserFromUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	result := s.inviteGrantRepository.Create(&entity.InviteGrant{
+		UserID:      user.ID,
+		Amount:      newGrant.Amount,
+		Reason:      newGrant.Reason,
+		GrantedByID: admin.ID,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return s.getQuota(user), nil
+}
+
+// GetInvitees lists the users who signed up with the user's invites. Users
+// can see their own invitees, moderators can see anyone's.
+func (s *InviteService) GetInvitees(session *model.Session, username string, offset int) ([]*model.User, error) {
+	user, err := s.userRepository.GetUserFromUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if !s.isSelf(session, user) && !s.securityService.Can(session, model.PermissionInviteList, nil) {
+		return nil, errors.New("not allowed")
+	}
+	invitees := s.userRepository.FindInvitees(user, offset)
+	return filterUserModelsForAccess(
+		session,
+		s.userService.canReadPii(session),
+		mapper.MapUserEntitiesToModels(invitees),
+	), nil
+}
+
+// GetInviteTree returns everyone the user let in, directly or through the
+// people they invited, to trace abuse waves back to their source.
+func (s *InviteService) GetInviteTree(session *model.Session, username string) (*model.InviteTree, error) {
+	if !s.securityService.Can(session, model.PermissionInviteAudit, nil) {
+		return nil, errors.New("not allowed")
+	}
+	user, err := s.userRepository.GetUserFromUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	edges, users, err := s.userRepository.FindInviteTree(user)
+	if err != nil {
+		return nil, err
+	}
+	nodes := map[uint]*model.InviteTree{
+		user.ID: {User: mapper.MapUserEntityToModel(user), Invitees: []*model.InviteTree{}},
+	}
+	for _, invitee := range users {
+		nodes[invitee.ID] = &model.InviteTree{
+			User:     mapper.MapUserEntityToModel(invitee),
+			Invitees: []*model.InviteTree{},
+		}
+	}
+	for _, edge := range edges {
+		parent, ok := nodes[edge.InviterID]
+		child, found := nodes[edge.UserID]
+		if ok && found {
+			parent.Invitees = append(parent.Invitees, child)
+		}
+	}
+	return nodes[user.ID], nil
+}
+
+// BanUserTree bans the user along with everyone in their invite tree.
+func (s *InviteService) BanUserTree(session *model.Session, username string) (*model.TreeBan, error) {
+	user, err := s.userRepository.GetUserFromUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if !s.securityService.Can(session, model.PermissionUserBan, user) {
+		return nil, errors.New("cannot ban user")
+	}
+	_, invitees, err := s.userRepository.FindInviteTree(user)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.TreeBan{Banned: []string{}, Skipped: []string{}}
+	for _, target := range append([]*entity.User{user}, invitees...) {
+		if target.IsBanned {
+			continue
+		}
+		if s.userService.BanUser(session, target) != nil {
+			result.Skipped = append(result.Skipped, target.Username)
+			continue
+		}
+		result.Banned = append(result.Banned, target.Username)
+	}
+	return result, nil
+}
+
+// getMember finds the session user if they are a member in good standing
+// who can spend invites. Tokens with scopes, like access tokens, and
+// impersonated sessions can't.
+func (s *InviteService) getMember(session *model.Session) (*entity.User, error) {
+	if session == nil || session.Scopes != nil || session.IsImpersonated() {
+		return nil, errors.New("not allowed")
+	}
+	user, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBanned || !user.Verified || user.IsServiceAccount {
+		return nil, errors.New("not allowed")
+	}
+	return user, nil
+}
+
+func (s *InviteService) getQuota(user *entity.User) *model.InviteQuota {
+	month := startOfMonth(time.Now())
+	usage := s.inviteRepository.CountForCreator(user.ID, month)
+	quota := &model.InviteQuota{
+		Monthly:     s.getMonthlyAllowance(user),
+		MonthlyUsed: usage.Monthly,
+		Granted:     s.inviteGrantRepository.SumForUser(user),
+		GrantedUsed: usage.Granted,
+		ResetsAt:    month.AddDate(0, 1, 0),
+	}
+	quota.Remaining = unused(quota.Monthly, quota.MonthlyUsed) + unused(quota.Granted, quota.GrantedUsed)
+	return quota
+}
+
+func (s *InviteService) getMonthlyAllowance(user *entity.User) int {
+	if time.Since(user.CreatedAt) < s.quotaPolicy.MinAccountAge {
+		return 0
+	}
+	return s.quotaPolicy.Monthly
+}
+
+// generateCode finds an invite code that isn't taken yet.
+func (s *InviteService) generateCode() (string, error) {
+	for attempt := 0; attempt <= 5; attempt++ {
+		code := util.GenerateCode()
+		if _, err := s.inviteRepository.FindOneByCode(code); err != nil {
+			return code, nil
+		}
+	}
+	return "", errors.New("error generating invite code")
+}
+
+// generateCodes finds count distinct codes that aren't taken yet, checking
+// them against existing invites in bulk.
+func (s *InviteService) generateCodes(count int) ([]string, error) {
+	codes := map[string]bool{}
+	for attempt := 0; attempt <= 5 && len(codes) < count; attempt++ {
+		var candidates []string
+		seen := map[string]bool{}
+		for len(codes)+len(candidates) < count {
+			code := util.GenerateCode()
+			if !codes[code] && !seen[code] {
+				seen[code] = true
+				candidates = append(candidates, code)
+			}
+		}
+		for _, taken := range s.inviteBatchRepository.FindTakenCodes(candidates) {
+			delete(seen, taken)
+		}
+		for code := range seen {
+			codes[code] = true
+		}
+	}
+	if len(codes) < count {
+		return nil, errors.New("error generating invite codes")
+	}
+	result := make([]string, 0, len(codes))
+	for code := range codes {
+		result = append(result, code)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func unused(allowed int, used int) int {
+	if used > allowed {
+		return 0
+	}
+	return allowed - used
+}
+
+func (s *InviteService) isSelf(session *model.Session, user *entity.User) bool {
+	if session == nil || session.User == nil {
+		return false
+	}
+	sessionUuid, err := uuid.Parse(session.User.Uuid)
+	return err == nil && sessionUuid == user.Uuid
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"strings"
+	"testing"
+)
+
+func createInvitee(svc *TestService, inviter *entity.User) *entity.User {
+	invite := &entity.Invite{Code: util.GenerateCode(), MaxUses: 1, CreatorID: &inviter.ID}
+	svc.inviteRepository.Create(invite)
+	emailAddr := util.RandomEmailAddress()
+	_, _ = svc.CreateUser(&model.Invite{Code: invite.Code}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	invitee, _ := svc.userRepository.GetUserFromEmail(emailAddr)
+	return invitee
+}
+
+func Test_User_Can_See_Their_Invitees(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	inviter, session := svc.CreateUserWithRole(model.USER)
+	invitee := createInvitee(svc, inviter)
+	_, otherSession := svc.CreateUserWithRole(model.USER)
+
+	// when
+	invitees, err := inviteService.GetInvitees(session, inviter.Username, 0)
+	_, otherErr := inviteService.GetInvitees(otherSession, inviter.Username, 0)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invitees) != 1 || invitees[0].Uuid != invitee.Uuid.String() {
+		t.Error("expected the invitee to be listed")
+	}
+	if otherErr == nil {
+		t.Error("expected other users not to see the invitees")
+	}
+}
+
+func Test_Invite_Tree_Walks_Every_Level(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	root, _ := svc.CreateUserWithRole(model.USER)
+	child := createInvitee(svc, root)
+	grandchild := createInvitee(svc, child)
+
+	// when
+	tree, err := inviteService.GetInviteTree(adminSession, root.Username)
+	_, moderatorErr := inviteService.GetInviteTree(moderatorSession, root.Username)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Invitees) != 1 || tree.Invitees[0].User.Uuid != child.Uuid.String() {
+		t.Fatal("expected the child in the tree")
+	}
+	if len(tree.Invitees[0].Invitees) != 1 || tree.Invitees[0].Invitees[0].User.Uuid != grandchild.Uuid.String() {
+		t.Error("expected the grandchild in the tree")
+	}
+	if moderatorErr == nil {
+		t.Error("expected the tree to be limited to admins")
+	}
+}
+
+func Test_Moderator_Can_Ban_Invite_Tree(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	root, _ := svc.CreateUserWithRole(model.USER)
+	child := createInvitee(svc, root)
+	grandchild := createInvitee(svc, child)
+
+	// when
+	result, err := inviteService.BanUserTree(moderatorSession, root.Username)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Banned) != 3 {
+		t.Error("expected three users to be banned, got :: ", result.Banned)
+	}
+	for _, user := range []*entity.User{root, child, grandchild} {
+		banned, _ := svc.userRepository.GetUserFromUuid(user.Uuid)
+		if !banned.IsBanned {
+			t.Error("expected user to be banned :: ", user.Username)
+		}
+	}
+}
+
+func Test_Member_Can_Spend_Monthly_Invites(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, session := svc.CreateVerifiedUserWithRole(model.USER)
+
+	// when
+	var errs []error
+	for i := 0; i < 3; i++ {
+		_, err := inviteService.CreateInvite(session, util.GenerateCode(), &model.NewInvite{})
+		errs = append(errs, err)
+	}
+
+	// then
+	if errs[0] != nil || errs[1] != nil {
+		t.Error("expected the monthly allowance to be spent")
+	}
+	if errs[2] == nil {
+		t.Error("expected the third invite to be over quota")
+	}
+	quota, _ := inviteService.GetInviteQuota(session)
+	if quota.Remaining != 0 || quota.MonthlyUsed != 2 {
+		t.Error("expected no invites left")
+	}
+	invites, _ := inviteService.GetOwnInvites(session, 0)
+	if len(invites) != 2 {
+		t.Error("expected the member to see their invites")
+	}
+}
+
+func Test_Member_Cannot_Create_Community_Invites(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, session := svc.CreateVerifiedUserWithRole(model.USER)
+
+	// when
+	_, err := inviteService.CreateInvite(session, util.GenerateCode(), &model.NewInvite{
+		MaxUses: 10,
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected multi-use invites to be limited to moderators")
+	}
+}
+
+func Test_Admin_Can_Grant_Extra_Invites(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	user, session := svc.CreateVerifiedUserWithRole(model.USER)
+
+	// when
+	_, moderatorErr := inviteService.GrantInvites(moderatorSession, user.Username, &model.NewInviteGrant{Amount: 1})
+	quota, err := inviteService.GrantInvites(adminSession, user.Username, &model.NewInviteGrant{
+		Amount: 1,
+		Reason: "helped with the launch",
+	})
+
+	// then
+	if moderatorErr == nil {
+		t.Error("expected grants to be limited to admins")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota.Granted != 1 || quota.Remaining != 3 {
+		t.Error("expected the grant to add to the quota")
+	}
+	for i := 0; i < 3; i++ {
+		if _, err = inviteService.CreateInvite(session, util.GenerateCode(), &model.NewInvite{}); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func Test_Email_Invite_Is_Tracked_Until_Accepted(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+	emailAddr := util.RandomEmailAddress()
+
+	// given
+	_, session := svc.CreateVerifiedUserWithRole(model.USER)
+	sent, err := inviteService.SendEmailInvite(session, util.GenerateCode(), &model.EmailInvite{
+		Email: emailAddr,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invite, _ := svc.inviteRepository.FindOneByCode(sent.Code)
+	invite.TrackingTokenHash = util.HashSecret("tracking-token")
+	svc.inviteRepository.Save(invite)
+
+	// when
+	link, err := inviteService.TrackEmailInvite("tracking-token", enum.InviteStatusClicked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = svc.CreateUser(&model.Invite{Code: sent.Code}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if sent.Status != string(enum.InviteStatusSent) || sent.Email != emailAddr {
+		t.Error("expected the invite to be sent to the address")
+	}
+	if !strings.Contains(link, sent.Code) {
+		t.Error("expected the link to fill in the code")
+	}
+	accepted, _ := svc.userService.GetInvite(sent.Code)
+	if accepted.Status != string(enum.InviteStatusAccepted) || accepted.ClickedAt == nil || accepted.AcceptedAt == nil {
+		t.Error("expected the invite to be clicked and accepted")
+	}
+}
+
+func Test_Email_Invite_Can_Be_Resent_And_Cancelled(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, session := svc.CreateVerifiedUserWithRole(model.USER)
+	_, otherSession := svc.CreateVerifiedUserWithRole(model.USER)
+	sent, _ := inviteService.SendEmailInvite(session, util.GenerateCode(), &model.EmailInvite{
+		Email: util.RandomEmailAddress(),
+	})
+
+	// when
+	resent, err := inviteService.ResendEmailInvite(session, sent.Code)
+	otherErr := svc.userService.RevokeInvite(otherSession, sent.Code)
+	cancelErr := svc.userService.RevokeInvite(session, sent.Code)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherErr == nil {
+		t.Error("expected other members not to cancel the invite")
+	}
+	if cancelErr != nil {
+		t.Error(cancelErr)
+	}
+	cancelled, _ := svc.inviteRepository.FindOneByCode(sent.Code)
+	if resent.SentAt == nil || cancelled.SendCount != 2 {
+		t.Error("expected the invite to be sent twice")
+	}
+	if cancelled.Status != enum.InviteStatusCancelled || !cancelled.Revoked {
+		t.Error("expected the invite to be cancelled")
+	}
+	if _, err = inviteService.ResendEmailInvite(session, sent.Code); err == nil {
+		t.Error("expected cancelled invites not to be resent")
+	}
+}
+
+func Test_Email_Invite_Rejects_Registered_Address(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	user, session := svc.CreateVerifiedUserWithRole(model.USER)
+
+	// when
+	_, err := inviteService.SendEmailInvite(session, util.GenerateCode(), &model.EmailInvite{
+		Email: user.Email,
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected registered addresses to be rejected")
+	}
+}
+
+func Test_Invite_Batch_Generates_Unique_Codes(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	campaign := "meetup-" + util.GenerateCode()
+
+	// when
+	batch, err := inviteService.CreateInviteBatch(adminSession, &model.NewInviteBatch{
+		Count:    50,
+		Campaign: campaign,
+		MaxUses:  2,
+	})
+	_, moderatorErr := inviteService.CreateInviteBatch(moderatorSession, &model.NewInviteBatch{Count: 1})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := map[string]bool{}
+	for _, invite := range batch.Invites {
+		if invite.Campaign != campaign || invite.MaxUses != 2 {
+			t.Error("expected every invite to share the batch's limits")
+		}
+		if len(invite.Code) != 19 {
+			t.Errorf("expected a long batch code, got %s", invite.Code)
+		}
+		codes[invite.Code] = true
+	}
+	if len(codes) != 50 {
+		t.Errorf("expected 50 unique codes, got %d", len(codes))
+	}
+	batchUuid, _ := uuid.Parse(batch.Uuid)
+	saved, err := inviteService.GetInviteBatch(adminSession, batchUuid)
+	if err != nil || len(saved.Invites) != 50 {
+		t.Error("expected the batch to be saved with its invites")
+	}
+	if moderatorErr == nil {
+		t.Error("expected moderators not to generate batches")
+	}
+}
+
+func Test_Campaign_Stats_Count_Redeemed_Codes(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	inviteService := CreateTestInviteService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	campaign := "meetup-" + util.GenerateCode()
+	batch, _ := inviteService.CreateInviteBatch(adminSession, &model.NewInviteBatch{
+		Count:    3,
+		Campaign: campaign,
+	})
+	_, _ = svc.CreateUser(&model.Invite{Code: batch.Invites[0].Code}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		Password: dummyPassword,
+	})
+
+	// when
+	stats, err := inviteService.GetCampaignStats(adminSession, campaign)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Codes != 3 || stats.Redeemed != 1 || stats.Uses != 1 {
+		t.Errorf("unexpected campaign stats %+v", stats)
+	}
+}
@@ -1,75 +1,270 @@
 package service
 
 import (
-	"github.com/sendgrid/rest"
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/third-place/user-service/config"
 	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mailer"
+	"github.com/third-place/user-service/internal/mailtemplate"
+	"github.com/third-place/user-service/internal/model"
+	"io/fs"
+	"log"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
-type MailClient interface {
-	Send(email *mail.SGMailV3) (*rest.Response, error)
+type MailService struct {
+	transport mailer.Transport
+	// apiUrl is where links that the service handles itself point, like
+	// invite tracking.
+	apiUrl string
+	// appUrl is where links to pages of the app point, like signing up or
+	// resetting a password.
+	appUrl    string
+	templates *mailtemplate.Renderer
 }
 
-type TestMailClient struct{}
+var fromMail = mailer.Address{Name: "ThirdplaceBot", Email: "info@thirdplaceapp.com"}
+
+const (
+	defaultApiUrl     = "https://thirdplaceapp.com"
+	defaultAppUrl     = "https://thirdplaceapp.com"
+	defaultMailLocale = "en"
+)
 
-func (t *TestMailClient) Send(email *mail.SGMailV3) (*rest.Response, error) {
-	return nil, nil
+// mailTemplates lists every template the service sends. They must all exist
+// in the default locale.
+var mailTemplates = []string{
+	"verification",
+	"password_reset",
+	"new_sign_in",
+	"invite",
+	"waitlist_confirmation",
 }
 
-type MailService struct {
-	client MailClient
+// criticalEmails are the emails people asked for, or need to keep their
+// account safe. They're sent even to addresses that bounced or reported our
+// email as spam, every other email is held back.
+var criticalEmails = map[string]bool{
+	"verification":          true,
+	"password_reset":        true,
+	"new_sign_in":           true,
+	"waitlist_confirmation": true,
 }
 
-var fromMail *mail.Email
+var (
+	templates     *mailtemplate.Renderer
+	templatesErr  error
+	templatesOnce sync.Once
+)
 
-func init() {
-	fromMail = mail.NewEmail("ThirdplaceBot", "info@thirdplaceapp.com")
+// The data each template is rendered with.
+type (
+	codeEmail struct {
+		Name     string
+		Username string
+		Code     string
+		Link     string
+	}
+	newSignInEmail struct {
+		Name       string
+		When       string
+		IpAddress  string
+		Device     string
+		RevokeLink string
+	}
+	inviteEmail struct {
+		InviterName string
+		Message     string
+		Code        string
+		Link        string
+		PixelLink   string
+	}
+	linkEmail struct {
+		Link string
+	}
+)
+
+// LoadMailTemplates reads the email templates from MAIL_TEMPLATE_DIR, or
+// the ones bundled with the service, once. Templates are rendered in the
+// recipient's locale, falling back to MAIL_DEFAULT_LOCALE, which is en by
+// default. Call it at startup to fail fast when a template is missing.
+func LoadMailTemplates() (*mailtemplate.Renderer, error) {
+	templatesOnce.Do(func() {
+		var fsys fs.FS
+		if dir := os.Getenv("MAIL_TEMPLATE_DIR"); dir != "" {
+			fsys = os.DirFS(dir)
+		} else {
+			fsys, templatesErr = fs.Sub(config.Templates, "templates")
+			if templatesErr != nil {
+				return
+			}
+		}
+		locale, ok := os.LookupEnv("MAIL_DEFAULT_LOCALE")
+		if !ok {
+			locale = defaultMailLocale
+		}
+		templates, templatesErr = mailtemplate.Load(fsys, locale, mailTemplates)
+	})
+	return templates, templatesErr
 }
 
 func CreateMailService() *MailService {
+	apiUrl, ok := os.LookupEnv("PUBLIC_API_URL")
+	if !ok {
+		apiUrl = defaultApiUrl
+	}
+	appUrl, ok := os.LookupEnv("PUBLIC_APP_URL")
+	if !ok {
+		appUrl = defaultAppUrl
+	}
+	renderer, err := LoadMailTemplates()
+	if err != nil {
+		log.Fatal("error loading email templates :: ", err)
+	}
 	return &MailService{
-		client: sendgrid.NewSendClient(os.Getenv("SENDGRID_API_KEY")),
+		transport: mailer.CreateTransportFromEnv(),
+		apiUrl:    strings.TrimSuffix(apiUrl, "/"),
+		appUrl:    strings.TrimSuffix(appUrl, "/"),
+		templates: renderer,
 	}
 }
 
 func CreateTestMailService() *MailService {
+	renderer, err := LoadMailTemplates()
+	if err != nil {
+		log.Fatal("error loading email templates :: ", err)
+	}
 	return &MailService{
-		client: &TestMailClient{},
-	}
-}
-
-func (m *MailService) SendVerificationEmail(user *entity.User) (*rest.Response, error) {
-	name := m.getSenderName(user)
-	link := m.createVerifyLink(user)
-	return m.client.Send(
-		mail.NewSingleEmail(
-			fromMail,
-			"Third place: email verification",
-			mail.NewEmail(name, user.Email),
-			"Your Third place verification code is "+user.OTP+"\n"+
-				"Copy and paste the link to verify your email address now: "+link,
-			"<p>Your Third place verification code is "+user.OTP+"</p>"+
-				"<p><a href=\""+link+"\">Click here to verify your email address</a></p>",
-		),
-	)
-}
-
-func (m *MailService) SendPasswordResetEmail(user *entity.User) (*rest.Response, error) {
-	name := m.getSenderName(user)
-	link := m.createPasswordResetLink(user)
-	return m.client.Send(
-		mail.NewSingleEmail(
-			fromMail,
-			"Third place: password reset request",
-			mail.NewEmail(name, user.Email),
-			"Your Third place verification code is "+user.OTP+"\n"+
-				"Copy and paste the link to verify your email address now: "+link,
-			"<p>Your Third place verification code is "+user.OTP+"</p>"+
-				"<p><a href=\""+link+"\">Click here to reset your password</a></p>",
-		),
-	)
+		transport: mailer.NewMemoryTransport(),
+		apiUrl:    defaultApiUrl,
+		appUrl:    defaultAppUrl,
+		templates: renderer,
+	}
+}
+
+// CreateVerificationEmail renders the email with the user's verification
+// code, to be queued in the outbox.
+func (m *MailService) CreateVerificationEmail(user *entity.User) (*entity.OutboxEmail, error) {
+	return m.createOutboxEmail(user, "verification", &codeEmail{
+		Name:     user.Name,
+		Username: user.Username,
+		Code:     user.OTP,
+		Link:     m.createVerifyLink(user),
+	})
+}
+
+func (m *MailService) CreatePasswordResetEmail(user *entity.User) (*entity.OutboxEmail, error) {
+	return m.createOutboxEmail(user, "password_reset", &codeEmail{
+		Name:     user.Name,
+		Username: user.Username,
+		Code:     user.OTP,
+		Link:     m.createPasswordResetLink(user),
+	})
+}
+
+func (m *MailService) CreateNewSignInEmail(user *entity.User, attempt *entity.LoginAttempt, revokeCode string) (*entity.OutboxEmail, error) {
+	when := attempt.CreatedAt
+	if when.IsZero() {
+		when = time.Now()
+	}
+	return m.createOutboxEmail(user, "new_sign_in", &newSignInEmail{
+		Name:       user.Name,
+		When:       when.UTC().Format("January 2, 2006 at 15:04 UTC"),
+		IpAddress:  attempt.IpAddress,
+		Device:     attempt.UserAgent,
+		RevokeLink: m.createRevokeSessionsLink(revokeCode),
+	})
+}
+
+// Deliver sends an email from the outbox.
+func (m *MailService) Deliver(email *entity.OutboxEmail) error {
+	return m.transport.Send(&mailer.Message{
+		From:    fromMail,
+		To:      mailer.Address{Name: email.ToName, Email: email.ToEmail},
+		Subject: email.Subject,
+		Text:    email.Text,
+		Html:    email.Html,
+	})
+}
+
+// CreateInviteEmail renders an email invite, to be queued in the outbox.
+func (m *MailService) CreateInviteEmail(inviter *entity.User, invite *entity.Invite, emailInvite *model.EmailInvite, trackingToken string) (*entity.OutboxEmail, error) {
+	inviterName := inviter.Name
+	if inviterName == "" {
+		inviterName = inviter.Username
+	}
+	locale := emailInvite.Locale
+	if locale == "" {
+		locale = inviter.Locale
+	}
+	return m.renderOutboxEmail(nil, mailer.Address{Name: emailInvite.Name, Email: invite.Email}, "invite", locale, &inviteEmail{
+		InviterName: inviterName,
+		Message:     emailInvite.Message,
+		Code:        invite.Code,
+		Link:        m.createInviteTrackingLink(trackingToken, "click"),
+		PixelLink:   m.createInviteTrackingLink(trackingToken, "open"),
+	})
+}
+
+// CreateWaitlistConfirmationEmail renders the email with the link that
+// confirms a waitlist entry, to be queued in the outbox.
+func (m *MailService) CreateWaitlistConfirmationEmail(entry *entity.WaitlistEntry, code string) (*entity.OutboxEmail, error) {
+	return m.renderOutboxEmail(nil, mailer.Address{Name: "", Email: entry.Email}, "waitlist_confirmation", entry.Locale, &linkEmail{
+		Link: m.createWaitlistConfirmLink(code),
+	})
+}
+
+func (m *MailService) createOutboxEmail(user *entity.User, template string, data interface{}) (*entity.OutboxEmail, error) {
+	to := mailer.Address{Name: m.getSenderName(user), Email: user.Email}
+	return m.renderOutboxEmail(&user.ID, to, template, user.Locale, data)
+}
+
+// renderOutboxEmail renders an email for the outbox. userID points at the
+// recipient's ID when they have an account, so it can be taken before the
+// user is saved.
+func (m *MailService) renderOutboxEmail(userID *uint, to mailer.Address, template string, locale string, data interface{}) (*entity.OutboxEmail, error) {
+	message, err := m.render(to, template, locale, data)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.OutboxEmail{
+		UserID:        userID,
+		Template:      template,
+		ToName:        to.Name,
+		ToEmail:       to.Email,
+		Subject:       message.Subject,
+		Text:          message.Text,
+		Html:          message.Html,
+		Status:        enum.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+func (m *MailService) render(to mailer.Address, template string, locale string, data interface{}) (*mailer.Message, error) {
+	email, err := m.templates.Render(template, locale, data)
+	if err != nil {
+		return nil, err
+	}
+	return &mailer.Message{
+		From:    fromMail,
+		To:      to,
+		Subject: email.Subject,
+		Text:    email.Text,
+		Html:    email.Html,
+	}, nil
+}
+
+func isCriticalEmail(template string) bool {
+	return criticalEmails[template]
+}
+
+// CreateInviteSignUpLink is the sign-up page with the invite code filled in.
+func (m *MailService) CreateInviteSignUpLink(invite *entity.Invite) string {
+	return m.appUrl + "/signup/?invite=" + url.QueryEscape(invite.Code) + "&email=" + url.QueryEscape(invite.Email)
 }
 
 func (m *MailService) getSenderName(user *entity.User) string {
@@ -81,9 +276,21 @@ func (m *MailService) getSenderName(user *entity.User) string {
 }
 
 func (m *MailService) createVerifyLink(user *entity.User) string {
-	return "https://thirdplaceapp.com/otp/?email=" + user.Email + "&code=" + user.OTP
+	return m.appUrl + "/otp/?email=" + url.QueryEscape(user.Email) + "&code=" + url.QueryEscape(user.OTP)
 }
 
 func (m *MailService) createPasswordResetLink(user *entity.User) string {
-	return "https://thirdplaceapp.com/forgot-password/?email=" + user.Email + "&code=" + user.OTP
+	return m.appUrl + "/forgot-password/?email=" + url.QueryEscape(user.Email) + "&code=" + url.QueryEscape(user.OTP)
+}
+
+func (m *MailService) createRevokeSessionsLink(revokeCode string) string {
+	return m.appUrl + "/session/revoke/?code=" + url.QueryEscape(revokeCode)
+}
+
+func (m *MailService) createWaitlistConfirmLink(code string) string {
+	return m.appUrl + "/waitlist/confirm/?code=" + url.QueryEscape(code)
+}
+
+func (m *MailService) createInviteTrackingLink(trackingToken string, event string) string {
+	return m.apiUrl + "/invite/track/" + trackingToken + "/" + event
 }
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/mailer"
+	"github.com/third-place/user-service/internal/mailtemplate"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func createTestTemplates() fstest.MapFS {
+	return fstest.MapFS{
+		"en/greeting.txt":  {Data: []byte("Subject: Hello {{.Name}}\n\nHello {{.Name}}\n")},
+		"en/greeting.html": {Data: []byte("<p>Hello {{.Name}}</p>")},
+		"de/greeting.txt":  {Data: []byte("Subject: Hallo {{.Name}}\n\nHallo {{.Name}}\n")},
+		"de/greeting.html": {Data: []byte("<p>Hallo {{.Name}}</p>")},
+	}
+}
+
+func Test_Mail_Templates_Fall_Back_To_The_Default_Locale(t *testing.T) {
+	// setup
+	renderer, err := mailtemplate.Load(createTestTemplates(), "en", []string{"greeting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]string{"Name": "Ada"}
+
+	// when
+	german, _ := renderer.Render("greeting", "de_AT", data)
+	french, _ := renderer.Render("greeting", "fr", data)
+
+	// then
+	if german.Subject != "Hallo Ada" || german.Text != "Hallo Ada\n" {
+		t.Errorf("expected the language of a regional locale, got %+v", german)
+	}
+	if french.Subject != "Hello Ada" || french.Html != "<p>Hello Ada</p>" {
+		t.Errorf("expected the default locale, got %+v", french)
+	}
+}
+
+func Test_Mail_Templates_Escape_Html(t *testing.T) {
+	// setup
+	renderer, _ := mailtemplate.Load(createTestTemplates(), "en", []string{"greeting"})
+
+	// when
+	email, err := renderer.Render("greeting", "en", map[string]string{"Name": "<script>"})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(email.Html, "<script>") || !strings.Contains(email.Text, "<script>") {
+		t.Errorf("expected only the html to be escaped, got %+v", email)
+	}
+}
+
+func Test_Mail_Templates_Require_Every_Template(t *testing.T) {
+	// when
+	_, missingErr := mailtemplate.Load(createTestTemplates(), "en", []string{"greeting", "farewell"})
+	_, localeErr := mailtemplate.Load(createTestTemplates(), "fr", []string{"greeting"})
+
+	// then
+	if missingErr == nil {
+		t.Error("expected a missing template to fail loading")
+	}
+	if localeErr == nil {
+		t.Error("expected templates missing from the default locale to fail loading")
+	}
+}
+
+func Test_Bundled_Mail_Templates_Render(t *testing.T) {
+	// setup
+	mailService := CreateTestMailService()
+	inviter := &entity.User{Username: "ada"}
+	invite := &entity.Invite{Code: "ABC-123", Email: "someone@example.org"}
+
+	// when
+	email, err := mailService.templates.Render("invite", "en", &inviteEmail{
+		InviterName: inviter.Username,
+		Message:     "<b>come join</b>",
+		Code:        invite.Code,
+		Link:        mailService.createInviteTrackingLink("token", "click"),
+		PixelLink:   mailService.createInviteTrackingLink("token", "open"),
+	})
+	queued, queueErr := mailService.CreateInviteEmail(inviter, invite, &model.EmailInvite{Message: "<b>come join</b>"}, "token")
+
+	// then
+	if err != nil || queueErr != nil {
+		t.Fatal(err, queueErr)
+	}
+	if queued.ToEmail != invite.Email || queued.UserID != nil || queued.Text != email.Text {
+		t.Errorf("unexpected queued email %+v", queued)
+	}
+	if email.Subject != "Third place: ada invited you to join" {
+		t.Errorf("unexpected subject %s", email.Subject)
+	}
+	if !strings.Contains(email.Html, "&lt;b&gt;come join&lt;/b&gt;") || !strings.Contains(email.Text, "ABC-123") {
+		t.Errorf("unexpected email %+v", email)
+	}
+}
+
+func Test_Password_Reset_Email_Has_The_Code(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	userRepository := repository.CreateUserRepository(util.SetupTestDatabase())
+
+	// given
+	userModel, _ := svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		Password: dummyPassword,
+	})
+
+	// when
+	_ = svc.ForgotPassword(userModel)
+	userEntity, _ := userRepository.GetUserFromUuid(uuid.MustParse(userModel.Uuid))
+	outboxService := &OutboxService{
+		svc.userService.outboxRepository,
+		svc.userService.emailRepository,
+		userRepository,
+		svc.userService.mailService,
+		CreateTestSecurityService(),
+	}
+	for _, email := range outboxService.outboxRepository.FindForUser(userEntity.ID, 0) {
+		outboxService.deliver(email)
+	}
+
+	// then
+	sent := svc.userService.mailService.transport.(*mailer.MemoryTransport).To(userEntity.Email)
+	if len(sent) == 0 {
+		t.Fatal("expected a password reset email")
+	}
+	email := sent[len(sent)-1]
+	if email.From != fromMail || email.Subject != "Third place: password reset request" {
+		t.Errorf("unexpected email %+v", email)
+	}
+	if !strings.Contains(email.Text, userEntity.OTP) || !strings.Contains(email.Html, userEntity.OTP) {
+		t.Error("expected the email to have the reset code")
+	}
+}
+
+func Test_Email_Links_Escape_The_Address_And_Code(t *testing.T) {
+	// setup
+	mailService := CreateTestMailService()
+
+	// given
+	user := &entity.User{Email: "ada+news@example.com", OTP: "AB&C D"}
+
+	// when
+	links := []string{
+		mailService.createVerifyLink(user),
+		mailService.createPasswordResetLink(user),
+	}
+
+	// then
+	for _, link := range links {
+		parsed, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(link, mailService.appUrl+"/") {
+			t.Errorf("expected the link to start with the app url, got %s", link)
+		}
+		if parsed.Query().Get("email") != user.Email || parsed.Query().Get("code") != user.OTP {
+			t.Errorf("expected the address and code to survive the query string, got %s", link)
+		}
+	}
+}
+
+func Test_File_Transport_Writes_Eml_Files(t *testing.T) {
+	// setup
+	dir := t.TempDir()
+	transport := mailer.NewFileTransport(dir)
+
+	// when
+	err := transport.Send(&mailer.Message{
+		From:    fromMail,
+		To:      mailer.Address{Name: "Ada", Email: "ada@example.org"},
+		Subject: "Hello",
+		Text:    "Hello Ada",
+		Html:    "<p>Hello Ada</p>",
+	})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one eml file, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	for _, expected := range []string{
+		"To: \"Ada\" <ada@example.org>",
+		"Subject: Hello",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Type: text/html; charset=utf-8",
+		"<p>Hello Ada</p>",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected the email to contain %s", expected)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"time"
+)
+
+const (
+	outboxBatchSize    = 20
+	outboxPollInterval = 2 * time.Second
+	// outboxLease is how long a worker has to send an email before another
+	// worker may pick it up.
+	outboxLease       = 2 * time.Minute
+	maxOutboxAttempts = 8
+	// Retries wait 30s, 1m, 2m and so on, up to an hour.
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+)
+
+type OutboxService struct {
+	outboxRepository *repository.OutboxEmailRepository
+	emailRepository  *repository.EmailRepository
+	userRepository   *repository.UserRepository
+	mailService      *MailService
+	securityService  *SecurityService
+}
+
+func CreateOutboxService() *OutboxService {
+	conn := db.CreateDefaultConnection()
+	return &OutboxService{
+		repository.CreateOutboxEmailRepository(conn),
+		repository.CreateEmailRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateMailService(),
+		CreateSecurityService(),
+	}
+}
+
+func CreateTestOutboxService() *OutboxService {
+	conn := util.SetupTestDatabase()
+	return &OutboxService{
+		repository.CreateOutboxEmailRepository(conn),
+		repository.CreateEmailRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateTestMailService(),
+		CreateTestSecurityService(),
+	}
+}
+
+// RunWorker delivers queued emails until the process exits. Any number of
+// workers can run at once, they never claim the same email.
+func (s *OutboxService) RunWorker() {
+	for {
+		if s.DeliverDue() < outboxBatchSize {
+			time.Sleep(outboxPollInterval)
+		}
+	}
+}
+
+// DeliverDue sends the emails that are due and returns how many it tried.
+func (s *OutboxService) DeliverDue() int {
+	emails, err := s.outboxRepository.ClaimDue(outboxBatchSize, outboxLease)
+	if err != nil {
+		log.Print("error claiming outbox emails :: ", err)
+		return 0
+	}
+	for _, email := range emails {
+		s.deliver(email)
+	}
+	return len(emails)
+}
+
+// GetUserEmails lists the emails sent to a user and how their delivery
+// went.
+func (s *OutboxService) GetUserEmails(session *model.Session, username string, offset int) ([]*model.OutboxEmail, error) {
+	if !s.securityService.Can(session, model.PermissionEmailDeliveryManage, nil) {
+		return nil, errors.New("not allowed")
+	}
+	user, err := s.userRepository.GetUserFromUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapOutboxEmailEntitiesToModels(s.outboxRepository.FindForUser(user.ID, offset)), nil
+}
+
+// ResendEmail queues the email again, rendered from the user's current
+// state, so it goes to their current address with their current code. Emails
+// with one-time links can't be resent, their links are only kept hashed.
+func (s *OutboxService) ResendEmail(session *model.Session, emailUuid uuid.UUID) (*model.OutboxEmail, error) {
+	if !s.securityService.Can(session, model.PermissionEmailDeliveryManage, nil) {
+		return nil, errors.New("not allowed")
+	}
+	original, err := s.outboxRepository.FindOneByUuid(emailUuid)
+	if err != nil {
+		return nil, err
+	}
+	if original.UserID == nil {
+		return nil, errEmailCannotBeResent()
+	}
+	user, err := s.userRepository.GetUserFromId(*original.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var email *entity.OutboxEmail
+	switch original.Template {
+	case "verification":
+		if user.Verified {
+			return nil, util.NewInputFieldError(
+				"email",
+				"this email address is already verified",
+			)
+		}
+		email, err = s.mailService.CreateVerificationEmail(user)
+	case "password_reset":
+		email, err = s.mailService.CreatePasswordResetEmail(user)
+	default:
+		return nil, errEmailCannotBeResent()
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := s.outboxRepository.Create(email)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mapper.MapOutboxEmailEntityToModel(email), nil
+}
+
+// deliver tries to send the email once. Failures are retried with
+// exponential backoff, until the email runs out of attempts. Only critical
+// emails go to addresses that bounced or reported spam.
+func (s *OutboxService) deliver(email *entity.OutboxEmail) {
+	if !isCriticalEmail(email.Template) && s.emailRepository.IsUndeliverable(email.ToEmail) {
+		email.Status = enum.OutboxStatusSuppressed
+		email.ClearBody()
+		s.outboxRepository.Save(email)
+		return
+	}
+	email.Attempts++
+	now := time.Now()
+	err := s.mailService.Deliver(email)
+	if err == nil {
+		email.Status = enum.OutboxStatusSent
+		email.SentAt = &now
+		email.LastError = ""
+		email.ClearBody()
+	} else {
+		log.Print("error delivering email :: ", err)
+		email.LastError = err.Error()
+		if email.Attempts >= maxOutboxAttempts {
+			email.Status = enum.OutboxStatusFailed
+			email.ClearBody()
+		} else {
+			email.NextAttemptAt = now.Add(outboxBackoff(email.Attempts))
+		}
+	}
+	result := s.outboxRepository.Save(email)
+	if result.Error != nil {
+		log.Print("error saving outbox email :: ", result.Error)
+	}
+}
+
+func errEmailCannotBeResent() error {
+	return util.NewInputFieldError(
+		"email",
+		"only verification and password reset emails can be resent",
+	)
+}
+
+// outboxBackoff is how long to wait before the next attempt, after the
+// given number of failed ones.
+func outboxBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return outboxBaseBackoff
+	}
+	backoff := outboxBaseBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mailer"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingTransport struct{}
+
+func (t *failingTransport) Name() string {
+	return "failing"
+}
+
+func (t *failingTransport) Send(message *mailer.Message) error {
+	return errors.New("connection refused")
+}
+
+func createTestOutboxEmail(outboxService *OutboxService) *entity.OutboxEmail {
+	email := &entity.OutboxEmail{
+		Template:      "verification",
+		ToEmail:       util.RandomEmailAddress(),
+		Subject:       "Hello",
+		Text:          "Hello",
+		Status:        enum.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	outboxService.outboxRepository.Create(email)
+	return email
+}
+
+func Test_Outbox_Backoff_Doubles_Up_To_An_Hour(t *testing.T) {
+	expected := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		8:  time.Hour,
+		80: time.Hour,
+	}
+	for attempts, backoff := range expected {
+		if outboxBackoff(attempts) != backoff {
+			t.Errorf("expected %s after %d attempts, got %s", backoff, attempts, outboxBackoff(attempts))
+		}
+	}
+}
+
+func Test_Forgot_Password_Queues_The_Email(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	outboxService := CreateTestOutboxService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	target, targetSession := svc.CreateUserWithRole(model.USER)
+
+	// when
+	err := svc.ForgotPassword(&model.User{Email: target.Email})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	emails, err := outboxService.GetUserEmails(adminSession, target.Username, 0)
+	if err != nil || len(emails) == 0 {
+		t.Fatal("expected the email to be in the outbox")
+	}
+	if emails[0].Template != "password_reset" || emails[0].Status != string(enum.OutboxStatusPending) {
+		t.Errorf("unexpected email %+v", emails[0])
+	}
+	if _, err = outboxService.GetUserEmails(targetSession, target.Username, 0); err == nil {
+		t.Error("expected users to not see the delivery log")
+	}
+}
+
+func Test_Outbox_Delivers_Due_Emails(t *testing.T) {
+	// setup
+	outboxService := CreateTestOutboxService()
+
+	// given
+	email := createTestOutboxEmail(outboxService)
+
+	// when
+	for outboxService.DeliverDue() > 0 {
+	}
+
+	// then
+	email, _ = outboxService.outboxRepository.FindOneByUuid(email.Uuid)
+	if email.Status != enum.OutboxStatusSent || email.SentAt == nil || email.Attempts != 1 {
+		t.Errorf("expected the email to be sent, got %+v", email)
+	}
+	if email.Text != "" || email.Html != "" {
+		t.Error("expected the body to be cleared once sent")
+	}
+	sent := outboxService.mailService.transport.(*mailer.MemoryTransport).To(email.ToEmail)
+	if len(sent) != 1 || sent[0].Subject != "Hello" {
+		t.Errorf("expected one email to be delivered, got %d", len(sent))
+	}
+}
+
+func Test_Outbox_Retries_Failed_Deliveries_With_Backoff(t *testing.T) {
+	// setup
+	outboxService := CreateTestOutboxService()
+	outboxService.mailService = &MailService{&failingTransport{}, defaultApiUrl, defaultAppUrl, outboxService.mailService.templates}
+
+	// given
+	email := createTestOutboxEmail(outboxService)
+
+	// when
+	outboxService.deliver(email)
+
+	// then
+	if email.Status != enum.OutboxStatusPending || email.Attempts != 1 {
+		t.Errorf("expected the email to be retried, got %+v", email)
+	}
+	if !strings.Contains(email.LastError, "connection refused") {
+		t.Errorf("expected the error to be recorded, got %s", email.LastError)
+	}
+	if time.Until(email.NextAttemptAt) < 29*time.Second {
+		t.Error("expected the next attempt to wait")
+	}
+
+	// when
+	email.Attempts = maxOutboxAttempts - 1
+	outboxService.deliver(email)
+
+	// then
+	if email.Status != enum.OutboxStatusFailed {
+		t.Errorf("expected the email to give up, got %s", email.Status)
+	}
+}
+
+func Test_Admin_Can_Resend_An_Email(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	outboxService := CreateTestOutboxService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	user, userSession := svc.CreateUserWithRole(model.USER)
+	email := outboxService.outboxRepository.FindForUser(user.ID, 0)[0]
+	for outboxService.DeliverDue() > 0 {
+	}
+	user.Email = util.RandomEmailAddress()
+	user.OTP = util.GenerateCode()
+	outboxService.userRepository.Save(user)
+
+	// when
+	_, userErr := outboxService.ResendEmail(userSession, email.Uuid)
+	resent, err := outboxService.ResendEmail(adminSession, email.Uuid)
+
+	// then
+	if userErr == nil {
+		t.Error("expected users to not resend emails")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resent.Uuid == email.Uuid.String() || resent.To != user.Email || resent.Status != string(enum.OutboxStatusPending) {
+		t.Errorf("expected a new pending email to the current address, got %+v", resent)
+	}
+	queued := outboxService.outboxRepository.FindForUser(user.ID, 0)[0]
+	if !strings.Contains(queued.Text, user.OTP) {
+		t.Error("expected the resent email to have the current code")
+	}
+}
+
+func Test_Emails_With_One_Time_Links_Are_Not_Resent(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	outboxService := CreateTestOutboxService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	user, _ := svc.CreateUserWithRole(model.USER)
+	email := createTestOutboxEmail(outboxService)
+	email.Template = "new_sign_in"
+	email.UserID = &user.ID
+	outboxService.outboxRepository.Save(email)
+
+	// when
+	_, err := outboxService.ResendEmail(adminSession, email.Uuid)
+
+	// then
+	if err == nil {
+		t.Error("expected emails with one-time links to not be resent")
+	}
+}
+
+func Test_Outbox_Holds_Back_Non_Critical_Emails_To_Undeliverable_Addresses(t *testing.T) {
+	// setup
+	outboxService := CreateTestOutboxService()
+
+	// given
+	critical := createTestOutboxEmail(outboxService)
+	nonCritical := createTestOutboxEmail(outboxService)
+	nonCritical.Template = "invite"
+	for _, email := range []*entity.OutboxEmail{critical, nonCritical} {
+		address := entity.CreateEmail(email.ToEmail)
+		address.DeliveryStatus = enum.EmailDeliveryStatusBounced
+		outboxService.emailRepository.Save(address)
+	}
+
+	// when
+	outboxService.deliver(critical)
+	outboxService.deliver(nonCritical)
+
+	// then
+	if critical.Status != enum.OutboxStatusSent {
+		t.Errorf("expected critical emails to be sent, got %s", critical.Status)
+	}
+	if nonCritical.Status != enum.OutboxStatusSuppressed || nonCritical.Attempts != 0 {
+		t.Errorf("expected the email to be held back, got %+v", nonCritical)
+	}
+}
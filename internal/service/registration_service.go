@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/emaildomain"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+)
+
+const maxAllowedDomains = 100
+
+type RegistrationService struct {
+	registrationRepository *repository.RegistrationSettingsRepository
+	userRepository         *repository.UserRepository
+	securityService        *SecurityService
+}
+
+func CreateRegistrationService() *RegistrationService {
+	conn := db.CreateDefaultConnection()
+	return &RegistrationService{
+		repository.CreateRegistrationSettingsRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateSecurityService(),
+	}
+}
+
+func CreateTestRegistrationService() *RegistrationService {
+	conn := util.SetupTestDatabase()
+	return &RegistrationService{
+		repository.CreateRegistrationSettingsRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateTestSecurityService(),
+	}
+}
+
+// GetRegistration tells clients who can sign up right now.
+func (s *RegistrationService) GetRegistration() *model.Registration {
+	return mapper.MapRegistrationSettingsEntityToModel(s.registrationRepository.Get())
+}
+
+// UpdateRegistration changes who can sign up. It takes effect for the next
+// sign-up, without a redeploy.
+func (s *RegistrationService) UpdateRegistration(session *model.Session, update *model.RegistrationUpdate) (*model.Registration, error) {
+	if !s.securityService.Can(session, model.PermissionRegistrationManage, nil) {
+		return nil, errors.New("not allowed")
+	}
+	mode := enum.RegistrationModeType(update.Mode)
+	if !mode.IsValid() {
+		return nil, util.NewInputFieldError(
+			"mode",
+			"mode must be invite_only, open, open_with_domain_allowlist or closed",
+		)
+	}
+	domains, err := normalizeDomains(update.AllowedDomains)
+	if err != nil {
+		return nil, err
+	}
+	if mode == enum.RegistrationModeOpenWithDomainAllowlist && len(domains) == 0 {
+		return nil, util.NewInputFieldError(
+			"allowedDomains",
+			"at least one domain has to be allowed in this mode",
+		)
+	}
+	userUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := s.userRepository.GetUserFromUuid(userUuid)
+	if err != nil {
+		return nil, err
+	}
+	settings := s.registrationRepository.Get()
+	settings.Mode = mode
+	settings.AllowedDomains = domains
+	settings.UpdatedByID = &admin.ID
+	result := s.registrationRepository.Save(settings)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mapper.MapRegistrationSettingsEntityToModel(settings), nil
+}
+
+// normalizeDomains lowercases the domains and drops duplicates, so they
+// compare equal to the domain of any address.
+func normalizeDomains(domains []string) ([]string, error) {
+	if len(domains) > maxAllowedDomains {
+		return nil, util.NewInputFieldError(
+			"allowedDomains",
+			"at most 100 domains can be allowed",
+		)
+	}
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, domain := range domains {
+		domain = emaildomain.Normalize(domain)
+		if domain == "" || seen[domain] {
+			continue
+		}
+		if !emaildomain.IsValid(domain) {
+			return nil, util.NewInputFieldError(
+				"allowedDomains",
+				domain+" is not a valid domain",
+			)
+		}
+		seen[domain] = true
+		normalized = append(normalized, domain)
+	}
+	return normalized, nil
+}
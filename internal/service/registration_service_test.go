@@ -0,0 +1,187 @@
+package service
+
+import (
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func resetRegistration(registrationService *RegistrationService, session *model.Session) {
+	_, _ = registrationService.UpdateRegistration(session, &model.RegistrationUpdate{
+		Mode: string(enum.RegistrationModeInviteOnly),
+	})
+}
+
+func Test_Open_Registration_Does_Not_Need_An_Invite(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	registrationService := CreateTestRegistrationService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	defer resetRegistration(registrationService, adminSession)
+	_, err := registrationService.UpdateRegistration(adminSession, &model.RegistrationUpdate{
+		Mode: string(enum.RegistrationModeOpen),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	_, err = svc.CreateUser(&model.Invite{}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		Password: dummyPassword,
+	})
+
+	// then
+	if err != nil {
+		t.Error("expected to sign up without an invite")
+	}
+	if registrationService.GetRegistration().InviteRequired {
+		t.Error("expected the client to be told no invite is needed")
+	}
+}
+
+func Test_Domain_Allowlist_Lets_In_Allowed_Domains(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	registrationService := CreateTestRegistrationService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	defer resetRegistration(registrationService, adminSession)
+	_, err := registrationService.UpdateRegistration(adminSession, &model.RegistrationUpdate{
+		Mode:           string(enum.RegistrationModeOpenWithDomainAllowlist),
+		AllowedDomains: []string{" @Example.org "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedEmail := "test-" + strconv.FormatInt(time.Now().UnixNano(), 10) + "@example.org"
+
+	// when
+	_, allowedErr := svc.CreateUser(&model.Invite{}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    allowedEmail,
+		Password: dummyPassword,
+	})
+	_, otherErr := svc.CreateUser(&model.Invite{}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		Password: dummyPassword,
+	})
+	_, invitedErr := svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		Password: dummyPassword,
+	})
+
+	// then
+	if allowedErr != nil {
+		t.Error("expected allowed domains to sign up without an invite")
+	}
+	if otherErr == nil {
+		t.Error("expected other domains to need an invite")
+	}
+	if invitedErr != nil {
+		t.Error("expected invites to keep working")
+	}
+	if !registrationService.GetRegistration().InviteRequired {
+		t.Error("expected the client to be told other domains need an invite")
+	}
+}
+
+func Test_Domain_Allowlist_Users_Log_In_After_Verifying(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	registrationService := CreateTestRegistrationService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	defer resetRegistration(registrationService, adminSession)
+	_, err := registrationService.UpdateRegistration(adminSession, &model.RegistrationUpdate{
+		Mode:           string(enum.RegistrationModeOpenWithDomainAllowlist),
+		AllowedDomains: []string{"example.org"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedEmail := "test-" + strconv.FormatInt(time.Now().UnixNano(), 10) + "@example.org"
+	_, err = svc.CreateUser(&model.Invite{}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    allowedEmail,
+		Password: dummyPassword,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSession := &model.NewSession{Email: allowedEmail, Password: dummyPassword}
+
+	// when
+	_, unverifiedErr := svc.userService.CreateSession(newSession)
+	user, _ := svc.userRepository.GetUserFromEmail(allowedEmail)
+	_ = svc.userService.SubmitOTP(&model.Otp{User: &model.User{Email: allowedEmail}, Code: user.OTP})
+	session, verifiedErr := svc.userService.CreateSession(newSession)
+
+	// then
+	if unverifiedErr == nil {
+		t.Error("expected no session before the address is verified")
+	}
+	if verifiedErr != nil || session == nil {
+		t.Error("expected a session once the address is verified")
+	}
+}
+
+func Test_Closed_Registration_Rejects_Everyone(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	registrationService := CreateTestRegistrationService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	defer resetRegistration(registrationService, adminSession)
+	_, err := registrationService.UpdateRegistration(adminSession, &model.RegistrationUpdate{
+		Mode: string(enum.RegistrationModeClosed),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	_, err = svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		Password: dummyPassword,
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected sign-ups to be rejected while registration is closed")
+	}
+	if !registrationService.GetRegistration().InviteRequired {
+		t.Error("expected the client to be told an invite is needed")
+	}
+}
+
+func Test_Only_Admins_Can_Change_Registration(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	registrationService := CreateTestRegistrationService()
+
+	// given
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+
+	// when
+	_, err := registrationService.UpdateRegistration(moderatorSession, &model.RegistrationUpdate{
+		Mode: string(enum.RegistrationModeOpen),
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected moderators not to change registration")
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+)
+
+type RoleService struct {
+	roleRepository  *repository.RoleRepository
+	securityService *SecurityService
+}
+
+func CreateRoleService() *RoleService {
+	conn := db.CreateDefaultConnection()
+	return &RoleService{
+		repository.CreateRoleRepository(conn),
+		CreateSecurityService(),
+	}
+}
+
+func CreateTestRoleService() *RoleService {
+	conn := util.SetupTestDatabase()
+	return &RoleService{
+		repository.CreateRoleRepository(conn),
+		CreateTestSecurityService(),
+	}
+}
+
+func (s *RoleService) GetRoles() []*model.RoleDefinition {
+	return mapper.MapRoleEntitiesToModels(s.roleRepository.FindAll())
+}
+
+func (s *RoleService) CreateRole(session *model.Session, roleModel *model.RoleDefinition) (*model.RoleDefinition, error) {
+	if roleModel.Name == "" {
+		return nil, util.NewInputFieldError(
+			"name",
+			"role name is required",
+		)
+	}
+	if _, err := s.roleRepository.FindOneByName(string(roleModel.Name)); err == nil {
+		return nil, util.NewInputFieldError(
+			"name",
+			"role already exists",
+		)
+	}
+	role := &entity.Role{
+		Name: string(roleModel.Name),
+	}
+	err := s.applyRoleModel(session, role, roleModel)
+	if err != nil {
+		return nil, err
+	}
+	result := s.roleRepository.Create(role)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mapper.MapRoleEntityToModel(role), nil
+}
+
+func (s *RoleService) UpdateRole(session *model.Session, name string, roleModel *model.RoleDefinition) (*model.RoleDefinition, error) {
+	role, err := s.roleRepository.FindOneByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canManage(session, role.Level) {
+		return nil, errors.New("not allowed")
+	}
+	err = s.applyRoleModel(session, role, roleModel)
+	if err != nil {
+		return nil, err
+	}
+	err = s.roleRepository.Save(role)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapRoleEntityToModel(role), nil
+}
+
+func (s *RoleService) applyRoleModel(session *model.Session, role *entity.Role, roleModel *model.RoleDefinition) error {
+	if !s.canManage(session, roleModel.Level) {
+		return errors.New("not allowed")
+	}
+	user, err := s.securityService.getUser(session)
+	if err != nil {
+		return errors.New("not allowed")
+	}
+	names := make([]string, len(roleModel.Permissions))
+	for i, permission := range roleModel.Permissions {
+		if !permission.IsValid() {
+			return util.NewInputFieldError(
+				"permissions",
+				"permission not recognized: "+string(permission),
+			)
+		}
+		if !s.canBundle(session, user, permission) {
+			return util.NewInputFieldError(
+				"permissions",
+				"cannot grant a permission you do not hold: "+string(permission),
+			)
+		}
+		names[i] = string(permission)
+	}
+	role.Description = roleModel.Description
+	role.Level = roleModel.Level
+	role.Permissions = s.roleRepository.FindPermissionsByName(names)
+	return nil
+}
+
+// canManage only lets users manage roles that rank below their own, so nobody
+// can mint a role more powerful than the one they hold.
+func (s *RoleService) canManage(session *model.Session, level int) bool {
+	if !s.securityService.Can(session, model.PermissionRoleManage, nil) {
+		return false
+	}
+	user, err := s.securityService.getUser(session)
+	if err != nil {
+		return false
+	}
+	return user.IsSuperAdmin || level < user.Level()
+}
+
+// canBundle only lets users put permissions they hold themselves into a role,
+// so a lower ranked role can't be used to hand out more than its creator has.
+func (s *RoleService) canBundle(session *model.Session, user *entity.User, permission model.Permission) bool {
+	return user.IsSuperAdmin || s.securityService.Can(session, permission, nil)
+}
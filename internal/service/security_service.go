@@ -1,50 +1,146 @@
 package service
 
 import (
-	"errors"
-	"github.com/google/uuid"
 	"github.com/third-place/user-service/internal/db"
 	"github.com/third-place/user-service/internal/entity"
 	"github.com/third-place/user-service/internal/model"
 	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
 )
 
+// impersonationBlockedPermissions can't be used while an admin impersonates
+// a user, so that impersonation can't be used to escalate privileges.
+var impersonationBlockedPermissions = []model.Permission{
+	model.PermissionUserAssignRole,
+	model.PermissionRoleManage,
+	model.PermissionUserImpersonate,
+	model.PermissionServiceAccountManage,
+}
+
 type SecurityService struct {
 	userRepository *repository.UserRepository
+	roleRepository *repository.RoleRepository
 }
 
 func CreateSecurityService() *SecurityService {
 	conn := db.CreateDefaultConnection()
 	return &SecurityService{
 		repository.CreateUserRepository(conn),
+		repository.CreateRoleRepository(conn),
 	}
 }
 
-func (s *SecurityService) IsInGoodStanding(session *model.Session) bool {
+func CreateTestSecurityService() *SecurityService {
+	conn := util.SetupTestDatabase()
+	return &SecurityService{
+		repository.CreateUserRepository(conn),
+		repository.CreateRoleRepository(conn),
+	}
+}
+
+// Can reports whether the session user holds the permission through one of
+// their roles. When a target user is given, the session user must also rank
+// above the target in the role hierarchy, unless they are a super-admin.
+func (s *SecurityService) Can(session *model.Session, permission model.Permission, target *entity.User) bool {
+	return s.Decide(session, permission, target).Allowed
+}
+
+// Decide is Can with the reason for the decision, for callers that need to
+// explain a denial.
+func (s *SecurityService) Decide(session *model.Session, permission model.Permission, target *entity.User) *model.AuthzDecision {
+	if session == nil {
+		return model.CreateAuthzDecision(false, "no session")
+	}
+	if session.IsImpersonated() {
+		for _, blocked := range impersonationBlockedPermissions {
+			if permission == blocked {
+				return model.CreateAuthzDecision(false, "not allowed while impersonating")
+			}
+		}
+	}
+	user, err := s.getUser(session)
+	if err != nil {
+		return model.CreateAuthzDecision(false, "subject not found")
+	}
+	if user.IsBanned {
+		return model.CreateAuthzDecision(false, "subject is banned")
+	}
+	if user.IsServiceAccount {
+		return s.decideForServiceAccount(session, permission, target)
+	}
+	if !user.HasPermission(permission) {
+		return model.CreateAuthzDecision(false, "subject lacks permission "+string(permission))
+	}
+	if session.Scopes != nil && !model.ScopesAllow(session.Scopes, permission) {
+		return model.CreateAuthzDecision(false, "token scopes do not include "+string(permission))
+	}
+	if target == nil {
+		return model.CreateAuthzDecision(true, "granted by role")
+	}
+	if user.IsSuperAdmin {
+		return model.CreateAuthzDecision(true, "granted to super-admin")
+	}
+	if target.ID == user.ID {
+		return model.CreateAuthzDecision(false, "subject cannot act on themselves")
+	}
+	if !s.outranks(user, target) {
+		return model.CreateAuthzDecision(false, "subject does not outrank the resource owner")
+	}
+	return model.CreateAuthzDecision(true, "granted by role")
+}
+
+// decideForServiceAccount grants service accounts exactly what their token
+// scopes cover. Service accounts have no place in the role hierarchy, so they
+// may act on any user except super-admins and other service accounts.
+func (s *SecurityService) decideForServiceAccount(session *model.Session, permission model.Permission, target *entity.User) *model.AuthzDecision {
+	if !model.ScopesAllow(session.Scopes, permission) {
+		return model.CreateAuthzDecision(false, "token scopes do not include "+string(permission))
+	}
+	if target != nil && (target.IsSuperAdmin || target.IsServiceAccount) {
+		return model.CreateAuthzDecision(false, "service accounts cannot act on this resource owner")
+	}
+	return model.CreateAuthzDecision(true, "granted by token scope")
+}
+
+// CanAssignRole reports whether the session user may give the role to, or
+// take it away from, the target user. Super-admins may assign any role.
+// Everyone else needs the assign permission and must outrank both the role
+// and the target.
+func (s *SecurityService) CanAssignRole(session *model.Session, role *entity.Role, target *entity.User) bool {
+	if !s.Can(session, model.PermissionUserAssignRole, target) {
+		return false
+	}
 	user, err := s.getUser(session)
 	if err != nil {
 		return false
 	}
-	return !user.IsBanned
+	return user.IsSuperAdmin || role.Level < user.Level()
 }
 
-func (s *SecurityService) IsModerator(session *model.Session) bool {
+func (s *SecurityService) IsInGoodStanding(session *model.Session) bool {
 	user, err := s.getUser(session)
 	if err != nil {
 		return false
 	}
-	return !user.IsBanned && (user.Role == string(model.MODERATOR) || user.Role == string(model.ADMIN))
+	return !user.IsBanned
 }
 
-func (s *SecurityService) getUser(session *model.Session) (*entity.User, error) {
-	if session.User == nil {
-		return nil, errors.New("user not defined")
+func (s *SecurityService) outranks(user *entity.User, target *entity.User) bool {
+	if target.Roles == nil {
+		err := s.roleRepository.LoadUserRoles(target)
+		if err != nil {
+			return false
+		}
 	}
-	userUuid, err := uuid.Parse(session.User.Uuid)
+	return user.Level() > target.Level()
+}
+
+func (s *SecurityService) getUser(session *model.Session) (*entity.User, error) {
+	user, err := getSessionUser(s.userRepository, session)
 	if err != nil {
 		return nil, err
 	}
-	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	err = s.roleRepository.LoadUserRoles(user)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"time"
+)
+
+const serviceAccountTokenLifetime = time.Hour
+
+type ServiceAccountService struct {
+	serviceAccountRepository *repository.ServiceAccountRepository
+	securityService          *SecurityService
+}
+
+func CreateServiceAccountService() *ServiceAccountService {
+	conn := db.CreateDefaultConnection()
+	return &ServiceAccountService{
+		repository.CreateServiceAccountRepository(conn),
+		CreateSecurityService(),
+	}
+}
+
+func CreateTestServiceAccountService() *ServiceAccountService {
+	conn := util.SetupTestDatabase()
+	return &ServiceAccountService{
+		repository.CreateServiceAccountRepository(conn),
+		CreateTestSecurityService(),
+	}
+}
+
+// CreateServiceAccount creates a service account and its client credentials.
+// The client secret is only returned here. Admins can only grant scopes
+// covering permissions they hold themselves.
+func (s *ServiceAccountService) CreateServiceAccount(session *model.Session, newAccount *model.NewServiceAccount) (*model.ServiceAccount, error) {
+	if !s.canManage(session) {
+		return nil, errors.New("not allowed")
+	}
+	if newAccount.Name == "" {
+		return nil, util.NewInputFieldError(
+			"name",
+			"service account name is required",
+		)
+	}
+	if len(newAccount.Scopes) == 0 {
+		return nil, util.NewInputFieldError(
+			"scopes",
+			"at least one scope is required",
+		)
+	}
+	for _, scope := range newAccount.Scopes {
+		if !scope.IsValid() {
+			return nil, util.NewInputFieldError(
+				"scopes",
+				"scope not recognized: "+string(scope),
+			)
+		}
+		for _, permission := range model.ScopePermissions[scope] {
+			if !s.securityService.Can(session, permission, nil) {
+				return nil, util.NewInputFieldError(
+					"scopes",
+					"cannot grant a scope you do not hold: "+string(scope),
+				)
+			}
+		}
+	}
+	clientId, clientSecret, err := util.GenerateClientCredentials()
+	if err != nil {
+		return nil, err
+	}
+	creator, err := s.securityService.getUser(session)
+	if err != nil {
+		return nil, err
+	}
+	account := &entity.ServiceAccount{
+		Name:       newAccount.Name,
+		ClientId:   clientId,
+		SecretHash: util.HashClientSecret(clientSecret),
+		CreatedBy:  creator.ID,
+		User: &entity.User{
+			Name:             newAccount.Name,
+			Username:         clientId,
+			Email:            clientId + "@service-accounts.invalid",
+			Verified:         true,
+			IsServiceAccount: true,
+		},
+	}
+	account.SetScopes(newAccount.Scopes)
+	err = s.serviceAccountRepository.Create(account)
+	if err != nil {
+		return nil, err
+	}
+	accountModel := mapper.MapServiceAccountEntityToModel(account)
+	accountModel.ClientSecret = clientSecret
+	return accountModel, nil
+}
+
+func (s *ServiceAccountService) GetServiceAccounts(session *model.Session, offset int) ([]*model.ServiceAccount, error) {
+	if !s.canManage(session) {
+		return nil, errors.New("not allowed")
+	}
+	return mapper.MapServiceAccountEntitiesToModels(s.serviceAccountRepository.FindAll(offset)), nil
+}
+
+// RotateServiceAccountSecret replaces the client secret. Tokens issued before
+// the rotation are revoked along with the old secret.
+func (s *ServiceAccountService) RotateServiceAccountSecret(session *model.Session, accountUuid uuid.UUID) (*model.ServiceAccount, error) {
+	if !s.canManage(session) {
+		return nil, errors.New("not allowed")
+	}
+	account, err := s.serviceAccountRepository.FindOneByUuid(accountUuid)
+	if err != nil || !account.IsActive() {
+		return nil, errors.New("service account not found")
+	}
+	_, clientSecret, err := util.GenerateClientCredentials()
+	if err != nil {
+		return nil, err
+	}
+	account.SecretHash = util.HashClientSecret(clientSecret)
+	account.User.RevokeSessions()
+	err = s.serviceAccountRepository.Save(account)
+	if err != nil {
+		return nil, err
+	}
+	accountModel := mapper.MapServiceAccountEntityToModel(account)
+	accountModel.ClientSecret = clientSecret
+	return accountModel, nil
+}
+
+// DisableServiceAccount stops the account from getting new tokens and
+// revokes the ones it has.
+func (s *ServiceAccountService) DisableServiceAccount(session *model.Session, accountUuid uuid.UUID) error {
+	if !s.canManage(session) {
+		return errors.New("not allowed")
+	}
+	account, err := s.serviceAccountRepository.FindOneByUuid(accountUuid)
+	if err != nil {
+		return err
+	}
+	if !account.IsActive() {
+		return nil
+	}
+	now := time.Now()
+	account.DisabledAt = &now
+	return s.serviceAccountRepository.Disable(account)
+}
+
+// IssueToken handles an OAuth2 client credentials grant. When no scopes are
+// requested the token gets every scope granted to the account.
+func (s *ServiceAccountService) IssueToken(request *model.OAuthTokenRequest) (*model.OAuthToken, error) {
+	if request.GrantType != model.GrantTypeClientCredentials {
+		return nil, model.NewOAuthError(
+			model.OAuthErrorUnsupportedGrantType,
+			"only the client_credentials grant is supported",
+		)
+	}
+	if request.ClientId == "" || request.ClientSecret == "" {
+		return nil, model.NewOAuthError(
+			model.OAuthErrorInvalidRequest,
+			"client_id and client_secret are required",
+		)
+	}
+	account, err := s.serviceAccountRepository.FindOneByClientId(request.ClientId)
+	if err != nil ||
+		!account.IsActive() ||
+		account.User.IsBanned ||
+		!util.CheckClientSecret(request.ClientSecret, account.SecretHash) {
+		return nil, model.NewOAuthError(
+			model.OAuthErrorInvalidClient,
+			"client authentication failed",
+		)
+	}
+	scopes := account.GetScopes()
+	if request.Scopes != nil {
+		if !model.ContainsScopes(scopes, request.Scopes) {
+			return nil, model.NewOAuthError(
+				model.OAuthErrorInvalidScope,
+				"requested scope is not granted to this client",
+			)
+		}
+		scopes = request.Scopes
+	}
+	claims := model.NewServiceAccountClaims(account.User.Uuid, scopes, serviceAccountTokenLifetime)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(util.JwtKey)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OAuthToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(serviceAccountTokenLifetime.Seconds()),
+		Scope:       model.JoinScopes(scopes),
+	}, nil
+}
+
+// canManage requires the service account permission from an interactive
+// session. Access tokens can't be used, so a leaked token can't mint client
+// credentials.
+func (s *ServiceAccountService) canManage(session *model.Session) bool {
+	if session == nil || util.IsAccessToken(session.Token) {
+		return false
+	}
+	return s.securityService.Can(session, model.PermissionServiceAccountManage, nil)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/model"
+	"testing"
+)
+
+func Test_ServiceAccount_Can_Get_A_Scoped_Token(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	serviceAccountService := CreateTestServiceAccountService()
+	securityService := CreateTestSecurityService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	target, _ := svc.CreateUserWithRole(model.USER)
+	account, err := serviceAccountService.CreateServiceAccount(adminSession, &model.NewServiceAccount{
+		Name:   "moderation bot",
+		Scopes: []model.Scope{model.ScopeUsersReadPii, model.ScopeUsersBan},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// when
+	token, err := serviceAccountService.IssueToken(&model.OAuthTokenRequest{
+		GrantType:    model.GrantTypeClientCredentials,
+		ClientId:     account.ClientId,
+		ClientSecret: account.ClientSecret,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	session, err := svc.GetSession(&model.SessionToken{Token: token.AccessToken})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if !securityService.Can(session, model.PermissionUserBan, target) {
+		t.Error("expected the service account to be able to ban")
+	}
+	if securityService.Can(session, model.PermissionUserList, nil) {
+		t.Error("expected the service account to be limited to its scopes")
+	}
+}
+
+func Test_ServiceAccount_Token_Requires_Valid_Credentials_And_Scopes(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	serviceAccountService := CreateTestServiceAccountService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	account, _ := serviceAccountService.CreateServiceAccount(adminSession, &model.NewServiceAccount{
+		Name:   "reader",
+		Scopes: []model.Scope{model.ScopeUsersRead},
+	})
+
+	// when
+	_, badSecretErr := serviceAccountService.IssueToken(&model.OAuthTokenRequest{
+		GrantType:    model.GrantTypeClientCredentials,
+		ClientId:     account.ClientId,
+		ClientSecret: "nope",
+	})
+	_, badScopeErr := serviceAccountService.IssueToken(&model.OAuthTokenRequest{
+		GrantType:    model.GrantTypeClientCredentials,
+		ClientId:     account.ClientId,
+		ClientSecret: account.ClientSecret,
+		Scopes:       []model.Scope{model.ScopeUsersBan},
+	})
+
+	// then
+	if err, ok := badSecretErr.(*model.OAuthError); !ok || err.Code != model.OAuthErrorInvalidClient {
+		t.Error("expected invalid_client")
+	}
+	if err, ok := badScopeErr.(*model.OAuthError); !ok || err.Code != model.OAuthErrorInvalidScope {
+		t.Error("expected invalid_scope")
+	}
+}
+
+func Test_ServiceAccount_Is_Hidden_And_Cannot_Log_In(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	serviceAccountService := CreateTestServiceAccountService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	account, _ := serviceAccountService.CreateServiceAccount(adminSession, &model.NewServiceAccount{
+		Name:   "hidden",
+		Scopes: []model.Scope{model.ScopeUsersRead},
+	})
+
+	// when
+	users := svc.userService.GetUsers(adminSession, 0)
+	_, err := svc.CreateSession(&model.NewSession{
+		Email:    account.ClientId + "@service-accounts.invalid",
+		Password: account.ClientSecret,
+	})
+
+	// then
+	for _, user := range users {
+		if user.Username == account.ClientId {
+			t.Error("expected service accounts to be excluded from users")
+		}
+	}
+	if err == nil {
+		t.Error("expected service accounts to be unable to log in")
+	}
+}
+
+func Test_Disabled_ServiceAccount_Tokens_Are_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	serviceAccountService := CreateTestServiceAccountService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	account, _ := serviceAccountService.CreateServiceAccount(adminSession, &model.NewServiceAccount{
+		Name:   "short lived",
+		Scopes: []model.Scope{model.ScopeUsersRead},
+	})
+	request := &model.OAuthTokenRequest{
+		GrantType:    model.GrantTypeClientCredentials,
+		ClientId:     account.ClientId,
+		ClientSecret: account.ClientSecret,
+	}
+	token, _ := serviceAccountService.IssueToken(request)
+
+	// when
+	err := serviceAccountService.DisableServiceAccount(adminSession, uuid.MustParse(account.Uuid))
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if _, err := svc.GetSession(&model.SessionToken{Token: token.AccessToken}); err == nil {
+		t.Error("expected the token to be revoked")
+	}
+	if _, err := serviceAccountService.IssueToken(request); err == nil {
+		t.Error("expected a disabled account to get no tokens")
+	}
+}
+
+func Test_Rotating_Secret_Revokes_Issued_Tokens(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	serviceAccountService := CreateTestServiceAccountService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	account, _ := serviceAccountService.CreateServiceAccount(adminSession, &model.NewServiceAccount{
+		Name:   "rotating bot",
+		Scopes: []model.Scope{model.ScopeUsersRead},
+	})
+	token, _ := serviceAccountService.IssueToken(&model.OAuthTokenRequest{
+		GrantType:    model.GrantTypeClientCredentials,
+		ClientId:     account.ClientId,
+		ClientSecret: account.ClientSecret,
+	})
+
+	// when
+	_, err := serviceAccountService.RotateServiceAccountSecret(adminSession, uuid.MustParse(account.Uuid))
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetSession(&model.SessionToken{Token: token.AccessToken}); err == nil {
+		t.Error("expected a token issued before the rotation to be rejected")
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"time"
+)
+
+// SessionService resolves the token a request is authenticated with into a
+// session. Every route that needs a session goes through it, so revoked
+// sessions, banned users and revoked access tokens are turned away
+// everywhere.
+type SessionService struct {
+	userRepository           *repository.UserRepository
+	accessTokenRepository    *repository.AccessTokenRepository
+	roleRepository           *repository.RoleRepository
+	revokedSessionRepository *repository.RevokedSessionRepository
+}
+
+func CreateSessionService() *SessionService {
+	conn := db.CreateDefaultConnection()
+	return &SessionService{
+		repository.CreateUserRepository(conn),
+		repository.CreateAccessTokenRepository(conn),
+		repository.CreateRoleRepository(conn),
+		repository.CreateRevokedSessionRepository(conn),
+	}
+}
+
+func CreateTestSessionService() *SessionService {
+	conn := util.SetupTestDatabase()
+	return &SessionService{
+		repository.CreateUserRepository(conn),
+		repository.CreateAccessTokenRepository(conn),
+		repository.CreateRoleRepository(conn),
+		repository.CreateRevokedSessionRepository(conn),
+	}
+}
+
+func (s *SessionService) GetSession(sessionToken *model.SessionToken) (*model.Session, error) {
+	if sessionToken == nil {
+		return nil, errors.New("token not valid")
+	}
+	if util.IsAccessToken(sessionToken.Token) {
+		return s.getAccessTokenSession(sessionToken)
+	}
+	claims, err := parseSessionClaims(sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	if s.isLoggedOut(sessionToken) {
+		return nil, errors.New("session revoked")
+	}
+	userUuid, err := uuid.Parse(claims.UserUuid)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBanned || user.IsSessionRevoked(claims) {
+		return nil, errors.New("session revoked")
+	}
+	if user.IsServiceAccount && len(claims.Scopes) == 0 {
+		return nil, errors.New("token not valid")
+	}
+	session := model.CreateSession(mapper.MapUserEntityToModel(user), sessionToken.Token)
+	session.Scopes = claims.Scopes
+	session.AuthTime = claims.GetAuthTime()
+	if claims.ImpersonatorUuid != "" {
+		impersonator, err := s.getImpersonator(claims)
+		if err != nil {
+			return nil, err
+		}
+		session.Impersonator = mapper.MapUserEntityToModel(impersonator)
+	}
+	return session, nil
+}
+
+// Logout revokes the session token, so a copy of it can't be used after the
+// user logged out. Access tokens are revoked through their own endpoint, and
+// tokens that are no longer valid need nothing done.
+func (s *SessionService) Logout(sessionToken *model.SessionToken) error {
+	if sessionToken == nil || util.IsAccessToken(sessionToken.Token) {
+		return nil
+	}
+	claims, err := parseSessionClaims(sessionToken)
+	if err != nil || claims.ExpiresAt == nil {
+		return nil
+	}
+	return s.revokedSessionRepository.Revoke(util.HashSecret(sessionToken.Token), claims.ExpiresAt.Time)
+}
+
+func (s *SessionService) isLoggedOut(sessionToken *model.SessionToken) bool {
+	return s.revokedSessionRepository.IsRevoked(util.HashSecret(sessionToken.Token))
+}
+
+// getImpersonator returns the admin behind an impersonation token. The token
+// stops working as soon as the admin is banned, their sessions are revoked,
+// or their roles no longer let them impersonate.
+func (s *SessionService) getImpersonator(claims *model.Claims) (*entity.User, error) {
+	impersonatorUuid, err := uuid.Parse(claims.ImpersonatorUuid)
+	if err != nil {
+		return nil, err
+	}
+	impersonator, err := s.userRepository.GetUserFromUuid(impersonatorUuid)
+	if err != nil || impersonator.IsBanned || impersonator.IsSessionRevoked(claims) {
+		return nil, errors.New("session revoked")
+	}
+	err = s.roleRepository.LoadUserRoles(impersonator)
+	if err != nil || !impersonator.HasPermission(model.PermissionUserImpersonate) {
+		return nil, errors.New("session revoked")
+	}
+	return impersonator, nil
+}
+
+func (s *SessionService) getAccessTokenSession(sessionToken *model.SessionToken) (*model.Session, error) {
+	accessToken, err := s.accessTokenRepository.FindOneByHash(util.HashAccessToken(sessionToken.Token))
+	if err != nil || !accessToken.IsValid() || accessToken.User.IsBanned {
+		return nil, errors.New("token not valid")
+	}
+	s.touchAccessToken(accessToken)
+	session := model.CreateSession(mapper.MapUserEntityToModel(accessToken.User), sessionToken.Token)
+	session.Scopes = accessToken.GetScopes()
+	return session, nil
+}
+
+// touchAccessToken records that the token was used. Writes are limited to one
+// a minute per token.
+func (s *SessionService) touchAccessToken(accessToken *entity.AccessToken) {
+	now := time.Now()
+	if accessToken.LastUsedAt != nil && now.Sub(*accessToken.LastUsedAt) < time.Minute {
+		return
+	}
+	accessToken.LastUsedAt = &now
+	s.accessTokenRepository.Save(accessToken)
+}
+
+// getSessionUser loads the user a session belongs to.
+func getSessionUser(userRepository *repository.UserRepository, session *model.Session) (*entity.User, error) {
+	if session == nil || session.User == nil {
+		return nil, errors.New("user not defined")
+	}
+	userUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	return userRepository.GetUserFromUuid(userUuid)
+}
+
+// parseSessionClaims checks the signature and expiry of a session JWT. It
+// doesn't check whether the session was revoked.
+func parseSessionClaims(sessionToken *model.SessionToken) (*model.Claims, error) {
+	claims := &model.Claims{}
+	token, err := jwt.ParseWithClaims(sessionToken.Token, claims, func(token *jwt.Token) (interface{}, error) {
+		return util.JwtKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("token not valid")
+	}
+	return claims, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"testing"
+)
+
+func Test_Banned_User_Session_Is_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	sessionService := CreateTestSessionService()
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	_, _ = svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	user, _ := svc.userRepository.GetUserFromEmail(emailAddr)
+	user.IsBanned = true
+	svc.userRepository.Save(user)
+
+	// when
+	_, err := sessionService.GetSession(&model.SessionToken{Token: session.Token})
+
+	// then
+	if err == nil {
+		t.Error("expected the session of a banned user to be rejected")
+	}
+}
+
+func Test_Session_Revoked_In_Same_Second_Is_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	sessionService := CreateTestSessionService()
+
+	// given
+	user, _ := svc.CreateUserWithRole(model.USER)
+	session, _ := svc.userService.createSessionForUser(user)
+	user.RevokeSessions()
+	svc.userRepository.Save(user)
+
+	// when
+	_, err := sessionService.GetSession(&model.SessionToken{Token: session.Token})
+
+	// then
+	if err == nil {
+		t.Error("expected a session issued just before the revocation to be rejected")
+	}
+}
+
+func Test_Impersonation_Ends_When_Impersonator_Is_Demoted_Or_Banned(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	sessionService := CreateTestSessionService()
+
+	// given
+	admin, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	user, _ := svc.CreateUserWithRole(model.USER)
+	otherAdmin, otherAdminSession := svc.CreateUserWithRole(model.ADMIN)
+	demoted, _ := svc.ImpersonateUser(adminSession, user.Username)
+	banned, _ := svc.ImpersonateUser(otherAdminSession, user.Username)
+	userRole, _ := svc.roleRepository.FindOneByName(string(model.USER))
+
+	// when
+	_ = svc.roleRepository.ReplaceUserRoles(admin, []*entity.Role{userRole})
+	otherAdmin.IsBanned = true
+	svc.userRepository.Save(otherAdmin)
+
+	// then
+	if _, err := sessionService.GetSession(&model.SessionToken{Token: demoted.Token}); err == nil {
+		t.Error("expected the impersonation to end when the admin lost the permission")
+	}
+	if _, err := sessionService.GetSession(&model.SessionToken{Token: banned.Token}); err == nil {
+		t.Error("expected the impersonation to end when the admin was banned")
+	}
+}
+
+func Test_Logged_Out_Session_Is_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	sessionService := CreateTestSessionService()
+
+	// given
+	user, _ := svc.CreateUserWithRole(model.USER)
+	session, _ := svc.userService.createSessionForUser(user)
+	otherSession, _ := svc.userService.createSessionForUser(user)
+	sessionToken := &model.SessionToken{Token: session.Token}
+
+	// when
+	err := sessionService.Logout(sessionToken)
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sessionService.GetSession(sessionToken); err == nil {
+		t.Error("expected a logged out session to be rejected")
+	}
+	if _, err = sessionService.GetSession(&model.SessionToken{Token: otherSession.Token}); err != nil {
+		t.Error("expected the user's other sessions to keep working")
+	}
+}
@@ -11,14 +11,18 @@ import (
 
 type TestService struct {
 	userService      *UserService
+	userRepository   *repository.UserRepository
 	inviteRepository *repository.InviteRepository
+	roleRepository   *repository.RoleRepository
 }
 
 func CreateTestService() *TestService {
 	conn := util.SetupTestDatabase()
 	return &TestService{
 		userService:      CreateTestUserService(),
+		userRepository:   repository.CreateUserRepository(conn),
 		inviteRepository: repository.CreateInviteRepository(conn),
+		roleRepository:   repository.CreateRoleRepository(conn),
 	}
 }
 
@@ -44,11 +48,11 @@ func (t *TestService) UpdateUser(session *model.Session, user *model.User) error
 }
 
 func (t *TestService) GetUserFromUuid(viewerUser *model.User, uuid uuid.UUID) (*model.User, error) {
-	return t.userService.GetUserFromUuid(viewerUser, uuid)
+	return t.userService.GetUserFromUuid(t.viewerSession(viewerUser), uuid)
 }
 
 func (t *TestService) GetUserFromUsername(viewerUser *model.User, username string) (*model.User, error) {
-	return t.userService.GetUserFromUsername(viewerUser, username)
+	return t.userService.GetUserFromUsername(t.viewerSession(viewerUser), username)
 }
 
 func (t *TestService) CreateSession(newSession *model.NewSession) (*model.Session, error) {
@@ -67,6 +71,77 @@ func (t *TestService) ConfirmForgotPassword(otp *model.Otp) error {
 	return t.userService.ConfirmForgotPassword(otp)
 }
 
+func (t *TestService) ChangeRole(session *model.Session, userEntity *entity.User, role model.Role) error {
+	return t.userService.ChangeRole(session, userEntity, role)
+}
+
+func (t *TestService) GrantRole(session *model.Session, userEntity *entity.User, role model.Role) error {
+	return t.userService.GrantRole(session, userEntity, role)
+}
+
+func (t *TestService) BanUser(session *model.Session, userEntity *entity.User) error {
+	return t.userService.BanUser(session, userEntity)
+}
+
+func (t *TestService) ImpersonateUser(session *model.Session, username string) (*model.Session, error) {
+	return t.userService.ImpersonateUser(session, username, &model.Impersonation{
+		Reason: "testing",
+	})
+}
+
+// CreateUserWithRole creates a user holding only the given role and returns
+// its entity along with a session for it.
+func (t *TestService) CreateUserWithRole(role model.Role) (*entity.User, *model.Session) {
+	user, _ := t.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		Password: "fOobar12345!",
+	})
+	userEntity, _ := t.userRepository.GetUserFromUuid(uuid.MustParse(user.Uuid))
+	roleEntity, _ := t.roleRepository.FindOneByName(string(role))
+	_ = t.roleRepository.ReplaceUserRoles(userEntity, []*entity.Role{roleEntity})
+	return userEntity, model.CreateSession(mapper.MapUserEntityToModel(userEntity), "")
+}
+
+// CreateVerifiedUserWithRole is CreateUserWithRole for a user who verified
+// their email address.
+func (t *TestService) CreateVerifiedUserWithRole(role model.Role) (*entity.User, *model.Session) {
+	userEntity, session := t.CreateUserWithRole(role)
+	userEntity.Verified = true
+	t.userRepository.Save(userEntity)
+	return userEntity, session
+}
+
+// CreateServiceAccountSession creates a service account with the given scopes
+// and returns a session for a token issued to it.
+func (t *TestService) CreateServiceAccountSession(scopes ...model.Scope) (*model.Session, error) {
+	serviceAccountService := CreateTestServiceAccountService()
+	_, adminSession := t.CreateUserWithRole(model.ADMIN)
+	account, err := serviceAccountService.CreateServiceAccount(adminSession, &model.NewServiceAccount{
+		Name:   "service " + util.RandomUsername(),
+		Scopes: scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	token, err := serviceAccountService.IssueToken(&model.OAuthTokenRequest{
+		GrantType:    model.GrantTypeClientCredentials,
+		ClientId:     account.ClientId,
+		ClientSecret: account.ClientSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t.GetSession(&model.SessionToken{Token: token.AccessToken})
+}
+
+func (t *TestService) viewerSession(viewerUser *model.User) *model.Session {
+	if viewerUser == nil {
+		return nil
+	}
+	return model.CreateSession(viewerUser, "")
+}
+
 func (t *TestService) createInvite() (*model.Invite, error) {
 	invite := &entity.Invite{
 		Code: util.GenerateCode(),
@@ -7,21 +7,43 @@ import (
 	"github.com/google/uuid"
 	"github.com/third-place/user-service/internal/db"
 	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
 	"github.com/third-place/user-service/internal/kafka"
 	"github.com/third-place/user-service/internal/mapper"
 	"github.com/third-place/user-service/internal/model"
 	"github.com/third-place/user-service/internal/repository"
 	"github.com/third-place/user-service/internal/util"
 	"log"
+	"strings"
 	"time"
 )
 
+const impersonationLifetime = 30 * time.Minute
+
+// revokeCodeLifetime is how long the link in a new sign-in notice works. It
+// matches the lifetime of the session it warns about.
+const revokeCodeLifetime = 24 * 7 * time.Hour
+
+// RecentAuthMaxAge is how long after entering their password, or signing in
+// with a linked identity, a user may perform sensitive operations without
+// doing it again.
+const RecentAuthMaxAge = 10 * time.Minute
+
 type UserService struct {
-	userRepository   *repository.UserRepository
-	inviteRepository *repository.InviteRepository
-	mailService      *MailService
-	kafkaWriter      kafka.Producer
-	securityService  *SecurityService
+	userRepository         *repository.UserRepository
+	inviteRepository       *repository.InviteRepository
+	roleRepository         *repository.RoleRepository
+	accessTokenRepository  *repository.AccessTokenRepository
+	auditLogRepository     *repository.AuditLogRepository
+	loginAttemptRepository *repository.LoginAttemptRepository
+	registrationRepository *repository.RegistrationSettingsRepository
+	outboxRepository       *repository.OutboxEmailRepository
+	emailRepository        *repository.EmailRepository
+	mailService            *MailService
+	kafkaWriter            kafka.Producer
+	securityService        *SecurityService
+	sessionService         *SessionService
+	emailDomainService     *EmailDomainService
 }
 
 func CreateTestUserService() *UserService {
@@ -33,9 +55,18 @@ func CreateTestUserService() *UserService {
 	return &UserService{
 		repository.CreateUserRepository(conn),
 		repository.CreateInviteRepository(conn),
+		repository.CreateRoleRepository(conn),
+		repository.CreateAccessTokenRepository(conn),
+		repository.CreateAuditLogRepository(conn),
+		repository.CreateLoginAttemptRepository(conn),
+		repository.CreateRegistrationSettingsRepository(conn),
+		repository.CreateOutboxEmailRepository(conn),
+		repository.CreateEmailRepository(conn),
 		CreateTestMailService(),
 		writer,
-		CreateSecurityService(),
+		CreateTestSecurityService(),
+		CreateTestSessionService(),
+		CreateTestEmailDomainService(),
 	}
 }
 
@@ -48,50 +79,62 @@ func CreateUserService() *UserService {
 	return &UserService{
 		repository.CreateUserRepository(conn),
 		repository.CreateInviteRepository(conn),
+		repository.CreateRoleRepository(conn),
+		repository.CreateAccessTokenRepository(conn),
+		repository.CreateAuditLogRepository(conn),
+		repository.CreateLoginAttemptRepository(conn),
+		repository.CreateRegistrationSettingsRepository(conn),
+		repository.CreateOutboxEmailRepository(conn),
+		repository.CreateEmailRepository(conn),
 		CreateMailService(),
 		writer,
 		CreateSecurityService(),
+		CreateSessionService(),
+		CreateEmailDomainService(),
 	}
 }
 
-func isPrivileged(role model.Role) bool {
-	return role == model.MODERATOR || role == model.ADMIN
+func (s *UserService) canReadPii(viewer *model.Session) bool {
+	if viewer == nil {
+		return false
+	}
+	return s.securityService.Can(viewer, model.PermissionUserReadPii, nil)
 }
 
-func filterUserModelForAccess(viewerUser *model.User, user *model.User) *model.User {
-	if viewerUser == nil || (user.Uuid != viewerUser.Uuid && !isPrivileged(viewerUser.Role)) {
+func filterUserModelForAccess(viewer *model.Session, canReadPii bool, user *model.User) *model.User {
+	if viewer == nil || viewer.User == nil || (user.Uuid != viewer.User.Uuid && !canReadPii) {
 		user.Birthday = ""
 		user.Email = ""
 	}
 	return user
 }
 
-func filterUserModelsForAccess(viewerUser *model.User, users []*model.User) []*model.User {
+func filterUserModelsForAccess(viewer *model.Session, canReadPii bool, users []*model.User) []*model.User {
 	for i, user := range users {
-		users[i] = filterUserModelForAccess(viewerUser, user)
+		users[i] = filterUserModelForAccess(viewer, canReadPii, user)
 	}
 	return users
 }
 
-func (s *UserService) GetUserFromUsername(viewerUser *model.User, username string) (*model.User, error) {
+func (s *UserService) GetUserFromUsername(viewer *model.Session, username string) (*model.User, error) {
 	userEntity, err := s.userRepository.GetUserFromUsername(username)
 	if err != nil {
 		return nil, err
 	}
-	return filterUserModelForAccess(viewerUser, mapper.MapUserEntityToModel(userEntity)), nil
+	return filterUserModelForAccess(viewer, s.canReadPii(viewer), mapper.MapUserEntityToModel(userEntity)), nil
 }
 
-func (s *UserService) GetUsers(viewerUser *model.User, offset int) []*model.User {
+func (s *UserService) GetUsers(viewer *model.Session, offset int) []*model.User {
 	userEntities := s.userRepository.GetUsers(offset)
-	return filterUserModelsForAccess(viewerUser, mapper.MapUserEntitiesToModels(userEntities))
+	return filterUserModelsForAccess(viewer, s.canReadPii(viewer), mapper.MapUserEntitiesToModels(userEntities))
 }
 
-func (s *UserService) GetUserFromUuid(viewerUser *model.User, userUuid uuid.UUID) (*model.User, error) {
+func (s *UserService) GetUserFromUuid(viewer *model.Session, userUuid uuid.UUID) (*model.User, error) {
 	userEntity, err := s.userRepository.GetUserFromUuid(userUuid)
 	if err != nil {
 		return nil, err
 	}
-	return filterUserModelForAccess(viewerUser, mapper.MapUserEntityToModel(userEntity)), nil
+	return filterUserModelForAccess(viewer, s.canReadPii(viewer), mapper.MapUserEntityToModel(userEntity)), nil
 }
 
 func (s *UserService) CreateUser(newUser *model.NewUser) (*model.User, error) {
@@ -103,25 +146,24 @@ func (s *UserService) CreateUser(newUser *model.NewUser) (*model.User, error) {
 			"passwords must be at least 8 characters",
 		)
 	}
-	invite, err := s.inviteRepository.FindOneByCode(newUser.InviteCode)
+	invite, admittedByDomain, err := s.checkRegistration(newUser.InviteCode, newUser.Email)
 	if err != nil {
-		log.Print("error finding invite :: ", err)
-		return nil, util.NewInputFieldError(
-			"inviteCode",
-			"invite code not found",
-		)
-	}
-	if invite.Claimed {
-		log.Print("attempting to use a claimed invite :: ", newUser.Email, newUser.InviteCode)
-		return nil, util.NewInputFieldError(
-			"inviteCode",
-			"there was a problem with your invite code",
-		)
+		return nil, err
 	}
 	user := mapper.MapNewUserModelToEntity(newUser)
-	user.InviteID = invite.ID
-	result := s.userRepository.Create(user)
-	if result.Error != nil {
+	user.AdmittedByDomain = admittedByDomain
+	if invite != nil {
+		user.InviteID = invite.ID
+	}
+	user.OTP = util.GenerateCode()
+	user.Password, _ = util.HashPassword(newUser.Password)
+	email, err := s.mailService.CreateVerificationEmail(user)
+	if err != nil {
+		log.Print("error rendering verification email :: ", err)
+		return nil, errors.New("error creating user")
+	}
+	err = s.userRepository.CreateWithInvite(user, invite, email)
+	if err != nil {
 		search, _ := s.userRepository.GetUserFromUsername(newUser.Username)
 		if search != nil {
 			return nil, util.NewInputFieldError(
@@ -136,17 +178,9 @@ func (s *UserService) CreateUser(newUser *model.NewUser) (*model.User, error) {
 				"email already registered, try logging in",
 			)
 		}
-		return nil, errors.New("error creating user")
-	}
-	invite.Claimed = true
-	s.inviteRepository.Save(invite)
-	user.OTP = util.GenerateCode()
-	user.Password, _ = util.HashPassword(newUser.Password)
-	s.userRepository.Save(user)
-	_, err = s.mailService.SendVerificationEmail(user)
-	if err != nil {
-		log.Print(err)
+		return nil, s.createUserError(invite, err)
 	}
+	s.assignDefaultRole(user)
 	userModel := mapper.MapUserEntityToModel(user)
 	err = s.publishUserToKafka(user)
 	if err != nil {
@@ -155,8 +189,104 @@ func (s *UserService) CreateUser(newUser *model.NewUser) (*model.User, error) {
 	return userModel, nil
 }
 
+// checkRegistration enforces the registration mode for a new user, and
+// returns the invite they sign up with. The invite is nil when the mode lets
+// them in without one. An invite code is still used when one is given, so
+// the invite tree stays complete. admittedByDomain is set when only the
+// domain of the address let them in, which holds until they verify it.
+func (s *UserService) checkRegistration(code string, email string) (invite *entity.Invite, admittedByDomain bool, err error) {
+	settings := s.registrationRepository.Get()
+	if settings.Mode == enum.RegistrationModeClosed {
+		return nil, false, util.NewInputFieldError(
+			"registration",
+			"registration is closed",
+		)
+	}
+	if err := s.emailDomainService.CheckEmail(email); err != nil {
+		return nil, false, err
+	}
+	if code == "" && !settings.RequiresInvite(email) {
+		return nil, settings.Mode == enum.RegistrationModeOpenWithDomainAllowlist, nil
+	}
+	if code == "" && settings.Mode == enum.RegistrationModeOpenWithDomainAllowlist {
+		return nil, false, util.NewInputFieldError(
+			"inviteCode",
+			"an invite code is required for this email address",
+		)
+	}
+	invite, err = s.findUsableInvite(code, email)
+	return invite, false, err
+}
+
+// findUsableInvite finds the invite a new user signs up with, and returns
+// an InputFieldError when it can't be used.
+func (s *UserService) findUsableInvite(code string, email string) (*entity.Invite, error) {
+	invite, err := s.inviteRepository.FindOneByCode(code)
+	if err != nil {
+		log.Print("error finding invite :: ", err)
+		return nil, util.NewInputFieldError(
+			"inviteCode",
+			"invite code not found",
+		)
+	}
+	if invite.Revoked {
+		log.Print("attempting to use a revoked invite :: ", email, code)
+		return nil, util.NewInputFieldError(
+			"inviteCode",
+			"this invite code has been revoked",
+		)
+	}
+	if invite.IsExpired() {
+		return nil, util.NewInputFieldError(
+			"inviteCode",
+			"this invite code has expired",
+		)
+	}
+	if invite.IsUsedUp() {
+		log.Print("attempting to use a claimed invite :: ", email, code)
+		return nil, util.NewInputFieldError(
+			"inviteCode",
+			"this invite code has already been used",
+		)
+	}
+	if !invite.IsFor(email) {
+		log.Print("attempting to use an invite sent to another email :: ", email, code)
+		return nil, util.NewInputFieldError(
+			"inviteCode",
+			"this invite code was sent to a different email address",
+		)
+	}
+	return invite, nil
+}
+
+// createUserError explains why a new user couldn't be created once the
+// username and email address are known to be free.
+func (s *UserService) createUserError(invite *entity.Invite, err error) error {
+	if errors.Is(err, repository.ErrInviteUsedUp) {
+		log.Print("error claiming invite :: ", invite.Code, err)
+		return util.NewInputFieldError(
+			"inviteCode",
+			"this invite code has already been used",
+		)
+	}
+	log.Print("error creating user :: ", err)
+	return errors.New("error creating user")
+}
+
+func (s *UserService) assignDefaultRole(user *entity.User) {
+	defaultRole, err := s.roleRepository.FindOneByName(string(model.USER))
+	if err == nil {
+		err = s.roleRepository.ReplaceUserRoles(user, []*entity.Role{defaultRole})
+	}
+	if err != nil {
+		log.Print("error assigning default role :: ", err)
+	}
+}
+
+// UpdateUser changes the session user's profile. Tokens restricted to scopes
+// can only do what their scopes cover, and no scope covers this.
 func (s *UserService) UpdateUser(session *model.Session, userModel *model.User) error {
-	if session.User.Uuid != userModel.Uuid {
+	if session.Scopes != nil || session.User.Uuid != userModel.Uuid {
 		return errors.New("unauthorized")
 	}
 	userEntity, err := s.userRepository.GetUserFromUuid(uuid.MustParse(userModel.Uuid))
@@ -169,6 +299,48 @@ func (s *UserService) UpdateUser(session *model.Session, userModel *model.User)
 	return nil
 }
 
+// ChangeEmail moves the session user to a new email address. The new
+// address has to be verified with the code sent to it. Controllers must
+// require a recent authentication first.
+func (s *UserService) ChangeEmail(session *model.Session, emailChange *model.EmailChange) error {
+	if session == nil || session.User == nil || session.IsImpersonated() {
+		return errors.New("not allowed")
+	}
+	if err := s.emailDomainService.CheckEmail(emailChange.Email); err != nil {
+		return err
+	}
+	userUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	if err != nil {
+		return err
+	}
+	if user.IsServiceAccount {
+		return errors.New("not allowed")
+	}
+	if strings.EqualFold(user.Email, emailChange.Email) {
+		return nil
+	}
+	search, _ := s.userRepository.GetUserFromEmail(emailChange.Email)
+	if search != nil {
+		return util.NewInputFieldError(
+			"email",
+			"email already registered",
+		)
+	}
+	user.Email = emailChange.Email
+	user.Verified = false
+	user.OTP = util.GenerateCode()
+	err = s.saveWithEmail(user, s.mailService.CreateVerificationEmail)
+	if err != nil {
+		return err
+	}
+	_ = s.publishUserToKafka(user)
+	return nil
+}
+
 func (s *UserService) CreateSession(newSession *model.NewSession) (*model.Session, error) {
 	if newSession.Email == "" {
 		return nil, util.NewInputFieldError(
@@ -189,73 +361,278 @@ func (s *UserService) CreateSession(newSession *model.NewSession) (*model.Sessio
 			"email not found, do you need to sign up?",
 		)
 	}
-	if !util.CheckPasswordHash(newSession.Password, search.Password) {
+	if search.IsServiceAccount || !util.CheckPasswordHash(newSession.Password, search.Password) {
+		s.recordLoginAttempt(search, enum.LoginMethodPassword, false, newSession.Client)
 		return nil, errors.New("authentication failed")
 	}
-	token, err := s.getJWT(search)
+	s.recordLoginAttempt(search, enum.LoginMethodPassword, true, newSession.Client)
+	return s.createSessionForUser(search)
+}
+
+// Reauthenticate checks the session user's password again and issues a new
+// token with a fresh auth_time, for operations that need a recent login.
+// Users without a password reauthenticate through a linked identity instead,
+// see IdentityService.Callback.
+func (s *UserService) Reauthenticate(session *model.Session, reauth *model.Reauth) (*model.Session, error) {
+	if session == nil || session.User == nil || session.Scopes != nil ||
+		session.IsImpersonated() || util.IsAccessToken(session.Token) {
+		return nil, errors.New("not allowed")
+	}
+	if reauth.Password == "" {
+		return nil, util.NewInputFieldError(
+			"password",
+			"password is required",
+		)
+	}
+	userUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepository.GetUserFromUuid(userUuid)
 	if err != nil {
 		return nil, err
 	}
+	if user.IsServiceAccount || !util.CheckPasswordHash(reauth.Password, user.Password) {
+		s.recordLoginAttempt(user, enum.LoginMethodReauth, false, reauth.Client)
+		return nil, errors.New("authentication failed")
+	}
+	s.recordLoginAttempt(user, enum.LoginMethodReauth, true, reauth.Client)
+	return s.createSessionForUser(user)
+}
+
+// GetLoginHistory returns the session user's recent login attempts.
+func (s *UserService) GetLoginHistory(session *model.Session, offset int) ([]*model.LoginAttempt, error) {
+	if session == nil || session.User == nil || session.Scopes != nil {
+		return nil, errors.New("not allowed")
+	}
+	userUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapLoginAttemptEntitiesToModels(s.loginAttemptRepository.FindForUser(user, offset)), nil
+}
+
+// RevokeSessionsFromNotice handles the "this wasn't me" link in a new
+// sign-in notice by revoking every session and access token of the user.
+func (s *UserService) RevokeSessionsFromNotice(revocation *model.SessionRevocation) error {
+	if revocation.Code == "" {
+		return errors.New("code not valid")
+	}
+	attempt, err := s.loginAttemptRepository.FindOneByRevokeCodeHash(util.HashSecret(revocation.Code))
+	if err != nil {
+		return errors.New("code not valid")
+	}
+	if attempt.RevokedAt != nil {
+		return nil
+	}
+	if time.Since(attempt.CreatedAt) > revokeCodeLifetime {
+		return errors.New("code expired")
+	}
+	user, err := s.userRepository.GetUserFromId(attempt.UserID)
+	if err != nil {
+		return err
+	}
+	err = s.revokeSessions(user)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	attempt.RevokedAt = &now
+	s.loginAttemptRepository.Save(attempt)
+	return nil
+}
+
+// recordLoginAttempt adds the attempt to the user's login history. When a
+// login succeeds from a device or network the user hasn't logged in
+// from before, the user is sent a notice with a link to revoke their
+// sessions. The first login after signing up never triggers one.
+func (s *UserService) recordLoginAttempt(user *entity.User, method enum.LoginMethodType, success bool, client model.ClientInfo) {
+	attempt := &entity.LoginAttempt{
+		UserID:            user.ID,
+		Method:            method,
+		Success:           success,
+		IpAddress:         client.IpAddress,
+		IpRange:           util.GetIpRange(client.IpAddress),
+		UserAgent:         client.UserAgent,
+		DeviceFingerprint: util.GetDeviceFingerprint(client),
+	}
+	var revokeCode string
+	if success && method != enum.LoginMethodReauth && s.loginAttemptRepository.HasSucceeded(user) {
+		attempt.NewDevice = !s.loginAttemptRepository.IsKnownDevice(user, attempt.DeviceFingerprint) ||
+			!s.loginAttemptRepository.IsKnownIpRange(user, attempt.IpRange)
+	}
+	if attempt.NewDevice {
+		code, err := util.GenerateSecret(24)
+		if err != nil {
+			log.Print("error generating revoke code :: ", err)
+		} else {
+			revokeCode = code
+			attempt.RevokeCodeHash = util.HashSecret(code)
+		}
+	}
+	if revokeCode != "" {
+		email, err := s.mailService.CreateNewSignInEmail(user, attempt, revokeCode)
+		if err == nil {
+			err = s.outboxRepository.SaveWithEmail(attempt, email)
+			if err != nil {
+				log.Print("error recording login attempt :: ", err)
+			}
+			return
+		}
+		log.Print("error creating new sign-in email :: ", err)
+	}
+	result := s.loginAttemptRepository.Create(attempt)
+	if result.Error != nil {
+		log.Print("error recording login attempt :: ", result.Error)
+	}
+}
+
+// createSessionForUser logs the user in. Users who only got in because of
+// the domain of their address can't log in before they prove they own it.
+func (s *UserService) createSessionForUser(user *entity.User) (*model.Session, error) {
+	if user.AdmittedByDomain && !user.Verified {
+		return nil, util.NewInputFieldError(
+			"email",
+			"verify your email address before logging in",
+		)
+	}
+	token, err := s.getJWT(user)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
 	return &model.Session{
-		Token: token,
-		User:  mapper.MapUserEntityToModel(search),
+		Token:    token,
+		User:     mapper.MapUserEntityToModel(user),
+		AuthTime: &now,
 	}, nil
 }
 
+// SetEmailDeliveryStatus flags the session when the user's address bounced
+// or reported our email as spam, so clients can ask them to update it.
+func (s *UserService) SetEmailDeliveryStatus(session *model.Session) {
+	if session.User == nil {
+		return
+	}
+	email, err := s.emailRepository.FindOneByEmail(session.User.Email)
+	if err == nil && email.DeliveryStatus.IsUndeliverable() {
+		session.EmailDeliveryStatus = string(email.DeliveryStatus)
+	}
+}
+
 func (s *UserService) GetSession(sessionToken *model.SessionToken) (*model.Session, error) {
-	claims := &model.Claims{}
-	token, err := jwt.ParseWithClaims(sessionToken.Token, claims, func(token *jwt.Token) (interface{}, error) {
-		return util.JwtKey, nil
-	})
+	return s.sessionService.GetSession(sessionToken)
+}
+
+// ImpersonateUser issues a short-lived session for the user with the given
+// username on behalf of an admin. Only interactive sessions can impersonate,
+// and every impersonation is recorded in the audit log before the token is
+// issued.
+func (s *UserService) ImpersonateUser(session *model.Session, username string, impersonation *model.Impersonation) (*model.Session, error) {
+	if session == nil || session.User == nil || session.Scopes != nil ||
+		session.IsImpersonated() || util.IsAccessToken(session.Token) {
+		return nil, errors.New("not allowed")
+	}
+	target, err := s.userRepository.GetUserFromUsername(username)
 	if err != nil {
 		return nil, err
 	}
-	if !token.Valid {
-		return nil, errors.New("token not valid")
+	if target.IsServiceAccount || !s.securityService.Can(session, model.PermissionUserImpersonate, target) {
+		return nil, errors.New("not allowed")
 	}
-	userUuid, err := uuid.Parse(claims.UserUuid)
+	actorUuid, err := uuid.Parse(session.User.Uuid)
 	if err != nil {
 		return nil, err
 	}
-	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	actor, err := s.userRepository.GetUserFromUuid(actorUuid)
+	if err != nil {
+		return nil, err
+	}
+	result := s.auditLogRepository.Create(&entity.AuditLog{
+		Action:    enum.AuditActionImpersonationStarted,
+		ActorID:   actor.ID,
+		SubjectID: target.ID,
+		Reason:    impersonation.Reason,
+		IpAddress: impersonation.IpAddress,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	claims := model.NewImpersonationClaims(target.Uuid, actor.Uuid, impersonationLifetime)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(util.JwtKey)
 	if err != nil {
 		return nil, err
 	}
-	return model.CreateSession(mapper.MapUserEntityToModel(user), sessionToken.Token), nil
+	targetModel := mapper.MapUserEntityToModel(target)
+	err = s.publishUserEvent(model.CreateUserEvent(
+		model.UserEventImpersonated,
+		targetModel,
+		actor.Uuid.String(),
+		map[string]string{
+			"reason": impersonation.Reason,
+		},
+	))
+	if err != nil {
+		log.Print("error publishing to kafka :: ", err)
+	}
+	impersonated := model.CreateSession(targetModel, token)
+	impersonated.Impersonator = mapper.MapUserEntityToModel(actor)
+	return impersonated, nil
 }
 
 func (s *UserService) RefreshSession(sessionToken *model.SessionToken) (*model.SessionToken, error) {
-	claims := &model.Claims{}
-	token, err := jwt.ParseWithClaims(sessionToken.Token, claims, func(token *jwt.Token) (interface{}, error) {
-		return util.JwtKey, nil
-	})
-	if err != nil || !token.Valid {
+	claims, err := parseSessionClaims(sessionToken)
+	if err != nil {
 		return nil, err
 	}
+	if s.sessionService.isLoggedOut(sessionToken) {
+		return nil, errors.New("session revoked")
+	}
+	userUuid, err := uuid.Parse(claims.UserUuid)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	if err != nil || user.IsBanned || user.IsSessionRevoked(claims) {
+		return nil, errors.New("session revoked")
+	}
+	if user.IsServiceAccount {
+		return nil, errors.New("service account tokens cannot be refreshed")
+	}
+	if claims.ImpersonatorUuid != "" {
+		return nil, errors.New("impersonation tokens cannot be refreshed")
+	}
 	if time.Until(claims.ExpiresAt.Time) > 24*4*time.Hour {
 		return nil, errors.New("token not ready for refresh")
 	}
 	expirationTime := time.Now().Add(1 * time.Hour)
 	claims.ExpiresAt = jwt.NewNumericDate(expirationTime)
-	token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(util.JwtKey)
 	return &model.SessionToken{
 		Token: tokenString,
 	}, nil
 }
 
-func (s *UserService) BanUser(sessionUser *entity.User, userEntity *entity.User) error {
-	if !s.canAdminister(sessionUser, userEntity) {
+func (s *UserService) BanUser(session *model.Session, userEntity *entity.User) error {
+	if !s.securityService.Can(session, model.PermissionUserBan, userEntity) {
 		return errors.New("cannot ban user")
 	}
 	userEntity.IsBanned = true
-	s.userRepository.Save(userEntity)
+	err := s.revokeSessions(userEntity)
+	if err != nil {
+		return err
+	}
 	_ = s.publishUserToKafka(userEntity)
 	return nil
 }
 
-func (s *UserService) UnbanUser(sessionUser *entity.User, userEntity *entity.User) error {
-	if !s.canAdminister(sessionUser, userEntity) {
+func (s *UserService) UnbanUser(session *model.Session, userEntity *entity.User) error {
+	if !s.securityService.Can(session, model.PermissionUserBan, userEntity) {
 		return errors.New("cannot ban user")
 	}
 	userEntity.IsBanned = false
@@ -264,6 +641,61 @@ func (s *UserService) UnbanUser(sessionUser *entity.User, userEntity *entity.Use
 	return nil
 }
 
+// ChangeRole replaces all of the user's roles with the given one.
+func (s *UserService) ChangeRole(session *model.Session, userEntity *entity.User, role model.Role) error {
+	roleEntity, err := s.findRole(role)
+	if err != nil {
+		return err
+	}
+	err = s.roleRepository.LoadUserRoles(userEntity)
+	if err != nil {
+		return err
+	}
+	return s.updateRoles(session, userEntity, []*entity.Role{roleEntity})
+}
+
+// GrantRole adds a role to the roles the user already holds.
+func (s *UserService) GrantRole(session *model.Session, userEntity *entity.User, role model.Role) error {
+	roleEntity, err := s.findRole(role)
+	if err != nil {
+		return err
+	}
+	err = s.roleRepository.LoadUserRoles(userEntity)
+	if err != nil {
+		return err
+	}
+	roles := []*entity.Role{roleEntity}
+	for _, existing := range userEntity.Roles {
+		if existing.ID == roleEntity.ID {
+			return nil
+		}
+		roles = append(roles, existing)
+	}
+	return s.updateRoles(session, userEntity, roles)
+}
+
+// RevokeRole takes a role away from the user.
+func (s *UserService) RevokeRole(session *model.Session, userEntity *entity.User, role model.Role) error {
+	roleEntity, err := s.findRole(role)
+	if err != nil {
+		return err
+	}
+	err = s.roleRepository.LoadUserRoles(userEntity)
+	if err != nil {
+		return err
+	}
+	var roles []*entity.Role
+	for _, existing := range userEntity.Roles {
+		if existing.ID != roleEntity.ID {
+			roles = append(roles, existing)
+		}
+	}
+	if len(roles) == len(userEntity.Roles) {
+		return nil
+	}
+	return s.updateRoles(session, userEntity, roles)
+}
+
 func (s *UserService) SubmitOTP(otp *model.Otp) error {
 	userEntity, err := s.userRepository.GetUserFromEmail(otp.User.Email)
 	if err != nil {
@@ -274,6 +706,8 @@ func (s *UserService) SubmitOTP(otp *model.Otp) error {
 	}
 	userEntity.Verified = true
 	s.userRepository.Save(userEntity)
+	// the code got through, so the address works again
+	s.emailRepository.MarkDeliverable(userEntity.Email)
 	return nil
 }
 
@@ -282,13 +716,21 @@ func (s *UserService) ForgotPassword(user *model.User) error {
 	if err != nil {
 		return err
 	}
+	if userEntity.IsServiceAccount {
+		return errors.New("service accounts have no password")
+	}
 	userEntity.OTP = util.GenerateCode()
-	s.userRepository.Save(userEntity)
-	_, err = s.mailService.SendPasswordResetEmail(userEntity)
+	return s.saveWithEmail(userEntity, s.mailService.CreatePasswordResetEmail)
+}
+
+// saveWithEmail saves the user and queues the email about the change in the
+// same transaction, so the email can't get lost when delivery fails.
+func (s *UserService) saveWithEmail(user *entity.User, createEmail func(*entity.User) (*entity.OutboxEmail, error)) error {
+	email, err := createEmail(user)
 	if err != nil {
-		log.Print(err)
+		return err
 	}
-	return nil
+	return s.outboxRepository.SaveWithEmail(user, email)
 }
 
 func (s *UserService) ConfirmForgotPassword(otp *model.Otp) error {
@@ -296,6 +738,9 @@ func (s *UserService) ConfirmForgotPassword(otp *model.Otp) error {
 	if err != nil {
 		return err
 	}
+	if userEntity.IsServiceAccount {
+		return errors.New("service accounts have no password")
+	}
 	if userEntity.OTP != otp.Code {
 		return errors.New("validation failed")
 	}
@@ -310,11 +755,12 @@ func (s *UserService) ConfirmForgotPassword(otp *model.Otp) error {
 	}
 	userEntity.Verified = true
 	s.userRepository.Save(userEntity)
+	s.emailRepository.MarkDeliverable(userEntity.Email)
 	return nil
 }
 
 func (s *UserService) GetInvites(session *model.Session, offset int) ([]*model.Invite, error) {
-	if !s.securityService.IsModerator(session) {
+	if !s.securityService.Can(session, model.PermissionInviteList, nil) {
 		return nil, errors.New("not allowed")
 	}
 	invites := s.inviteRepository.FindInvites(offset)
@@ -329,12 +775,21 @@ func (s *UserService) GetInvite(code string) (*model.Invite, error) {
 	return mapper.MapInviteEntityToModel(invite), nil
 }
 
-func (s *UserService) CreateInviteFromCode(session *model.Session, code string) (*model.Invite, error) {
-	if !s.securityService.IsModerator(session) {
+func (s *UserService) CreateInviteFromCode(session *model.Session, code string, newInvite *model.NewInvite) (*model.Invite, error) {
+	if !s.securityService.Can(session, model.PermissionInviteCreate, nil) {
 		return nil, errors.New("not allowed")
 	}
-	invite := &entity.Invite{
-		Code: code,
+	creatorUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	creator, err := s.userRepository.GetUserFromUuid(creatorUuid)
+	if err != nil {
+		return nil, err
+	}
+	invite, err := buildInvite(creator, code, newInvite)
+	if err != nil {
+		return nil, err
 	}
 	result := s.inviteRepository.Create(invite)
 	if result.Error != nil {
@@ -343,24 +798,150 @@ func (s *UserService) CreateInviteFromCode(session *model.Session, code string)
 	return mapper.MapInviteEntityToModel(invite), nil
 }
 
+// buildInvite validates the limits asked for a new invite.
+func buildInvite(creator *entity.User, code string, newInvite *model.NewInvite) (*entity.Invite, error) {
+	if newInvite.MaxUses == 0 {
+		newInvite.MaxUses = 1
+	}
+	if newInvite.MaxUses < 0 {
+		return nil, util.NewInputFieldError(
+			"maxUses",
+			"invites must allow at least one use",
+		)
+	}
+	if newInvite.ExpiresAt != nil && newInvite.ExpiresAt.Before(time.Now()) {
+		return nil, util.NewInputFieldError(
+			"expiresAt",
+			"expiry must be in the future",
+		)
+	}
+	return &entity.Invite{
+		Code:      code,
+		CreatorID: &creator.ID,
+		ExpiresAt: newInvite.ExpiresAt,
+		MaxUses:   newInvite.MaxUses,
+		Email:     strings.TrimSpace(newInvite.Email),
+	}, nil
+}
+
+// RevokeInvite stops anyone else from signing up with the invite. Users who
+// already did keep their accounts. Members can cancel their own invites.
+func (s *UserService) RevokeInvite(session *model.Session, code string) error {
+	invite, err := s.inviteRepository.FindOneByCode(code)
+	if err != nil {
+		return err
+	}
+	if !s.isInviteCreator(session, invite) && !s.securityService.Can(session, model.PermissionInviteRevoke, nil) {
+		return errors.New("not allowed")
+	}
+	invite.Revoked = true
+	if invite.IsPending() {
+		invite.Status = enum.InviteStatusCancelled
+	}
+	return s.inviteRepository.Save(invite).Error
+}
+
+func (s *UserService) isInviteCreator(session *model.Session, invite *entity.Invite) bool {
+	if session == nil || session.User == nil || invite.CreatorID == nil ||
+		session.Scopes != nil || session.IsImpersonated() {
+		return false
+	}
+	userUuid, err := uuid.Parse(session.User.Uuid)
+	if err != nil {
+		return false
+	}
+	user, err := s.userRepository.GetUserFromUuid(userUuid)
+	return err == nil && !user.IsBanned && user.ID == *invite.CreatorID
+}
+
+// publishUserToKafka publishes the user for other services. Service accounts
+// aren't members of the community, so they are never published.
 func (s *UserService) publishUserToKafka(userEntity *entity.User) error {
+	if userEntity.IsServiceAccount {
+		return nil
+	}
 	topic := "users"
 	userModel := mapper.MapUserEntityToModel(userEntity)
 	userData, _ := json.Marshal(userModel)
 	return s.kafkaWriter.Produce(kafka.CreateMessage(userData, topic), nil)
 }
 
-func (s *UserService) canAdminister(sessionUser *entity.User, user *entity.User) bool {
-	if sessionUser.IsBanned || sessionUser.Role == "user" {
-		return false
+func (s *UserService) publishUserEvent(event *model.UserEvent) error {
+	topic := "user-events"
+	eventData, _ := json.Marshal(event)
+	return s.kafkaWriter.Produce(kafka.CreateMessage(eventData, topic), nil)
+}
+
+func (s *UserService) findRole(role model.Role) (*entity.Role, error) {
+	roleEntity, err := s.roleRepository.FindOneByName(string(role))
+	if err != nil {
+		return nil, util.NewInputFieldError(
+			"role",
+			"role not recognized",
+		)
 	}
-	if user.Role == "moderator" {
-		return sessionUser.Role == "admin"
+	return roleEntity, nil
+}
+
+// updateRoles checks that the session user may assign every role being added
+// or removed, then saves the change, revokes the user's sessions and access
+// tokens and lets the rest of the platform know.
+func (s *UserService) updateRoles(session *model.Session, userEntity *entity.User, roles []*entity.Role) error {
+	changed := map[uint]*entity.Role{}
+	for _, role := range roles {
+		changed[role.ID] = role
+	}
+	for _, role := range userEntity.Roles {
+		if _, ok := changed[role.ID]; ok {
+			delete(changed, role.ID)
+		} else {
+			changed[role.ID] = role
+		}
 	}
-	if user.Role == "admin" {
-		return false
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, role := range changed {
+		if !s.securityService.CanAssignRole(session, role, userEntity) {
+			return errors.New("cannot change role")
+		}
+	}
+	oldRole := userEntity.Role
+	userEntity.RevokeSessions()
+	err := s.roleRepository.ReplaceUserRoles(userEntity, roles)
+	if err != nil {
+		return err
+	}
+	_ = s.publishUserToKafka(userEntity)
+	roleNames := make([]string, len(roles))
+	for i, role := range roles {
+		roleNames[i] = role.Name
+	}
+	err = s.publishUserEvent(model.CreateUserEvent(
+		model.UserEventRoleChanged,
+		mapper.MapUserEntityToModel(userEntity),
+		session.User.Uuid,
+		map[string]string{
+			"old_role": oldRole,
+			"new_role": userEntity.Role,
+			"roles":    strings.Join(roleNames, ","),
+		},
+	))
+	if err != nil {
+		log.Print("error publishing to kafka :: ", err)
+	}
+	return nil
+}
+
+// revokeSessions signs the user out everywhere, and revokes their personal
+// access tokens too.
+func (s *UserService) revokeSessions(user *entity.User) error {
+	user.RevokeSessions()
+	result := s.userRepository.Save(user)
+	if result.Error != nil {
+		return result.Error
 	}
-	return true
+	return s.accessTokenRepository.RevokeForUser(user).Error
 }
 
 func (s *UserService) getJWT(user *entity.User) (string, error) {
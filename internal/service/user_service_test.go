@@ -2,6 +2,8 @@ package service
 
 import (
 	"github.com/google/uuid"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
 	"github.com/third-place/user-service/internal/model"
 	"github.com/third-place/user-service/internal/repository"
 	"github.com/third-place/user-service/internal/util"
@@ -520,3 +522,567 @@ func Test_Can_Reset_Password(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_Admin_Can_Promote_To_Moderator(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	userRepository := repository.CreateUserRepository(util.SetupTestDatabase())
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	userEntity, _ := svc.CreateUserWithRole(model.USER)
+
+	// when
+	err := svc.ChangeRole(adminSession, userEntity, model.MODERATOR)
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	userEntity, _ = userRepository.GetUserFromUuid(userEntity.Uuid)
+	if userEntity.Role != string(model.MODERATOR) || userEntity.SessionsRevokedAt == nil {
+		t.Fail()
+	}
+}
+
+func Test_Only_SuperAdmin_Can_Create_Admins(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	userRepository := repository.CreateUserRepository(util.SetupTestDatabase())
+
+	// given
+	adminEntity, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	userEntity, _ := svc.CreateUserWithRole(model.USER)
+
+	// when
+	err := svc.ChangeRole(adminSession, userEntity, model.ADMIN)
+
+	// then
+	if err == nil {
+		t.Error("expected only a super-admin to be able to create admins")
+	}
+
+	// when
+	adminEntity.IsSuperAdmin = true
+	userRepository.Save(adminEntity)
+	err = svc.ChangeRole(adminSession, userEntity, model.ADMIN)
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_User_Can_Hold_Several_Roles(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	roleService := CreateTestRoleService()
+	securityService := CreateTestSecurityService()
+
+	// given
+	adminEntity, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	adminEntity.IsSuperAdmin = true
+	repository.CreateUserRepository(util.SetupTestDatabase()).Save(adminEntity)
+	roleName := model.Role("inviter-" + util.RandomUsername())
+	_, err := roleService.CreateRole(adminSession, &model.RoleDefinition{
+		Name:        roleName,
+		Level:       10,
+		Permissions: []model.Permission{model.PermissionInviteCreate},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	userEntity, userSession := svc.CreateUserWithRole(model.USER)
+
+	// when
+	err = svc.GrantRole(adminSession, userEntity, roleName)
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if !securityService.Can(userSession, model.PermissionInviteCreate, nil) {
+		t.Error("expected the granted role to give its permissions")
+	}
+	if securityService.Can(userSession, model.PermissionUserBan, nil) {
+		t.Error("expected permissions outside of the user's roles to be denied")
+	}
+}
+
+func Test_Role_Manager_Cannot_Bundle_Permissions_They_Lack(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	roleService := CreateTestRoleService()
+
+	// given
+	adminEntity, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	adminEntity.IsSuperAdmin = true
+	repository.CreateUserRepository(util.SetupTestDatabase()).Save(adminEntity)
+	managerRole := model.Role("role-manager-" + util.RandomUsername())
+	_, err := roleService.CreateRole(adminSession, &model.RoleDefinition{
+		Name:        managerRole,
+		Level:       60,
+		Permissions: []model.Permission{model.PermissionRoleManage, model.PermissionUserBan},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	moderatorEntity, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	err = svc.GrantRole(adminSession, moderatorEntity, managerRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	_, err = roleService.CreateRole(moderatorSession, &model.RoleDefinition{
+		Name:        model.Role("impersonator-" + util.RandomUsername()),
+		Level:       10,
+		Permissions: []model.Permission{model.PermissionUserImpersonate},
+	})
+
+	// then
+	if err == nil {
+		t.Error("expected a moderator to be unable to bundle a permission they lack")
+	}
+
+	// when
+	_, err = roleService.CreateRole(moderatorSession, &model.RoleDefinition{
+		Name:        model.Role("banner-" + util.RandomUsername()),
+		Level:       10,
+		Permissions: []model.Permission{model.PermissionUserBan},
+	})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Moderator_Cannot_Ban_Moderator(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+
+	// given
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	otherModerator, _ := svc.CreateUserWithRole(model.MODERATOR)
+	userEntity, _ := svc.CreateUserWithRole(model.USER)
+
+	// when
+	err := svc.BanUser(moderatorSession, otherModerator)
+
+	// then
+	if err == nil {
+		t.Error("expected a moderator to be unable to ban another moderator")
+	}
+
+	// when
+	err = svc.BanUser(moderatorSession, userEntity)
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Revoked_Session_Is_Rejected(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	userRepository := repository.CreateUserRepository(util.SetupTestDatabase())
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	user, _ := svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	userEntity, _ := userRepository.GetUserFromUuid(uuid.MustParse(user.Uuid))
+	userEntity.RevokeSessions()
+	userRepository.Save(userEntity)
+
+	// when
+	getSession, err := svc.GetSession(&model.SessionToken{
+		Token: session.Token,
+	})
+
+	// then
+	if getSession != nil || err == nil {
+		t.Fail()
+	}
+}
+
+func Test_Admin_Can_Impersonate_User(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+
+	// given
+	admin, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	user, _ := svc.CreateUserWithRole(model.USER)
+
+	// when
+	impersonated, err := svc.ImpersonateUser(adminSession, user.Username)
+	if err != nil {
+		t.Error(err)
+	}
+	session, err := svc.GetSession(&model.SessionToken{
+		Token: impersonated.Token,
+	})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if session.User.Uuid != user.Uuid.String() ||
+		session.Impersonator == nil ||
+		session.Impersonator.Uuid != admin.Uuid.String() {
+		t.Error("expected the session to mark both the user and the impersonator")
+	}
+	_, err = accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name: "sneaky",
+	})
+	if err == nil {
+		t.Error("expected impersonated sessions to be unable to create tokens")
+	}
+}
+
+func Test_Impersonation_Requires_Outranking_The_User(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+
+	// given
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	user, _ := svc.CreateUserWithRole(model.USER)
+	otherAdmin, _ := svc.CreateUserWithRole(model.ADMIN)
+
+	// when
+	_, moderatorErr := svc.ImpersonateUser(moderatorSession, user.Username)
+	_, adminErr := svc.ImpersonateUser(adminSession, otherAdmin.Username)
+
+	// then
+	if moderatorErr == nil {
+		t.Error("expected moderators to be unable to impersonate")
+	}
+	if adminErr == nil {
+		t.Error("expected admins to be unable to impersonate admins")
+	}
+}
+
+func Test_Reauthenticate_Refreshes_AuthTime(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	_, _ = svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+
+	// when
+	_, badErr := svc.userService.Reauthenticate(session, &model.Reauth{
+		Password: "wrong password",
+	})
+	reauthed, err := svc.userService.Reauthenticate(session, &model.Reauth{
+		Password: dummyPassword,
+	})
+
+	// then
+	if badErr == nil {
+		t.Error("expected a wrong password to be rejected")
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	getSession, _ := svc.GetSession(&model.SessionToken{Token: reauthed.Token})
+	if util.RequireRecentAuth(getSession, time.Minute) != nil {
+		t.Error("expected the new session to count as recently authenticated")
+	}
+}
+
+func Test_Impersonated_Session_Is_Never_Recently_Authenticated(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+
+	// given
+	_, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	user, _ := svc.CreateUserWithRole(model.USER)
+	impersonated, _ := svc.ImpersonateUser(adminSession, user.Username)
+
+	// when
+	session, _ := svc.GetSession(&model.SessionToken{Token: impersonated.Token})
+	err := util.RequireRecentAuth(session, time.Hour)
+
+	// then
+	if reauthErr, ok := err.(*util.ReauthRequiredError); !ok || reauthErr.Code != util.ReauthRequiredCode {
+		t.Error("expected a reauth_required error")
+	}
+	if svc.userService.ChangeEmail(session, &model.EmailChange{Email: util.RandomEmailAddress()}) == nil {
+		t.Error("expected impersonated sessions to be unable to change email")
+	}
+}
+
+func Test_Change_Email_Requires_Verifying_The_New_Address(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+
+	// given
+	user, session := svc.CreateUserWithRole(model.USER)
+	newEmail := util.RandomEmailAddress()
+
+	// when
+	err := svc.userService.ChangeEmail(session, &model.EmailChange{Email: newEmail})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	updated, _ := svc.userRepository.GetUserFromUuid(user.Uuid)
+	if updated.Email != newEmail || updated.Verified {
+		t.Error("expected the new email to be saved unverified")
+	}
+}
+
+func Test_Login_Attempts_Are_Recorded(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	_, _ = svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	client := model.ClientInfo{IpAddress: "10.0.0.1", UserAgent: "laptop"}
+
+	// when
+	_, _ = svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: "wrong password",
+		Client:   client,
+	})
+	_, _ = svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: dummyPassword,
+		Client:   client,
+	})
+	_, _ = svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: dummyPassword,
+		Client:   client,
+	})
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: dummyPassword,
+		Client:   model.ClientInfo{IpAddress: "192.168.5.5", UserAgent: "phone"},
+	})
+
+	// then
+	history, err := svc.userService.GetLoginHistory(session, 0)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(history) != 4 {
+		t.Fatal("expected every attempt to be recorded")
+	}
+	if !history[0].NewDevice || history[1].NewDevice || history[2].NewDevice || history[3].Success {
+		t.Error("expected only the login from the phone to be a new device")
+	}
+}
+
+func Test_Sign_In_Notice_Code_Revokes_Sessions(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	accessTokenService := CreateTestAccessTokenService()
+	loginAttemptRepository := repository.CreateLoginAttemptRepository(util.SetupTestDatabase())
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	_, _ = svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	session, _ := svc.CreateSession(&model.NewSession{
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	accessToken, _ := accessTokenService.CreateAccessToken(session, &model.NewAccessToken{
+		Name: "stolen",
+	})
+	user, _ := svc.userRepository.GetUserFromEmail(emailAddr)
+	loginAttemptRepository.Create(&entity.LoginAttempt{
+		UserID:         user.ID,
+		Method:         enum.LoginMethodPassword,
+		Success:        true,
+		NewDevice:      true,
+		RevokeCodeHash: util.HashSecret("not-me"),
+	})
+
+	// when
+	err := svc.userService.RevokeSessionsFromNotice(&model.SessionRevocation{
+		Code: "not-me",
+	})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+	if _, err := svc.GetSession(&model.SessionToken{Token: session.Token}); err == nil {
+		t.Error("expected the session to be revoked")
+	}
+	if _, err := svc.GetSession(&model.SessionToken{Token: accessToken.Token}); err == nil {
+		t.Error("expected the access token to be revoked")
+	}
+}
+
+func Test_Invite_Errors_Explain_Why(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	expired := time.Now().Add(-time.Hour)
+
+	// given
+	invites := map[string]*entity.Invite{
+		"this invite code has expired":                           {Code: util.GenerateCode(), MaxUses: 1, ExpiresAt: &expired},
+		"this invite code has been revoked":                      {Code: util.GenerateCode(), MaxUses: 1, Revoked: true},
+		"this invite code was sent to a different email address": {Code: util.GenerateCode(), MaxUses: 1, Email: util.RandomEmailAddress()},
+	}
+
+	for message, invite := range invites {
+		svc.inviteRepository.Create(invite)
+
+		// when
+		_, err := svc.CreateUser(&model.Invite{Code: invite.Code}, &model.NewUser{
+			Username: util.RandomUsername(),
+			Email:    util.RandomEmailAddress(),
+			Password: dummyPassword,
+		})
+
+		// then
+		if fieldErr, ok := err.(*util.InputFieldError); !ok || fieldErr.Message != message {
+			t.Error("expected :: ", message, ", got :: ", err)
+		}
+	}
+}
+
+func Test_Invite_Can_Be_Used_Up_To_Max_Uses(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+
+	// given
+	invite, err := svc.userService.CreateInviteFromCode(moderatorSession, util.GenerateCode(), &model.NewInvite{
+		MaxUses: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	var errs []error
+	for i := 0; i < 3; i++ {
+		_, err = svc.CreateUser(invite, &model.NewUser{
+			Username: util.RandomUsername(),
+			Email:    util.RandomEmailAddress(),
+			Password: dummyPassword,
+		})
+		errs = append(errs, err)
+	}
+
+	// then
+	if errs[0] != nil || errs[1] != nil {
+		t.Error("expected the first two sign-ups to succeed")
+	}
+	if errs[2] == nil {
+		t.Error("expected the third sign-up to fail")
+	}
+	updated, _ := svc.userService.GetInvite(invite.Code)
+	if updated.Uses != 2 || !updated.Claimed {
+		t.Error("expected the invite to be used up")
+	}
+}
+
+func Test_Invite_Locked_To_Email_Accepts_That_Email(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	emailAddr := util.RandomEmailAddress()
+
+	// given
+	invite := &entity.Invite{Code: util.GenerateCode(), MaxUses: 1, Email: emailAddr}
+	svc.inviteRepository.Create(invite)
+
+	// when
+	_, err := svc.CreateUser(&model.Invite{Code: invite.Code}, &model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+
+	// then
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Moderator_Can_Revoke_Invite(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	_, userSession := svc.CreateUserWithRole(model.USER)
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+	invite, _ := svc.CreateInvite()
+
+	// when
+	userErr := svc.userService.RevokeInvite(userSession, invite.Code)
+	moderatorErr := svc.userService.RevokeInvite(moderatorSession, invite.Code)
+
+	// then
+	if userErr == nil {
+		t.Error("expected a regular user not to be able to revoke invites")
+	}
+	if moderatorErr != nil {
+		t.Error(moderatorErr)
+	}
+	revoked, _ := svc.userService.GetInvite(invite.Code)
+	if !revoked.Revoked {
+		t.Error("expected the invite to be revoked")
+	}
+}
+
+func Test_Sign_Up_Is_Undone_When_The_Invite_Is_Used_Up(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+
+	// given
+	inviter, _ := svc.CreateUserWithRole(model.USER)
+	invite := &entity.Invite{Code: util.GenerateCode(), MaxUses: 1, CreatorID: &inviter.ID}
+	svc.userService.inviteRepository.Create(invite)
+	_ = svc.userService.inviteRepository.Claim(invite)
+	user := &entity.User{
+		Username: util.RandomUsername(),
+		Email:    util.RandomEmailAddress(),
+		InviteID: invite.ID,
+	}
+	email, _ := svc.userService.mailService.CreateVerificationEmail(user)
+
+	// when
+	err := svc.userService.userRepository.CreateWithInvite(user, invite, email)
+
+	// then
+	if err != repository.ErrInviteUsedUp {
+		t.Errorf("expected the used up invite to fail the sign-up, got %v", err)
+	}
+	if search, _ := svc.userService.userRepository.GetUserFromEmail(user.Email); search != nil {
+		t.Error("expected the user to not be created")
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"github.com/third-place/user-service/internal/db"
+	"github.com/third-place/user-service/internal/entity"
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/mapper"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/repository"
+	"github.com/third-place/user-service/internal/util"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	maxWaitlistReasonLength = 500
+	maxWaitlistBatch        = 100
+	// waitlistConfirmationLifetime is how long a confirmation link works.
+	// Entries that aren't confirmed by then are deleted, so nobody can hold
+	// on to someone else's address.
+	waitlistConfirmationLifetime = 7 * 24 * time.Hour
+)
+
+type WaitlistService struct {
+	waitlistRepository *repository.WaitlistRepository
+	inviteRepository   *repository.InviteRepository
+	userRepository     *repository.UserRepository
+	securityService    *SecurityService
+	inviteService      *InviteService
+	mailService        *MailService
+	outboxRepository   *repository.OutboxEmailRepository
+	emailDomainService *EmailDomainService
+}
+
+func CreateWaitlistService() *WaitlistService {
+	conn := db.CreateDefaultConnection()
+	return &WaitlistService{
+		repository.CreateWaitlistRepository(conn),
+		repository.CreateInviteRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateSecurityService(),
+		CreateInviteService(),
+		CreateMailService(),
+		repository.CreateOutboxEmailRepository(conn),
+		CreateEmailDomainService(),
+	}
+}
+
+func CreateTestWaitlistService() *WaitlistService {
+	conn := util.SetupTestDatabase()
+	return &WaitlistService{
+		repository.CreateWaitlistRepository(conn),
+		repository.CreateInviteRepository(conn),
+		repository.CreateUserRepository(conn),
+		CreateTestSecurityService(),
+		CreateTestInviteService(),
+		CreateTestMailService(),
+		repository.CreateOutboxEmailRepository(conn),
+		CreateTestEmailDomainService(),
+	}
+}
+
+// JoinWaitlist adds someone without an invite to the waitlist and emails
+// them a link to confirm their address. Only confirmed entries can be
+// approved.
+func (s *WaitlistService) JoinWaitlist(newEntry *model.NewWaitlistEntry) (*model.WaitlistEntry, error) {
+	email := strings.ToLower(strings.TrimSpace(newEntry.Email))
+	if err := s.emailDomainService.CheckEmail(email); err != nil {
+		return nil, err
+	}
+	if len(newEntry.Reason) > maxWaitlistReasonLength {
+		return nil, util.NewInputFieldError(
+			"reason",
+			"reasons can be at most 500 characters",
+		)
+	}
+	if search, _ := s.userRepository.GetUserFromEmail(email); search != nil {
+		return nil, util.NewInputFieldError(
+			"email",
+			"this email address already has an account, try logging in",
+		)
+	}
+	err := s.waitlistRepository.DeleteUnconfirmedBefore(time.Now().Add(-waitlistConfirmationLifetime)).Error
+	if err != nil {
+		return nil, err
+	}
+	if search, _ := s.waitlistRepository.FindOneByEmail(email); search != nil {
+		if search.Status != enum.WaitlistStatusUnconfirmed {
+			return nil, errAlreadyOnWaitlist()
+		}
+		return s.resendWaitlistConfirmation(search, newEntry)
+	}
+	code, err := util.GenerateSecret(24)
+	if err != nil {
+		return nil, err
+	}
+	entry := &entity.WaitlistEntry{
+		Email:                email,
+		Reason:               strings.TrimSpace(newEntry.Reason),
+		Locale:               newEntry.Locale,
+		Status:               enum.WaitlistStatusUnconfirmed,
+		ConfirmationCodeHash: util.HashSecret(code),
+	}
+	err = s.saveWithConfirmationEmail(entry, code)
+	if err != nil {
+		// lost a race with the same address
+		if search, _ := s.waitlistRepository.FindOneByEmail(email); search != nil {
+			return nil, errAlreadyOnWaitlist()
+		}
+		return nil, err
+	}
+	return mapper.MapWaitlistEntryEntityToModel(entry), nil
+}
+
+// resendWaitlistConfirmation replaces the entry's reason and confirmation
+// code with the new ones, since whoever can read the address decides which
+// request to confirm.
+func (s *WaitlistService) resendWaitlistConfirmation(entry *entity.WaitlistEntry, newEntry *model.NewWaitlistEntry) (*model.WaitlistEntry, error) {
+	code, err := util.GenerateSecret(24)
+	if err != nil {
+		return nil, err
+	}
+	entry.Reason = strings.TrimSpace(newEntry.Reason)
+	entry.Locale = newEntry.Locale
+	entry.ConfirmationCodeHash = util.HashSecret(code)
+	err = s.saveWithConfirmationEmail(entry, code)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapWaitlistEntryEntityToModel(entry), nil
+}
+
+// saveWithConfirmationEmail saves the entry and queues the email with its
+// confirmation code in one transaction.
+func (s *WaitlistService) saveWithConfirmationEmail(entry *entity.WaitlistEntry, code string) error {
+	email, err := s.mailService.CreateWaitlistConfirmationEmail(entry, code)
+	if err != nil {
+		log.Print("error rendering waitlist confirmation email :: ", err)
+		return errors.New("error sending waitlist confirmation email")
+	}
+	return s.outboxRepository.SaveWithEmail(entry, email)
+}
+
+// ConfirmWaitlistEntry confirms the address of the entry the code was sent
+// to.
+func (s *WaitlistService) ConfirmWaitlistEntry(confirmation *model.WaitlistConfirmation) (*model.WaitlistEntry, error) {
+	entry, err := s.waitlistRepository.FindOneByConfirmationCodeHash(util.HashSecret(confirmation.Code))
+	if err != nil || time.Since(entry.UpdatedAt) > waitlistConfirmationLifetime {
+		return nil, util.NewInputFieldError(
+			"code",
+			"this confirmation link is invalid or was already used",
+		)
+	}
+	now := time.Now()
+	entry.Status = enum.WaitlistStatusConfirmed
+	entry.ConfirmedAt = &now
+	entry.ConfirmationCodeHash = ""
+	result := s.waitlistRepository.Save(entry)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mapper.MapWaitlistEntryEntityToModel(entry), nil
+}
+
+// GetWaitlist lists entries oldest first, optionally only those with the
+// given status.
+func (s *WaitlistService) GetWaitlist(session *model.Session, status string, offset int) ([]*model.WaitlistEntry, error) {
+	if !s.securityService.Can(session, model.PermissionWaitlistManage, nil) {
+		return nil, errors.New("not allowed")
+	}
+	statusType := enum.WaitlistStatusType(status)
+	if status != "" && !statusType.IsValid() {
+		return nil, util.NewInputFieldError(
+			"status",
+			"status must be unconfirmed, confirmed or invited",
+		)
+	}
+	return mapper.MapWaitlistEntryEntitiesToModels(s.waitlistRepository.FindEntries(statusType, offset)), nil
+}
+
+// ApproveWaitlistBatch invites the oldest confirmed entries. Each gets a
+// single-use invite locked to their address, sent by email like any other
+// email invite.
+func (s *WaitlistService) ApproveWaitlistBatch(session *model.Session, approval *model.WaitlistApproval) (*model.WaitlistBatch, error) {
+	if !s.securityService.Can(session, model.PermissionWaitlistManage, nil) {
+		return nil, errors.New("not allowed")
+	}
+	if approval.Count < 1 || approval.Count > maxWaitlistBatch {
+		return nil, util.NewInputFieldError(
+			"count",
+			"batches must be between 1 and 100 entries",
+		)
+	}
+	admin, err := getSessionUser(s.userRepository, session)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.waitlistRepository.ApproveBatch(approval.Count, admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	batch := &model.WaitlistBatch{Invited: []*model.WaitlistEntry{}, Failed: []string{}}
+	for _, entry := range entries {
+		invite, err := s.inviteEntry(admin, entry)
+		if err != nil {
+			log.Print("error inviting waitlist entry :: ", err)
+			entry.Status = enum.WaitlistStatusConfirmed
+			entry.ApprovedByID = nil
+			entry.InvitedAt = nil
+			s.waitlistRepository.Save(entry)
+			batch.Failed = append(batch.Failed, entry.Email)
+			continue
+		}
+		entry.InviteID = &invite.ID
+		s.waitlistRepository.Save(entry)
+		batch.Invited = append(batch.Invited, mapper.MapWaitlistEntryEntityToModel(entry))
+	}
+	return batch, nil
+}
+
+func (s *WaitlistService) inviteEntry(admin *entity.User, entry *entity.WaitlistEntry) (*entity.Invite, error) {
+	code, err := s.inviteService.generateCode()
+	if err != nil {
+		return nil, err
+	}
+	invite, err := buildInvite(admin, code, &model.NewInvite{Email: entry.Email})
+	if err != nil {
+		return nil, err
+	}
+	result := s.inviteRepository.Create(invite)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	err = s.inviteService.sendInvite(admin, invite, &model.EmailInvite{
+		Email:  entry.Email,
+		Locale: entry.Locale,
+	})
+	if err != nil {
+		s.inviteRepository.Delete(invite)
+		return nil, err
+	}
+	return invite, nil
+}
+
+func errAlreadyOnWaitlist() error {
+	return util.NewInputFieldError(
+		"email",
+		"this email address is already on the waitlist",
+	)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"github.com/third-place/user-service/internal/enum"
+	"github.com/third-place/user-service/internal/model"
+	"github.com/third-place/user-service/internal/util"
+	"strings"
+	"testing"
+	"time"
+)
+
+func joinConfirmedWaitlist(waitlistService *WaitlistService) string {
+	emailAddr := util.RandomEmailAddress()
+	_, _ = waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: emailAddr})
+	entry, _ := waitlistService.waitlistRepository.FindOneByEmail(emailAddr)
+	entry.ConfirmationCodeHash = util.HashSecret("confirm-" + emailAddr)
+	waitlistService.waitlistRepository.Save(entry)
+	_, _ = waitlistService.ConfirmWaitlistEntry(&model.WaitlistConfirmation{Code: "confirm-" + emailAddr})
+	return emailAddr
+}
+
+func Test_Waitlist_Entry_Is_Confirmed_With_Its_Code(t *testing.T) {
+	// setup
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	joined, err := waitlistService.JoinWaitlist(&model.NewWaitlistEntry{
+		Email:  emailAddr,
+		Reason: "my friends are here",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, _ := waitlistService.waitlistRepository.FindOneByEmail(emailAddr)
+	entry.ConfirmationCodeHash = util.HashSecret("known-code")
+	waitlistService.waitlistRepository.Save(entry)
+
+	// when
+	_, wrongErr := waitlistService.ConfirmWaitlistEntry(&model.WaitlistConfirmation{Code: "wrong-code"})
+	confirmed, err := waitlistService.ConfirmWaitlistEntry(&model.WaitlistConfirmation{Code: "known-code"})
+
+	// then
+	if joined.Status != string(enum.WaitlistStatusUnconfirmed) {
+		t.Error("expected new entries to be unconfirmed")
+	}
+	if wrongErr == nil {
+		t.Error("expected a wrong code to be rejected")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed.Status != string(enum.WaitlistStatusConfirmed) || confirmed.ConfirmedAt == nil {
+		t.Error("expected the entry to be confirmed")
+	}
+}
+
+func Test_Waitlist_Rejects_Duplicates_And_Registered_Addresses(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	user, _ := svc.CreateUserWithRole(model.USER)
+	emailAddr := joinConfirmedWaitlist(waitlistService)
+
+	// when
+	_, duplicateErr := waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: " " + emailAddr})
+	_, registeredErr := waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: user.Email})
+
+	// then
+	if duplicateErr == nil {
+		t.Error("expected duplicate entries to be rejected")
+	}
+	if registeredErr == nil {
+		t.Error("expected registered addresses to be rejected")
+	}
+}
+
+func Test_Waitlist_Rejects_Registered_Addresses_In_Any_Case(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	emailAddr := "Alice." + util.RandomEmailAddress()
+	_, err := svc.CreateInvitedUser(&model.NewUser{
+		Username: util.RandomUsername(),
+		Email:    emailAddr,
+		Password: dummyPassword,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	_, err = waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: strings.ToLower(emailAddr)})
+
+	// then
+	if err == nil {
+		t.Error("expected an address registered with capitals to be rejected")
+	}
+}
+
+func Test_Joining_Again_Replaces_An_Unconfirmed_Entry(t *testing.T) {
+	// setup
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	_, _ = waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: emailAddr, Reason: "squatting"})
+	entry, _ := waitlistService.waitlistRepository.FindOneByEmail(emailAddr)
+	entry.ConfirmationCodeHash = util.HashSecret("old-code")
+	waitlistService.waitlistRepository.Save(entry)
+
+	// when
+	_, err := waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: emailAddr, Reason: "my friends are here"})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, _ = waitlistService.waitlistRepository.FindOneByEmail(emailAddr)
+	if entry.Reason != "my friends are here" {
+		t.Error("expected the reason to be replaced")
+	}
+	_, err = waitlistService.ConfirmWaitlistEntry(&model.WaitlistConfirmation{Code: "old-code"})
+	if err == nil {
+		t.Error("expected the old confirmation code to stop working")
+	}
+}
+
+func Test_Stale_Unconfirmed_Entries_Expire(t *testing.T) {
+	// setup
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	emailAddr := util.RandomEmailAddress()
+	_, _ = waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: emailAddr})
+	entry, _ := waitlistService.waitlistRepository.FindOneByEmail(emailAddr)
+	entry.ConfirmationCodeHash = util.HashSecret("stale-code")
+	waitlistService.waitlistRepository.Save(entry)
+	util.SetupTestDatabase().Model(entry).
+		UpdateColumn("updated_at", time.Now().Add(-waitlistConfirmationLifetime-time.Minute))
+
+	// when
+	_, confirmErr := waitlistService.ConfirmWaitlistEntry(&model.WaitlistConfirmation{Code: "stale-code"})
+	_, _ = waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: util.RandomEmailAddress()})
+
+	// then
+	if confirmErr == nil {
+		t.Error("expected a stale confirmation code to be rejected")
+	}
+	if search, _ := waitlistService.waitlistRepository.FindOneByEmail(emailAddr); search != nil {
+		t.Error("expected the stale entry to be deleted")
+	}
+}
+
+func Test_Approving_A_Batch_Invites_Confirmed_Entries(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	admin, adminSession := svc.CreateUserWithRole(model.ADMIN)
+	confirmedAddr := joinConfirmedWaitlist(waitlistService)
+	unconfirmedAddr := util.RandomEmailAddress()
+	_, _ = waitlistService.JoinWaitlist(&model.NewWaitlistEntry{Email: unconfirmedAddr})
+
+	// when
+	batch, err := waitlistService.ApproveWaitlistBatch(adminSession, &model.WaitlistApproval{Count: maxWaitlistBatch})
+
+	// then
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Invited) == 0 {
+		t.Fatal("expected the batch to invite someone")
+	}
+	confirmed, _ := waitlistService.waitlistRepository.FindOneByEmail(confirmedAddr)
+	if confirmed.Status != enum.WaitlistStatusInvited || confirmed.InviteID == nil {
+		t.Fatal("expected the confirmed entry to be invited")
+	}
+	sent := false
+	for _, invite := range waitlistService.inviteRepository.FindForCreator(admin.ID, 0) {
+		if invite.ID == *confirmed.InviteID {
+			sent = invite.Email == confirmedAddr && invite.Status == enum.InviteStatusSent
+		}
+	}
+	if !sent {
+		t.Error("expected an email invite locked to the entry's address")
+	}
+	unconfirmed, _ := waitlistService.waitlistRepository.FindOneByEmail(unconfirmedAddr)
+	if unconfirmed.Status != enum.WaitlistStatusUnconfirmed {
+		t.Error("expected unconfirmed entries to be left waiting")
+	}
+}
+
+func Test_Only_Admins_Can_Manage_The_Waitlist(t *testing.T) {
+	// setup
+	svc := CreateTestService()
+	waitlistService := CreateTestWaitlistService()
+
+	// given
+	_, moderatorSession := svc.CreateUserWithRole(model.MODERATOR)
+
+	// when
+	_, listErr := waitlistService.GetWaitlist(moderatorSession, "", 0)
+	_, approveErr := waitlistService.ApproveWaitlistBatch(moderatorSession, &model.WaitlistApproval{Count: 1})
+
+	// then
+	if listErr == nil || approveErr == nil {
+		t.Error("expected moderators not to manage the waitlist")
+	}
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"strings"
+)
+
+const accessTokenPrefix = "tpat_"
+
+// GenerateAccessToken returns a new personal access token and the prefix
+// that identifies it. Only the hash of the token should be stored.
+func GenerateAccessToken() (string, string, error) {
+	secret, err := GenerateSecret(24)
+	if err != nil {
+		return "", "", err
+	}
+	token := accessTokenPrefix + secret
+	return token, token[:len(accessTokenPrefix)+8], nil
+}
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, accessTokenPrefix)
+}
+
+func HashAccessToken(token string) string {
+	return HashSecret(token)
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/third-place/user-service/internal/model"
+	"net"
+)
+
+func GetClientInfo(c *gin.Context) model.ClientInfo {
+	return model.ClientInfo{
+		IpAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+		DeviceId:  c.GetHeader("x-device-id"),
+	}
+}
+
+// GetIpRange returns the network an address belongs to, a /24 for IPv4 and
+// a /48 for IPv6, so that logins from the same network look alike.
+func GetIpRange(ip string) string {
+	address := net.ParseIP(ip)
+	if address == nil {
+		return ""
+	}
+	if v4 := address.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: address.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// GetDeviceFingerprint identifies the client's device by the id it sends,
+// falling back to its user agent.
+func GetDeviceFingerprint(client model.ClientInfo) string {
+	if client.DeviceId != "" {
+		return HashSecret("device:" + client.DeviceId)
+	}
+	return HashSecret("agent:" + client.UserAgent)
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	SessionCookieName     = "session_token"
+	CsrfCookieName        = "csrf_token"
+	CsrfHeaderName        = "x-csrf-token"
+	SocialNonceCookieName = "social_nonce"
+	sessionCookieAge      = 7 * 24 * time.Hour
+)
+
+var cookieDomain = os.Getenv("SESSION_COOKIE_DOMAIN")
+
+// SetSessionCookies stores the session token in an HttpOnly cookie that
+// scripts can't read, next to a CSRF cookie that the web client reads and
+// sends back in the x-csrf-token header. It returns the CSRF token.
+func SetSessionCookies(c *gin.Context, sessionToken string) string {
+	csrfToken := GetCsrfToken(sessionToken)
+	setCookie(c, SessionCookieName, sessionToken, int(sessionCookieAge.Seconds()), true)
+	setCookie(c, CsrfCookieName, csrfToken, int(sessionCookieAge.Seconds()), false)
+	return csrfToken
+}
+
+func ClearSessionCookies(c *gin.Context) {
+	setCookie(c, SessionCookieName, "", -1, true)
+	setCookie(c, CsrfCookieName, "", -1, false)
+}
+
+// GetCsrfToken derives the CSRF token from the session token, so a cookie
+// planted by another site can't be paired with a made up header.
+func GetCsrfToken(sessionToken string) string {
+	mac := hmac.New(sha256.New, JwtKey)
+	mac.Write([]byte("csrf:" + sessionToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsCookieSession is true when the session token is read from the cookie,
+// which is the case unless a header carries one.
+func IsCookieSession(c *gin.Context) bool {
+	return getHeaderSessionToken(c) == "" && getSessionCookie(c) != ""
+}
+
+// CheckCsrfToken checks that the x-csrf-token header matches both the CSRF
+// cookie and the session cookie.
+func CheckCsrfToken(c *gin.Context) bool {
+	header := c.GetHeader(CsrfHeaderName)
+	cookie, err := c.Cookie(CsrfCookieName)
+	if header == "" || err != nil {
+		return false
+	}
+	expected := GetCsrfToken(getSessionCookie(c))
+	return hmac.Equal([]byte(header), []byte(cookie)) && hmac.Equal([]byte(header), []byte(expected))
+}
+
+// SetSocialNonceCookie keeps the nonce of a social sign-in in the browser
+// that started it, so another site can't complete a sign-in of its own in
+// this browser.
+func SetSocialNonceCookie(c *gin.Context, nonce string, maxAge time.Duration) {
+	setCookie(c, SocialNonceCookieName, nonce, int(maxAge.Seconds()), true)
+}
+
+func GetSocialNonceCookie(c *gin.Context) string {
+	nonce, err := c.Cookie(SocialNonceCookieName)
+	if err != nil {
+		return ""
+	}
+	return nonce
+}
+
+func ClearSocialNonceCookie(c *gin.Context) {
+	setCookie(c, SocialNonceCookieName, "", -1, true)
+}
+
+func getSessionCookie(c *gin.Context) string {
+	sessionToken, err := c.Cookie(SessionCookieName)
+	if err != nil {
+		return ""
+	}
+	return sessionToken
+}
+
+func setCookie(c *gin.Context, name string, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cookieDomain,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
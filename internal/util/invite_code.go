@@ -1,18 +1,33 @@
 package util
 
-import "math/rand"
+import (
+	"crypto/rand"
+	"math/big"
+)
 
-var numbers = []rune("0123456789")
-var letters = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+var codeAlphabet = []rune("ABCDEFGHJKLMNPQRSTUVWXYZ23456789")
 
+// GenerateCode returns a code with 80 random bits, in four groups of four
+// letters and digits that are hard to mix up. Codes are used for invites,
+// some of which can be used many times, and for one-time passwords, so they
+// have to be too long to guess.
 func GenerateCode() string {
-	l := make([]rune, 3)
-	n := make([]rune, 3)
-	for i := range l {
-		l[i] = letters[rand.Intn(len(letters))]
+	code := make([]rune, 0, 19)
+	for i := 0; i < 16; i++ {
+		if i > 0 && i%4 == 0 {
+			code = append(code, '-')
+		}
+		code = append(code, codeAlphabet[randomIndex(len(codeAlphabet))])
 	}
-	for i := range n {
-		n[i] = numbers[rand.Intn(len(numbers))]
+	return string(code)
+}
+
+// randomIndex picks an index below n with crypto/rand, since codes are
+// used as secrets.
+func randomIndex(n int) int64 {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
 	}
-	return string(l) + "-" + string(n)
+	return i.Int64()
 }
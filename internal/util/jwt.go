@@ -1,5 +1,15 @@
 package util
 
-import "os"
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"os"
+	"time"
+)
 
 var JwtKey = []byte(os.Getenv("JWT_KEY"))
+
+func init() {
+	// Tokens carry their issue time to the millisecond, so revoking sessions
+	// also catches tokens issued earlier in the same second.
+	jwt.TimePrecision = time.Millisecond
+}
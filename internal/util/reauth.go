@@ -0,0 +1,36 @@
+package util
+
+import (
+	"github.com/third-place/user-service/internal/model"
+	"time"
+)
+
+const ReauthRequiredCode = "reauth_required"
+
+// ReauthRequiredError tells the client to send the user through
+// POST /session/reauth, or through a linked identity when they have no
+// password, and retry with the new token.
+type ReauthRequiredError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// MaxAge is how many seconds ago the user may have last authenticated.
+	MaxAge int `json:"max_age"`
+}
+
+func (e *ReauthRequiredError) Error() string {
+	return e.Message
+}
+
+// RequireRecentAuth returns a ReauthRequiredError unless the session user
+// entered their credentials within maxAge. Sessions from tokens that weren't
+// issued by logging in, like access tokens and impersonation, never qualify.
+func RequireRecentAuth(session *model.Session, maxAge time.Duration) error {
+	if session != nil && session.AuthTime != nil && time.Since(*session.AuthTime) <= maxAge {
+		return nil
+	}
+	return &ReauthRequiredError{
+		Code:    ReauthRequiredCode,
+		Message: "please sign in again to continue",
+		MaxAge:  int(maxAge.Seconds()),
+	}
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"crypto/subtle"
+)
+
+const clientIdPrefix = "tpsa_"
+
+// GenerateClientCredentials returns a new client id and secret for a service
+// account. Only the hash of the secret should be stored.
+func GenerateClientCredentials() (string, string, error) {
+	id, err := GenerateSecret(8)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := GenerateSecret(32)
+	if err != nil {
+		return "", "", err
+	}
+	return clientIdPrefix + id, secret, nil
+}
+
+func HashClientSecret(secret string) string {
+	return HashSecret(secret)
+}
+
+func CheckClientSecret(secret string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashClientSecret(secret)), []byte(hash)) == 1
+}
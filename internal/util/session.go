@@ -1,15 +1,16 @@
 package util
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/google/uuid"
 	"github.com/third-place/user-service/internal/model"
+	"strings"
 )
 
+// GetSessionTokenModel reads the session token from the x-session-token
+// header, from an Authorization: Bearer header, or from the session cookie.
+// Any of them can hold a session JWT or a personal access token.
 func GetSessionTokenModel(c *gin.Context) *model.SessionToken {
-	sessionToken := c.GetHeader("x-session-token")
+	sessionToken := getSessionToken(c)
 	if sessionToken == "" {
 		return nil
 	}
@@ -18,26 +19,23 @@ func GetSessionTokenModel(c *gin.Context) *model.SessionToken {
 	}
 }
 
-func GetSession(c *gin.Context) (*model.Session, error) {
-	sessionToken := c.GetHeader("x-session-token")
-	claims := &model.Claims{}
-	token, err := jwt.ParseWithClaims(sessionToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return JwtKey, nil
-	})
-	if err != nil {
-		return nil, err
+func getSessionToken(c *gin.Context) string {
+	if sessionToken := getHeaderSessionToken(c); sessionToken != "" {
+		return sessionToken
 	}
-	if !token.Valid {
-		return nil, errors.New("token not valid")
+	return getSessionCookie(c)
+}
+
+// getHeaderSessionToken reads the session token from the x-session-token
+// header or a bearer Authorization header. Other headers are ignored.
+func getHeaderSessionToken(c *gin.Context) string {
+	sessionToken := c.GetHeader("x-session-token")
+	if sessionToken != "" {
+		return sessionToken
 	}
-	_, err = uuid.Parse(claims.UserUuid)
-	if err != nil {
-		return nil, err
+	authorization := c.GetHeader("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+		return strings.TrimSpace(authorization[7:])
 	}
-	return &model.Session{
-		User: &model.User{
-			Uuid: claims.UserUuid,
-		},
-		Token: sessionToken,
-	}, nil
+	return ""
 }